@@ -137,6 +137,8 @@ func (a *Agent) Run(ctx context.Context) error {
 
 	startTime := time.Now()
 
+	a.restoreSpilled()
+
 	log.Printf("D! [agent] Connecting outputs")
 	next, ou, err := a.startOutputs(ctx, a.Config.Outputs)
 	if err != nil {
@@ -837,7 +839,13 @@ func (a *Agent) runOutputs(
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	for _, output := range unit.outputs {
+	// Keep track of the outputs finishing their last flush
+	finished := make([]chan struct{}, len(unit.outputs))
+	for i := range finished {
+		finished[i] = make(chan struct{})
+	}
+
+	for i, output := range unit.outputs {
 		interval := interval
 		// Overwrite agent flush_interval if this plugin has its own.
 		if output.Config.FlushInterval != 0 {
@@ -851,14 +859,15 @@ func (a *Agent) runOutputs(
 		}
 
 		wg.Add(1)
-		go func(output *models.RunningOutput) {
+		go func(output *models.RunningOutput, done chan struct{}) {
 			defer wg.Done()
+			defer close(done)
 
 			ticker := NewRollingTicker(interval, jitter)
 			defer ticker.Stop()
 
 			a.flushLoop(ctx, output, ticker)
-		}(output)
+		}(output, finished[i])
 	}
 
 	for metric := range unit.src {
@@ -873,10 +882,92 @@ func (a *Agent) runOutputs(
 
 	log.Println("I! [agent] Hang on, flushing any cached metrics before shutdown")
 	cancel()
-	wg.Wait()
+
+	// Wait for the outputs to finish flushing but only up to the configured
+	// shutdown timeout
+	if timeout := time.Duration(a.Config.Agent.ShutdownTimeout); timeout > 0 {
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(timeout):
+			log.Printf("W! [agent] Shutdown timeout of %s exceeded, not waiting for outputs to finish flushing", timeout)
+		}
+	} else {
+		wg.Wait()
+	}
+
+	// Apply the drain policy and only stop the outputs that finished writing
+	// as closing an output while writing is not allowed.
+	stopped := make([]*models.RunningOutput, 0, len(unit.outputs))
+	for i, output := range unit.outputs {
+		var flushed bool
+		select {
+		case <-finished[i]:
+			flushed = true
+			stopped = append(stopped, output)
+		default:
+		}
+		drainOutput(output, flushed)
+	}
 
 	log.Println("I! [agent] Stopping running outputs")
-	stopRunningOutputs(unit.outputs)
+	stopRunningOutputs(stopped)
+}
+
+// restoreSpilled adds the metrics spilled on a previous shutdown to the
+// buffers of the outputs.
+func (a *Agent) restoreSpilled() {
+	for _, output := range a.Config.Outputs {
+		n, err := output.RestoreSpilled()
+		if err != nil {
+			log.Printf("E! [agent] Restoring spilled metrics of [%s] failed: %v", output.LogName(), err)
+		} else if n > 0 {
+			log.Printf("I! [agent] Restored %d spilled metrics of [%s]", n, output.LogName())
+		}
+	}
+}
+
+// drainOutput applies the drain policy of the output to the metrics remaining
+// in the output's buffer after the last flush on shutdown and logs the result.
+func drainOutput(output *models.RunningOutput, flushed bool) {
+	if !flushed {
+		log.Printf("W! [agent] [%s] did not finish writing before shutdown, metrics being written might be lost",
+			output.LogName())
+	}
+
+	remaining := output.BufferLength()
+	if remaining == 0 {
+		log.Printf("I! [agent] [%s] drained successfully, no metrics remaining", output.LogName())
+		return
+	}
+
+	// The disk buffer already persists all metrics
+	if output.Config.BufferStrategy == "disk" {
+		log.Printf("I! [agent] [%s] drained with %d metrics remaining in the disk buffer", output.LogName(), remaining)
+		return
+	}
+
+	switch output.Config.DrainPolicy {
+	case "spill":
+		n, err := output.Spill()
+		if err != nil {
+			log.Printf("E! [agent] [%s] spilling metrics failed after spilling %d of %d metrics: %v",
+				output.LogName(), n, remaining, err)
+			return
+		}
+		log.Printf("I! [agent] [%s] drained with %d of %d remaining metrics spilled to %q",
+			output.LogName(), n, remaining, output.Config.BufferDirectory)
+	case "discard":
+		n := output.Discard()
+		log.Printf("I! [agent] [%s] drained with %d remaining metrics discarded", output.LogName(), n)
+	default:
+		log.Printf("W! [agent] [%s] drained with %d remaining metrics lost", output.LogName(), remaining)
+	}
 }
 
 // flushLoop runs an output's flush function periodically until the context is
@@ -1104,6 +1195,8 @@ func (a *Agent) runOnce(ctx context.Context, wait time.Duration) error {
 
 	startTime := time.Now()
 
+	a.restoreSpilled()
+
 	log.Printf("D! [agent] Connecting outputs")
 	next, ou, err := a.startOutputs(ctx, a.Config.Outputs)
 	if err != nil {
@@ -228,6 +228,69 @@ func TestCases(t *testing.T) {
 	}
 }
 
+func TestShutdownTimeout(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Agent.FlushInterval = config.Duration(time.Hour)
+	cfg.Agent.ShutdownTimeout = config.Duration(100 * time.Millisecond)
+	a := NewAgent(cfg)
+
+	// Block the output on the first write to force the shutdown timeout
+	plugin := &blockingOutput{release: make(chan struct{})}
+	defer close(plugin.release)
+	output := models.NewRunningOutput(plugin, &models.OutputConfig{
+		Name:            "blocking",
+		ID:              "blocking",
+		DrainPolicy:     "spill",
+		BufferDirectory: t.TempDir(),
+	}, 1, 100)
+	require.NoError(t, output.Init())
+
+	src := make(chan telegraf.Metric, 2)
+	src <- testutil.TestMetric(1)
+	src <- testutil.TestMetric(2)
+	close(src)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.runOutputs(&outputUnit{src: src, outputs: []*models.RunningOutput{output}})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "shutdown did not finish within the timeout")
+	}
+
+	// The metric not being written must have been spilled
+	restored := models.NewRunningOutput(&blockingOutput{}, output.Config, 1, 100)
+	require.NoError(t, restored.Init())
+	n, err := restored.RestoreSpilled()
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+}
+
+type blockingOutput struct {
+	release chan struct{}
+}
+
+func (*blockingOutput) SampleConfig() string {
+	return ""
+}
+
+func (*blockingOutput) Connect() error {
+	return nil
+}
+
+func (*blockingOutput) Close() error {
+	return nil
+}
+
+func (o *blockingOutput) Write([]telegraf.Metric) error {
+	<-o.release
+	return nil
+}
+
 // Implement a "test-mode" like call but collect the metrics
 func collect(ctx context.Context, a *Agent, wait time.Duration) ([]telegraf.Metric, error) {
 	var received []telegraf.Metric
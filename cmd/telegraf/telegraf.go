@@ -24,6 +24,7 @@ import (
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/logger"
 	"github.com/influxdata/telegraf/plugins/aggregators"
+	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
@@ -388,6 +389,15 @@ func (t *Telegraf) runAgent(ctx context.Context, reloadConfig bool) error {
 		RotationMaxSize:         int64(c.Agent.LogfileRotationMaxSize),
 		RotationMaxArchives:     c.Agent.LogfileRotationMaxArchives,
 		LogWithTimezone:         c.Agent.LogWithTimezone,
+		RemoteAddress:           c.Agent.LogRemoteAddress,
+		RemoteTLS: tls.ClientConfig{
+			TLSCA:              c.Agent.LogRemoteTLSCA,
+			TLSCert:            c.Agent.LogRemoteTLSCert,
+			TLSKey:             c.Agent.LogRemoteTLSKey,
+			InsecureSkipVerify: c.Agent.LogRemoteInsecureSkipVerify,
+		},
+		RemoteBufferSize: c.Agent.LogRemoteBufferSize,
+		DedupWindow:      time.Duration(c.Agent.LogDedupWindow),
 	}
 
 	if err := logger.SetupLogging(logConfig); err != nil {
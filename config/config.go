@@ -237,7 +237,7 @@ type AgentConfig struct {
 	LogTarget string `toml:"logtarget" deprecated:"1.32.0;1.40.0;use 'logformat' and 'logfile' instead"`
 
 	// Log format controls the way messages are logged and can be one of "text",
-	// "structured" or, on Windows, "eventlog".
+	// "structured", "syslog", "otlp" or, on Windows, "eventlog".
 	LogFormat string `toml:"logformat"`
 
 	// Name of the file to be logged to or stderr if empty. Ignored for "eventlog" format.
@@ -262,6 +262,24 @@ type AgentConfig struct {
 	// Pick a timezone to use when logging or type 'local' for local time.
 	LogWithTimezone string `toml:"log_with_timezone"`
 
+	// Address of the remote destination for the "syslog" and "otlp" log
+	// formats, e.g. "udp://localhost:514" or "grpc://localhost:4317".
+	LogRemoteAddress string `toml:"log_remote_address"`
+
+	// TLS settings for connecting to the remote log destination.
+	LogRemoteTLSCA              string `toml:"log_remote_tls_ca"`
+	LogRemoteTLSCert            string `toml:"log_remote_tls_cert"`
+	LogRemoteTLSKey             string `toml:"log_remote_tls_key"`
+	LogRemoteInsecureSkipVerify bool   `toml:"log_remote_insecure_skip_verify"`
+
+	// Maximum number of messages buffered for the remote log destination.
+	// Messages are dropped if the buffer is full.
+	LogRemoteBufferSize int `toml:"log_remote_buffer_size"`
+
+	// Window for suppressing repeated log messages of the same plugin with
+	// the same message template. Zero disables the suppression.
+	LogDedupWindow Duration `toml:"log_dedup_window"`
+
 	Hostname     string
 	OmitHostname bool
 
@@ -299,6 +317,11 @@ type AgentConfig struct {
 	// BufferDirectory is the directory to store buffer files for serialized
 	// to disk metrics when using the "disk" buffer strategy.
 	BufferDirectory string `toml:"buffer_directory"`
+
+	// ShutdownTimeout is the maximum time to wait for outputs to write the
+	// buffered metrics on shutdown. After the timeout the outputs' drain
+	// policy is applied to the remaining metrics. Zero means no limit.
+	ShutdownTimeout Duration `toml:"shutdown_timeout"`
 }
 
 // InputNames returns a list of strings of the configured inputs.
@@ -963,7 +986,36 @@ func (c *Config) addAggregator(name, source string, table *ast.Table) error {
 		return err
 	}
 
-	c.Aggregators = append(c.Aggregators, models.NewRunningAggregator(aggregator, conf))
+	// Overlapping windows require a separate aggregator instance per window
+	instances := make([]telegraf.Aggregator, 0, conf.WindowCount()-1)
+	for range conf.WindowCount() - 1 {
+		instance := creator()
+		if err := c.toml.UnmarshalTable(table, instance); err != nil {
+			return err
+		}
+		instances = append(instances, instance)
+	}
+	ra := models.NewRunningAggregator(aggregator, conf, instances...)
+
+	// Event-time aggregation opens windows depending on the metric time so
+	// instances are created on demand. Unknown options were already reported
+	// for the first instance.
+	if conf.EventTime {
+		tomlCfg := &toml.Config{
+			NormFieldName: toml.DefaultConfig.NormFieldName,
+			FieldToKey:    toml.DefaultConfig.FieldToKey,
+			MissingField:  func(reflect.Type, string) error { return nil },
+		}
+		ra.SetInstanceCreator(func() (telegraf.Aggregator, error) {
+			instance := creator()
+			if err := tomlCfg.UnmarshalTable(table, instance); err != nil {
+				return nil, err
+			}
+			return instance, nil
+		})
+	}
+
+	c.Aggregators = append(c.Aggregators, ra)
 	return nil
 }
 
@@ -1455,6 +1507,14 @@ func (c *Config) buildAggregator(name, source string, tbl *ast.Table) (*models.A
 	if grace, found := c.getFieldDuration(tbl, "grace"); found {
 		conf.Grace = grace
 	}
+	conf.Window = c.getFieldString(tbl, "window")
+	conf.Advance, _ = c.getFieldDuration(tbl, "advance")
+	conf.WindowTimestamp = c.getFieldString(tbl, "window_timestamp")
+	conf.EventTime = c.getFieldBool(tbl, "event_time")
+	conf.AllowedLateness, _ = c.getFieldDuration(tbl, "allowed_lateness")
+	conf.LateUpdateWindow, _ = c.getFieldDuration(tbl, "late_update_window")
+	conf.MaxOpenWindows = c.getFieldInt(tbl, "max_open_windows")
+	conf.FutureTolerance, _ = c.getFieldDuration(tbl, "future_tolerance")
 
 	conf.DropOriginal = c.getFieldBool(tbl, "drop_original")
 	conf.MeasurementPrefix = c.getFieldString(tbl, "name_prefix")
@@ -1612,6 +1672,7 @@ func (c *Config) buildInput(name, source string, tbl *ast.Table) (*models.InputC
 	cp.CollectionOffset, _ = c.getFieldDuration(tbl, "collection_offset")
 	cp.StartupErrorBehavior = c.getFieldString(tbl, "startup_error_behavior")
 	cp.TimeSource = c.getFieldString(tbl, "time_source")
+	cp.Priority = c.getFieldString(tbl, "priority")
 
 	cp.MeasurementPrefix = c.getFieldString(tbl, "name_prefix")
 	cp.MeasurementSuffix = c.getFieldString(tbl, "name_suffix")
@@ -1666,12 +1727,14 @@ func (c *Config) buildOutput(name, source string, tbl *ast.Table) (*models.Outpu
 	oc.FlushJitter, _ = c.getFieldDuration(tbl, "flush_jitter")
 	oc.MetricBufferLimit = c.getFieldInt(tbl, "metric_buffer_limit")
 	oc.MetricBatchSize = c.getFieldInt(tbl, "metric_batch_size")
+	oc.MaxInFlight = c.getFieldInt(tbl, "max_in_flight")
 	oc.Alias = c.getFieldString(tbl, "alias")
 	oc.NameOverride = c.getFieldString(tbl, "name_override")
 	oc.NameSuffix = c.getFieldString(tbl, "name_suffix")
 	oc.NamePrefix = c.getFieldString(tbl, "name_prefix")
 	oc.StartupErrorBehavior = c.getFieldString(tbl, "startup_error_behavior")
 	oc.LogLevel = c.getFieldString(tbl, "log_level")
+	oc.DrainPolicy = c.getFieldString(tbl, "drain_policy")
 
 	if c.hasErrs() {
 		return nil, c.firstErr()
@@ -1689,19 +1752,22 @@ func (c *Config) buildOutput(name, source string, tbl *ast.Table) (*models.Outpu
 func (c *Config) missingTomlField(_ reflect.Type, key string) error {
 	switch key {
 	// General options to ignore
-	case "alias", "always_include_local_tags",
+	case "advance", "alias", "allowed_lateness", "always_include_local_tags",
 		"buffer_strategy", "buffer_directory",
 		"collection_jitter", "collection_offset",
-		"data_format", "delay", "drop", "drop_original",
+		"data_format", "delay", "drain_policy", "drop", "drop_original",
+		"event_time",
 		"fielddrop", "fieldexclude", "fieldinclude", "fieldpass", "flush_interval", "flush_jitter",
+		"future_tolerance",
 		"grace",
 		"interval",
-		"log_level", "lvm", // What is this used for?
-		"metric_batch_size", "metric_buffer_limit", "metricpass",
+		"late_update_window", "log_level", "lvm", // What is this used for?
+		"max_in_flight", "max_open_windows", "metric_batch_size", "metric_buffer_limit", "metricpass",
 		"name_override", "name_prefix", "name_suffix", "namedrop", "namedrop_separator", "namepass", "namepass_separator",
 		"order",
-		"pass", "period", "precision",
-		"tagdrop", "tagexclude", "taginclude", "tagpass", "tags", "startup_error_behavior":
+		"pass", "period", "precision", "priority",
+		"tagdrop", "tagexclude", "taginclude", "tagpass", "tags", "startup_error_behavior",
+		"window", "window_timestamp":
 
 	// Secret-store options to ignore
 	case "id":
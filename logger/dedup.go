@@ -0,0 +1,164 @@
+package logger
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// dedupKey identifies a message of a specific plugin instance
+type dedupKey struct {
+	prefix   string
+	level    telegraf.LogLevel
+	template string
+}
+
+// dedupEntry keeps track of the suppressed repetitions of a message
+type dedupEntry struct {
+	start      time.Time
+	suppressed uint64
+	last       *entry
+}
+
+// deduplicator suppresses repeated messages of the same plugin instance and
+// message template within the given window. Suppressed messages are
+// summarized when the window expires.
+type deduplicator struct {
+	window   time.Duration
+	output   sink
+	timezone *time.Location
+	entries  map[dedupKey]*dedupEntry
+
+	done chan struct{}
+	wg   sync.WaitGroup
+	sync.Mutex
+}
+
+func newDeduplicator(window time.Duration, output sink, tz *time.Location) *deduplicator {
+	d := &deduplicator{
+		window:   window,
+		output:   output,
+		timezone: tz,
+		entries:  make(map[dedupKey]*dedupEntry),
+		done:     make(chan struct{}),
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.run()
+	}()
+
+	return d
+}
+
+// check returns true if the message should be printed and false if it is a
+// repetition within the current window and should be suppressed. An empty
+// template causes the message itself to be used as template.
+func (d *deduplicator) check(level telegraf.LogLevel, ts time.Time, prefix string, attr map[string]interface{}, template string, args ...interface{}) bool {
+	// Copy the attributes as the entry might be printed as part of a summary
+	// later while the caller's map is modified
+	e := &entry{
+		timestamp:  ts,
+		level:      level,
+		prefix:     prefix,
+		attributes: maps.Clone(attr),
+		args:       args,
+	}
+	if template == "" {
+		template = e.message()
+	}
+	key := dedupKey{prefix: prefix, level: level, template: template}
+
+	d.Lock()
+	current, found := d.entries[key]
+	if !found {
+		d.entries[key] = &dedupEntry{start: ts, last: e}
+		d.Unlock()
+		return true
+	}
+	if ts.Sub(current.start) < d.window {
+		current.suppressed++
+		current.last = e
+		d.Unlock()
+		return false
+	}
+
+	// The window expired so output the summary of the previous window before
+	// starting a new one with the current message
+	summary := current.summary(d.window)
+	current.start = ts
+	current.suppressed = 0
+	current.last = e
+	d.Unlock()
+
+	if summary != nil {
+		d.print(summary)
+	}
+	return true
+}
+
+// flush outputs the summaries of all expired windows or of all windows if
+// force is set and forgets about those messages
+func (d *deduplicator) flush(now time.Time, force bool) {
+	var summaries []*entry
+
+	d.Lock()
+	for key, current := range d.entries {
+		if !force && now.Sub(current.start) < d.window {
+			continue
+		}
+		if summary := current.summary(d.window); summary != nil {
+			summary.timestamp = now
+			summaries = append(summaries, summary)
+		}
+		delete(d.entries, key)
+	}
+	d.Unlock()
+
+	for _, summary := range summaries {
+		d.print(summary)
+	}
+}
+
+func (d *deduplicator) run() {
+	ticker := time.NewTicker(d.window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.done:
+			return
+		case now := <-ticker.C:
+			d.flush(now, false)
+		}
+	}
+}
+
+func (d *deduplicator) stop() {
+	close(d.done)
+	d.wg.Wait()
+	d.flush(time.Now(), true)
+}
+
+func (d *deduplicator) print(e *entry) {
+	d.output.Print(e.level, e.timestamp.In(d.timezone), e.prefix, e.attributes, e.args...)
+}
+
+func (e *dedupEntry) summary(window time.Duration) *entry {
+	if e.suppressed == 0 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("message repeated %d times in the last %s: %s", e.suppressed, window, e.last.message())
+	return &entry{
+		timestamp:  e.last.timestamp,
+		level:      e.last.level,
+		prefix:     e.last.prefix,
+		attributes: e.last.attributes,
+		args:       []interface{}{msg},
+	}
+}
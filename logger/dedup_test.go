@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+func TestDedupSuppressRepeats(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "telegraf.log")
+
+	instance = defaultHandler()
+	cfg := &Config{
+		Logfile:             filename,
+		LogFormat:           "text",
+		RotationMaxArchives: -1,
+		DedupWindow:         time.Hour,
+	}
+	require.NoError(t, SetupLogging(cfg))
+
+	reg := selfstat.Register("gather", "errors", map[string]string{"input": "dedup_test"})
+	l := New("inputs", "dedup_test", "")
+	l.RegisterErrorCallback(func() { reg.Incr(1) })
+	for i := range 5 {
+		l.Errorf("parsing message %d failed", i)
+	}
+	l.Warn("different message")
+	New("inputs", "dedup_test", "other").Errorf("parsing message %d failed", 0)
+	require.NoError(t, CloseLogging())
+
+	// All errors must be counted even if suppressed
+	require.Equal(t, int64(5), reg.Get())
+
+	buf, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(string(buf)), "\n") {
+		require.Greater(t, len(line), 19)
+		lines = append(lines, line[19:])
+	}
+	expected := []string{
+		"Z E! [inputs.dedup_test] parsing message 0 failed",
+		"Z W! [inputs.dedup_test] different message",
+		"Z E! [inputs.dedup_test::other] parsing message 0 failed",
+		"Z E! [inputs.dedup_test] message repeated 4 times in the last 1h0m0s: parsing message 4 failed",
+	}
+	require.Equal(t, expected, lines)
+}
+
+func TestDedupConcurrentShutdown(t *testing.T) {
+	instance = defaultHandler()
+	cfg := &Config{
+		Logfile:             filepath.Join(t.TempDir(), "telegraf.log"),
+		LogFormat:           "text",
+		RotationMaxArchives: -1,
+		DedupWindow:         time.Hour,
+	}
+	require.NoError(t, SetupLogging(cfg))
+
+	// Logging while closing the logger must neither race nor panic
+	l := New("inputs", "dedup_test", "")
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				l.Errorf("parsing message %d failed", i)
+			}
+		}()
+	}
+	require.NoError(t, CloseLogging())
+	wg.Wait()
+}
+
+func TestDedupWindowExpired(t *testing.T) {
+	var summaries []string
+	output := &captureSink{print: func(msg string) { summaries = append(summaries, msg) }}
+	d := &deduplicator{
+		window:   time.Minute,
+		output:   output,
+		timezone: time.UTC,
+		entries:  make(map[dedupKey]*dedupEntry),
+	}
+
+	start := time.Now()
+	require.True(t, d.check(telegraf.Error, start, "[test] ", nil, "", "foo"))
+	require.False(t, d.check(telegraf.Error, start.Add(10*time.Second), "[test] ", nil, "", "foo"))
+	require.False(t, d.check(telegraf.Error, start.Add(20*time.Second), "[test] ", nil, "", "foo"))
+	require.True(t, d.check(telegraf.Error, start.Add(10*time.Second), "[test] ", nil, "", "bar"))
+	require.Empty(t, summaries)
+
+	// The next repetition after the window is printed after a summary
+	require.True(t, d.check(telegraf.Error, start.Add(time.Minute), "[test] ", nil, "", "foo"))
+	require.Equal(t, []string{"message repeated 2 times in the last 1m0s: foo"}, summaries)
+
+	// Windows without repetitions are forgotten without summary
+	d.flush(start.Add(3*time.Minute), false)
+	require.Len(t, summaries, 1)
+	require.Empty(t, d.entries)
+}
+
+type captureSink struct {
+	print func(string)
+}
+
+func (s *captureSink) Print(_ telegraf.LogLevel, _ time.Time, _ string, _ map[string]interface{}, args ...interface{}) {
+	s.print(args[0].(string))
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"maps"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -26,6 +28,7 @@ type handler struct {
 	timezone *time.Location
 
 	impl      sink
+	dedup     atomic.Pointer[deduplicator]
 	earlysink *log.Logger
 	earlylogs *list.List
 	sync.Mutex
@@ -100,6 +103,11 @@ func (h *handler) close() error {
 	}
 	h.Unlock()
 
+	// Output pending summaries of suppressed messages
+	if d := h.dedup.Swap(nil); d != nil {
+		d.stop()
+	}
+
 	if l, ok := h.impl.(io.Closer); ok {
 		return l.Close()
 	}
@@ -140,3 +148,146 @@ func (l *redirectLogger) Close() error {
 	}
 	return nil
 }
+
+// remoteSink is implemented by sinks sending the log messages to a remote
+// destination. Those sinks are wrapped in an asyncSink to avoid blocking the
+// logging callers on slow or unreachable destinations.
+type remoteSink interface {
+	// send the given log entries to the remote destination
+	send(entries []*entry) error
+	io.Closer
+}
+
+// Maximum time to wait for queued messages to be sent when closing a remote
+// sink
+const remoteCloseTimeout = 10 * time.Second
+
+// asyncSink buffers the log messages and sends them in the background to
+// the remote sink. Messages are dropped if the buffer is full.
+type asyncSink struct {
+	remote    remoteSink
+	queue     chan *entry
+	batchSize int
+	dropped   atomic.Uint64
+	errlog    *log.Logger
+
+	closeTimeout time.Duration
+	closeErr     error
+	cancel       chan struct{}
+	done         chan struct{}
+	closed       bool
+	sync.RWMutex
+}
+
+func newAsyncSink(remote remoteSink, bufferSize int) *asyncSink {
+	if bufferSize <= 0 {
+		bufferSize = 1000
+	}
+
+	s := &asyncSink{
+		remote:    remote,
+		queue:     make(chan *entry, bufferSize),
+		batchSize: min(bufferSize, 100),
+		errlog:    log.New(os.Stderr, "", 0),
+
+		closeTimeout: remoteCloseTimeout,
+		cancel:       make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	go s.run()
+
+	return s
+}
+
+func (s *asyncSink) Print(level telegraf.LogLevel, ts time.Time, prefix string, attr map[string]interface{}, args ...interface{}) {
+	// Copy the attributes as the caller's map might be modified while the
+	// entry is waiting in the queue
+	e := &entry{
+		timestamp:  ts,
+		level:      level,
+		prefix:     prefix,
+		attributes: maps.Clone(attr),
+		args:       args,
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.queue <- e:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+func (s *asyncSink) Close() error {
+	// Stop accepting new messages and wait for the queued ones to be sent
+	s.Lock()
+	if s.closed {
+		s.Unlock()
+		<-s.done
+		return s.closeErr
+	}
+	s.closed = true
+	close(s.queue)
+	s.Unlock()
+
+	// Do not block shutdown on unreachable or stalled destinations but drop
+	// the remaining messages instead
+	timer := time.NewTimer(s.closeTimeout)
+	defer timer.Stop()
+	select {
+	case <-s.done:
+		return s.closeErr
+	case <-timer.C:
+		close(s.cancel)
+		return fmt.Errorf("sending log messages timed out, dropping %d queued messages", len(s.queue))
+	}
+}
+
+func (s *asyncSink) run() {
+	defer close(s.done)
+	defer func() {
+		s.closeErr = s.remote.Close()
+	}()
+
+	batch := make([]*entry, 0, s.batchSize)
+	for e := range s.queue {
+		// Collect all messages available up to the batch size
+		batch = append(batch, e)
+	collect:
+		for len(batch) < s.batchSize {
+			select {
+			case e, ok := <-s.queue:
+				if !ok {
+					break collect
+				}
+				batch = append(batch, e)
+			default:
+				break collect
+			}
+		}
+
+		if n := s.dropped.Swap(0); n > 0 {
+			s.errlog.Printf("W! Log buffer full, %d messages have been dropped", n)
+		}
+		select {
+		case <-s.cancel:
+			// Closing timed out so drop the remaining messages
+		default:
+			if err := s.remote.send(batch); err != nil {
+				s.errlog.Printf("E! Sending %d log messages failed: %v", len(batch), err)
+			}
+		}
+		clear(batch)
+		batch = batch[:0]
+	}
+}
+
+// message returns the log message of the entry
+func (e *entry) message() string {
+	return fmt.Sprint(e.args...)
+}
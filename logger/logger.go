@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/tls"
 )
 
 // Central handler for the logs used by the logger to actually output the logs.
@@ -90,11 +91,18 @@ func (l *logger) AddAttribute(key string, value interface{}) {
 
 // Error logging including callbacks
 func (l *logger) Errorf(format string, args ...interface{}) {
-	l.Error(fmt.Sprintf(format, args...))
+	l.print(telegraf.Error, time.Now(), format, fmt.Sprintf(format, args...))
+	l.notifyError()
 }
 
 func (l *logger) Error(args ...interface{}) {
 	l.Print(telegraf.Error, time.Now(), args...)
+	l.notifyError()
+}
+
+// notifyError triggers the error callbacks, this is done for every error
+// even if the message itself is suppressed as a repetition
+func (l *logger) notifyError() {
 	for _, f := range l.onError {
 		f()
 	}
@@ -102,7 +110,7 @@ func (l *logger) Error(args ...interface{}) {
 
 // Warning logging
 func (l *logger) Warnf(format string, args ...interface{}) {
-	l.Warn(fmt.Sprintf(format, args...))
+	l.print(telegraf.Warn, time.Now(), format, fmt.Sprintf(format, args...))
 }
 
 func (l *logger) Warn(args ...interface{}) {
@@ -111,7 +119,7 @@ func (l *logger) Warn(args ...interface{}) {
 
 // Info logging
 func (l *logger) Infof(format string, args ...interface{}) {
-	l.Info(fmt.Sprintf(format, args...))
+	l.print(telegraf.Info, time.Now(), format, fmt.Sprintf(format, args...))
 }
 
 func (l *logger) Info(args ...interface{}) {
@@ -120,7 +128,7 @@ func (l *logger) Info(args ...interface{}) {
 
 // Debug logging, this is suppressed on console
 func (l *logger) Debugf(format string, args ...interface{}) {
-	l.Debug(fmt.Sprintf(format, args...))
+	l.print(telegraf.Debug, time.Now(), format, fmt.Sprintf(format, args...))
 }
 
 func (l *logger) Debug(args ...interface{}) {
@@ -129,7 +137,7 @@ func (l *logger) Debug(args ...interface{}) {
 
 // Trace logging, this is suppressed on console
 func (l *logger) Tracef(format string, args ...interface{}) {
-	l.Trace(fmt.Sprintf(format, args...))
+	l.print(telegraf.Trace, time.Now(), format, fmt.Sprintf(format, args...))
 }
 
 func (l *logger) Trace(args ...interface{}) {
@@ -137,6 +145,12 @@ func (l *logger) Trace(args ...interface{}) {
 }
 
 func (l *logger) Print(level telegraf.LogLevel, ts time.Time, args ...interface{}) {
+	l.print(level, ts, "", args...)
+}
+
+// print outputs the message using the given template for detecting repeated
+// messages, an empty template uses the message itself
+func (l *logger) print(level telegraf.LogLevel, ts time.Time, template string, args ...interface{}) {
 	// Check if we are in early logging state and store the message in this case
 	if instance.impl == nil {
 		instance.add(level, ts, l.prefix, l.attributes, args...)
@@ -147,6 +161,9 @@ func (l *logger) Print(level telegraf.LogLevel, ts time.Time, args ...interface{
 		return
 	}
 	if instance.impl != nil {
+		if d := instance.dedup.Load(); d != nil && !d.check(level, ts, l.prefix, l.attributes, template, args...) {
+			return
+		}
 		instance.impl.Print(level, ts.In(instance.timezone), l.prefix, l.attributes, args...)
 	} else {
 		msg := append([]interface{}{ts.In(instance.timezone).Format(time.RFC3339), " ", level.Indicator(), " ", l.prefix}, args...)
@@ -198,6 +215,14 @@ type Config struct {
 	InstanceName string
 	// Structured logging message key
 	StructuredLogMessageKey string
+	// address of the remote destination for the "syslog" and "otlp" formats
+	RemoteAddress string
+	// TLS settings for connecting to the remote destination
+	RemoteTLS tls.ClientConfig
+	// number of messages buffered for the remote destination
+	RemoteBufferSize int
+	// window for suppressing repeated messages, zero disables suppression
+	DedupWindow time.Duration
 
 	// internal  log-level
 	logLevel telegraf.LogLevel
@@ -281,6 +306,9 @@ func SetupLogging(cfg *Config) error {
 	// Update the logging instance
 	skipEarlyLogs := cfg.LogFormat == "text" && cfg.Logfile == ""
 	instance.switchSink(l, cfg.logLevel, tz, skipEarlyLogs)
+	if cfg.DedupWindow > 0 {
+		instance.dedup.Store(newDeduplicator(cfg.DedupWindow, l, tz))
+	}
 
 	return nil
 }
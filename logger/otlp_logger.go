@@ -0,0 +1,194 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/influxdata/telegraf"
+)
+
+const otlpTimeout = 10 * time.Second
+
+type otlpLogger struct {
+	serviceName string
+	hostname    string
+
+	// HTTP transport
+	url    string
+	client *http.Client
+
+	// gRPC transport
+	conn      *grpc.ClientConn
+	logClient plogotlp.GRPCClient
+}
+
+func (l *otlpLogger) Close() error {
+	if l.client != nil {
+		l.client.CloseIdleConnections()
+	}
+	if l.conn != nil {
+		return l.conn.Close()
+	}
+	return nil
+}
+
+func (l *otlpLogger) send(entries []*entry) error {
+	request := plogotlp.NewExportRequestFromLogs(l.convert(entries))
+
+	ctx, cancel := context.WithTimeout(context.Background(), otlpTimeout)
+	defer cancel()
+
+	if l.logClient != nil {
+		_, err := l.logClient.Export(ctx, request)
+		return err
+	}
+
+	body, err := request.MarshalProto()
+	if err != nil {
+		return fmt.Errorf("serializing request failed: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("received status %d: %s", resp.StatusCode, string(msg))
+	}
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
+
+func (l *otlpLogger) convert(entries []*entry) plog.Logs {
+	logs := plog.NewLogs()
+	rl := logs.ResourceLogs().AppendEmpty()
+	rl.Resource().Attributes().PutStr("service.name", l.serviceName)
+	rl.Resource().Attributes().PutStr("host.name", l.hostname)
+
+	records := rl.ScopeLogs().AppendEmpty().LogRecords()
+	records.EnsureCapacity(len(entries))
+	observed := pcommon.NewTimestampFromTime(time.Now())
+	for _, e := range entries {
+		record := records.AppendEmpty()
+		record.SetTimestamp(pcommon.NewTimestampFromTime(e.timestamp))
+		record.SetObservedTimestamp(observed)
+		record.SetSeverityNumber(otlpSeverity(e.level))
+		record.SetSeverityText(e.level.String())
+		record.Body().SetStr(e.message())
+
+		attrs := record.Attributes()
+		attrs.EnsureCapacity(len(e.attributes))
+		for k, v := range e.attributes {
+			switch v := v.(type) {
+			case string:
+				attrs.PutStr(k, v)
+			case bool:
+				attrs.PutBool(k, v)
+			case int:
+				attrs.PutInt(k, int64(v))
+			case int64:
+				attrs.PutInt(k, v)
+			case float64:
+				attrs.PutDouble(k, v)
+			default:
+				attrs.PutStr(k, fmt.Sprint(v))
+			}
+		}
+	}
+	return logs
+}
+
+func otlpSeverity(level telegraf.LogLevel) plog.SeverityNumber {
+	switch level {
+	case telegraf.Error:
+		return plog.SeverityNumberError
+	case telegraf.Warn:
+		return plog.SeverityNumberWarn
+	case telegraf.Info:
+		return plog.SeverityNumberInfo
+	case telegraf.Debug:
+		return plog.SeverityNumberDebug
+	case telegraf.Trace:
+		return plog.SeverityNumberTrace
+	}
+	return plog.SeverityNumberUnspecified
+}
+
+func createOTLPLogger(cfg *Config) (sink, error) {
+	u, err := url.Parse(cfg.RemoteAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OTLP address %q: %w", cfg.RemoteAddress, err)
+	}
+
+	tlsCfg, err := cfg.RemoteTLS.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// The hostname is optional so ignore errors
+	hostname, _ := os.Hostname()
+
+	l := &otlpLogger{
+		serviceName: cfg.InstanceName,
+		hostname:    hostname,
+	}
+
+	switch u.Scheme {
+	case "grpc":
+		creds := insecure.NewCredentials()
+		if tlsCfg != nil {
+			creds = credentials.NewTLS(tlsCfg)
+		}
+		conn, err := grpc.NewClient(u.Host, grpc.WithTransportCredentials(creds))
+		if err != nil {
+			return nil, fmt.Errorf("creating gRPC client failed: %w", err)
+		}
+		l.conn = conn
+		l.logClient = plogotlp.NewGRPCClient(conn)
+	case "http", "https":
+		if u.Path == "" || u.Path == "/" {
+			u.Path = "/v1/logs"
+		}
+		if tlsCfg == nil && u.Scheme == "https" {
+			tlsCfg = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		l.url = u.String()
+		l.client = &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsCfg,
+			},
+			Timeout: otlpTimeout,
+		}
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q", u.Scheme)
+	}
+
+	return newAsyncSink(l, cfg.RemoteBufferSize), nil
+}
+
+func init() {
+	add("otlp", createOTLPLogger)
+}
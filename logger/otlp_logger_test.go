@@ -0,0 +1,183 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+	"google.golang.org/grpc"
+
+	"github.com/influxdata/telegraf"
+)
+
+func TestOTLPHTTP(t *testing.T) {
+	received := make(chan plog.Logs, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/logs" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		request := plogotlp.NewExportRequest()
+		if err := request.UnmarshalProto(body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- request.Logs()
+	}))
+	defer server.Close()
+
+	instance = defaultHandler()
+	cfg := &Config{
+		LogFormat:     "otlp",
+		RemoteAddress: server.URL,
+		InstanceName:  "telegraf-test",
+	}
+	require.NoError(t, SetupLogging(cfg))
+
+	l := New("inputs", "test", "foo")
+	l.AddAttribute("device", "sda")
+	l.Error("TEST")
+	require.NoError(t, CloseLogging())
+
+	var logs plog.Logs
+	select {
+	case logs = <-received:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timeout waiting for logs")
+	}
+	checkOTLPLogs(t, logs)
+}
+
+func TestOTLPGRPC(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	receiver := &otlpReceiver{received: make(chan plog.Logs, 1)}
+	server := grpc.NewServer()
+	plogotlp.RegisterGRPCServer(server, receiver)
+	go server.Serve(listener) //nolint:errcheck // ignore error on shutdown
+	defer server.Stop()
+
+	instance = defaultHandler()
+	cfg := &Config{
+		LogFormat:     "otlp",
+		RemoteAddress: "grpc://" + listener.Addr().String(),
+		InstanceName:  "telegraf-test",
+	}
+	require.NoError(t, SetupLogging(cfg))
+
+	l := New("inputs", "test", "foo")
+	l.AddAttribute("device", "sda")
+	l.Error("TEST")
+	require.NoError(t, CloseLogging())
+
+	var logs plog.Logs
+	select {
+	case logs = <-receiver.received:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "timeout waiting for logs")
+	}
+	checkOTLPLogs(t, logs)
+}
+
+func checkOTLPLogs(t *testing.T, logs plog.Logs) {
+	t.Helper()
+
+	require.Equal(t, 1, logs.LogRecordCount())
+	rl := logs.ResourceLogs().At(0)
+	name, found := rl.Resource().Attributes().Get("service.name")
+	require.True(t, found)
+	require.Equal(t, "telegraf-test", name.Str())
+
+	record := rl.ScopeLogs().At(0).LogRecords().At(0)
+	require.Equal(t, "TEST", record.Body().Str())
+	require.Equal(t, plog.SeverityNumberError, record.SeverityNumber())
+	require.Equal(t, "ERROR", record.SeverityText())
+	expected := map[string]interface{}{
+		"category": "inputs",
+		"plugin":   "test",
+		"alias":    "foo",
+		"device":   "sda",
+	}
+	require.Equal(t, expected, record.Attributes().AsRaw())
+}
+
+type otlpReceiver struct {
+	plogotlp.UnimplementedGRPCServer
+	received chan plog.Logs
+}
+
+func (r *otlpReceiver) Export(_ context.Context, request plogotlp.ExportRequest) (plogotlp.ExportResponse, error) {
+	r.received <- request.Logs()
+	return plogotlp.NewExportResponse(), nil
+}
+
+func TestAsyncSinkAttributesCopied(t *testing.T) {
+	remote := &blockingRemote{
+		release:  make(chan struct{}),
+		received: make(chan []*entry, 1),
+	}
+	s := newAsyncSink(remote, 10)
+
+	attr := map[string]interface{}{"device": "sda"}
+	s.Print(telegraf.Error, time.Now(), "[inputs.test]", attr, "TEST")
+
+	// Modifying the caller's attributes must not affect the queued entry
+	attr["device"] = "sdb"
+	close(remote.release)
+	require.NoError(t, s.Close())
+
+	batch := <-remote.received
+	require.Len(t, batch, 1)
+	require.Equal(t, map[string]interface{}{"device": "sda"}, batch[0].attributes)
+}
+
+func TestAsyncSinkCloseTimeout(t *testing.T) {
+	remote := &blockingRemote{
+		release:  make(chan struct{}),
+		received: make(chan []*entry, 1),
+	}
+	s := newAsyncSink(remote, 10)
+	s.closeTimeout = 100 * time.Millisecond
+
+	// Closing must not block on a stalled destination
+	s.Print(telegraf.Error, time.Now(), "[inputs.test]", nil, "first")
+	require.Eventually(t, func() bool { return len(s.queue) == 0 }, time.Second, 10*time.Millisecond)
+	s.Print(telegraf.Error, time.Now(), "[inputs.test]", nil, "second")
+	require.ErrorContains(t, s.Close(), "sending log messages timed out, dropping 1 queued messages")
+
+	// The remaining messages must be dropped once the destination recovers
+	close(remote.release)
+	<-s.done
+	batch := <-remote.received
+	require.Len(t, batch, 1)
+	require.Equal(t, "first", batch[0].message())
+	require.Empty(t, remote.received)
+}
+
+type blockingRemote struct {
+	release  chan struct{}
+	received chan []*entry
+}
+
+func (r *blockingRemote) send(entries []*entry) error {
+	<-r.release
+	r.received <- append([]*entry(nil), entries...)
+	return nil
+}
+
+func (*blockingRemote) Close() error {
+	return nil
+}
@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leodido/go-syslog/v4/rfc5424"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Structured-data ID used for the log attributes, see RFC5424 section 7.2.2
+const syslogSDID = "telegraf@32473"
+
+// Timeout for connecting and writing to the syslog server
+const syslogTimeout = 5 * time.Second
+
+type syslogLogger struct {
+	network  string
+	address  string
+	tlsCfg   *tls.Config
+	appname  string
+	hostname string
+	procid   string
+
+	conn net.Conn
+}
+
+func (l *syslogLogger) Close() error {
+	if l.conn == nil {
+		return nil
+	}
+	err := l.conn.Close()
+	l.conn = nil
+	return err
+}
+
+func (l *syslogLogger) connect() error {
+	dialer := &net.Dialer{Timeout: syslogTimeout}
+
+	var err error
+	switch {
+	case l.tlsCfg != nil:
+		l.conn, err = tls.DialWithDialer(dialer, "tcp", l.address, l.tlsCfg)
+	default:
+		l.conn, err = dialer.Dial(l.network, l.address)
+	}
+	return err
+}
+
+func (l *syslogLogger) send(entries []*entry) error {
+	if l.conn == nil {
+		if err := l.connect(); err != nil {
+			return fmt.Errorf("connecting to %s://%s failed: %w", l.network, l.address, err)
+		}
+	}
+
+	for _, e := range entries {
+		msg, err := l.format(e)
+		if err != nil {
+			return fmt.Errorf("formatting message failed: %w", err)
+		}
+
+		// Use octet-counting framing for stream connections, see RFC6587
+		// section 3.4.1 and RFC5425 section 4.3
+		if l.network != "udp" {
+			msg = strconv.Itoa(len(msg)) + " " + msg
+		}
+
+		if err := l.conn.SetWriteDeadline(time.Now().Add(syslogTimeout)); err != nil {
+			return err
+		}
+		if _, err := l.conn.Write([]byte(msg)); err != nil {
+			// Close the connection to reconnect on the next message
+			l.conn.Close()
+			l.conn = nil
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *syslogLogger) format(e *entry) (string, error) {
+	var msg rfc5424.SyslogMessage
+	msg.SetVersion(1)
+	msg.SetTimestamp(e.timestamp.Format(time.RFC3339Nano))
+	msg.SetPriority(syslogPriority(e.level))
+	msg.SetHostname(l.hostname)
+	msg.SetAppname(l.appname)
+	msg.SetProcID(l.procid)
+	msg.SetMessage(e.message())
+	for k, v := range e.attributes {
+		msg.SetParameter(syslogSDID, k, fmt.Sprint(v))
+	}
+
+	return msg.String()
+}
+
+// syslogPriority returns the priority for the "daemon" facility with the
+// severity matching the given log-level
+func syslogPriority(level telegraf.LogLevel) uint8 {
+	const facility = 3 << 3
+
+	switch level {
+	case telegraf.Error:
+		return facility + 3
+	case telegraf.Warn:
+		return facility + 4
+	case telegraf.Info:
+		return facility + 6
+	}
+	return facility + 7
+}
+
+func createSyslogLogger(cfg *Config) (sink, error) {
+	network, address, found := strings.Cut(cfg.RemoteAddress, "://")
+	if !found {
+		return nil, fmt.Errorf("invalid syslog address %q", cfg.RemoteAddress)
+	}
+
+	tlsCfg, err := cfg.RemoteTLS.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	switch network {
+	case "udp", "tcp":
+	case "tls":
+		if tlsCfg == nil {
+			tlsCfg = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported syslog protocol %q", network)
+	}
+	if network == "udp" && tlsCfg != nil {
+		return nil, errors.New("TLS is not supported for syslog over UDP")
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+
+	l := &syslogLogger{
+		network:  network,
+		address:  address,
+		tlsCfg:   tlsCfg,
+		appname:  cfg.InstanceName,
+		hostname: hostname,
+		procid:   strconv.Itoa(os.Getpid()),
+	}
+
+	return newAsyncSink(l, cfg.RemoteBufferSize), nil
+}
+
+func init() {
+	add("syslog", createSyslogLogger)
+}
@@ -0,0 +1,120 @@
+package logger
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leodido/go-syslog/v4/rfc5424"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyslogUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	instance = defaultHandler()
+	cfg := &Config{
+		LogFormat:     "syslog",
+		RemoteAddress: "udp://" + conn.LocalAddr().String(),
+		InstanceName:  "telegraf-test",
+	}
+	require.NoError(t, SetupLogging(cfg))
+
+	l := New("inputs", "test", "foo")
+	l.AddAttribute("device", "sda")
+	l.Warn("TEST")
+	l.Debug("TEST") // <- should be ignored
+	require.NoError(t, CloseLogging())
+
+	buf := make([]byte, 1024)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+
+	msg, err := rfc5424.NewParser().Parse(buf[:n])
+	require.NoError(t, err)
+	actual := msg.(*rfc5424.SyslogMessage)
+	require.Equal(t, "TEST", *actual.Message)
+	require.Equal(t, "telegraf-test", *actual.Appname)
+	require.Equal(t, uint8(3), *actual.Facility)
+	require.Equal(t, uint8(4), *actual.Severity)
+	expected := map[string]map[string]string{
+		syslogSDID: {
+			"category": "inputs",
+			"plugin":   "test",
+			"alias":    "foo",
+			"device":   "sda",
+		},
+	}
+	require.Equal(t, expected, *actual.StructuredData)
+}
+
+func TestSyslogTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	received := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		// Read the octet-counted frames
+		reader := bufio.NewReader(conn)
+		for {
+			length, err := reader.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(length))
+			if err != nil {
+				return
+			}
+			buf := make([]byte, n)
+			if _, err := reader.Read(buf); err != nil {
+				return
+			}
+			received <- string(buf)
+		}
+	}()
+
+	instance = defaultHandler()
+	cfg := &Config{
+		LogFormat:     "syslog",
+		RemoteAddress: "tcp://" + listener.Addr().String(),
+	}
+	require.NoError(t, SetupLogging(cfg))
+
+	l := New("outputs", "test", "")
+	l.Error("first")
+	l.Info("second")
+	require.NoError(t, CloseLogging())
+
+	for _, expected := range []string{"first", "second"} {
+		select {
+		case raw := <-received:
+			msg, err := rfc5424.NewParser().Parse([]byte(raw))
+			require.NoError(t, err)
+			require.Equal(t, expected, *msg.(*rfc5424.SyslogMessage).Message)
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "timeout waiting for message")
+		}
+	}
+}
+
+func TestSyslogInvalidAddress(t *testing.T) {
+	instance = defaultHandler()
+	cfg := &Config{
+		LogFormat:     "syslog",
+		RemoteAddress: "unix:///tmp/syslog",
+	}
+	require.ErrorContains(t, SetupLogging(cfg), "unsupported syslog protocol")
+}
@@ -16,6 +16,10 @@ type metric struct {
 	MetricTime   time.Time
 
 	MetricType telegraf.ValueType
+
+	// MetricPriority is the priority of the metric relative to the normal
+	// priority of zero, negative values denote a higher priority
+	MetricPriority int
 }
 
 func New(
@@ -248,11 +252,12 @@ func (m *metric) SetType(t telegraf.ValueType) {
 
 func (m *metric) Copy() telegraf.Metric {
 	m2 := &metric{
-		MetricName:   m.MetricName,
-		MetricTags:   make([]*telegraf.Tag, len(m.MetricTags)),
-		MetricFields: make([]*telegraf.Field, len(m.MetricFields)),
-		MetricTime:   m.MetricTime,
-		MetricType:   m.MetricType,
+		MetricName:     m.MetricName,
+		MetricTags:     make([]*telegraf.Tag, len(m.MetricTags)),
+		MetricFields:   make([]*telegraf.Field, len(m.MetricFields)),
+		MetricTime:     m.MetricTime,
+		MetricType:     m.MetricType,
+		MetricPriority: m.MetricPriority,
 	}
 
 	for i, tag := range m.MetricTags {
@@ -265,6 +270,30 @@ func (m *metric) Copy() telegraf.Metric {
 	return m2
 }
 
+// SetPriority sets the priority of the metric relative to the normal priority
+// of zero where negative values denote a higher priority. The priority is not
+// part of the metric's data and is only used for ordering the metrics in the
+// output buffers. Metrics not created by this package are left unchanged.
+func SetPriority(m telegraf.Metric, priority int) {
+	if um, ok := m.(telegraf.UnwrappableMetric); ok {
+		m = um.Unwrap()
+	}
+	if pm, ok := m.(*metric); ok {
+		pm.MetricPriority = priority
+	}
+}
+
+// Priority returns the priority of the metric set with SetPriority
+func Priority(m telegraf.Metric) int {
+	if um, ok := m.(telegraf.UnwrappableMetric); ok {
+		m = um.Unwrap()
+	}
+	if pm, ok := m.(*metric); ok {
+		return pm.MetricPriority
+	}
+	return 0
+}
+
 func (m *metric) HashID() uint64 {
 	h := fnv.New64a()
 	h.Write([]byte(m.MetricName))
@@ -326,3 +326,21 @@ func TestValueType(t *testing.T) {
 
 	require.Equal(t, telegraf.Gauge, m.Type())
 }
+
+func TestPriority(t *testing.T) {
+	m := baseMetric()
+	require.Zero(t, Priority(m))
+
+	SetPriority(m, -1)
+	require.Equal(t, -1, Priority(m))
+	require.Equal(t, -1, Priority(m.Copy()))
+
+	// The priority is not part of the metric's identity
+	require.Equal(t, baseMetric().HashID(), m.HashID())
+
+	// Tracking metrics carry the priority of the wrapped metric
+	tm, _ := WithTracking(m, func(telegraf.DeliveryInfo) {})
+	require.Equal(t, -1, Priority(tm))
+	SetPriority(tm, 1)
+	require.Equal(t, 1, Priority(m))
+}
@@ -92,6 +92,9 @@ type BufferStats struct {
 	MetricsDropped  selfstat.Stat
 	BufferSize      selfstat.Stat
 	BufferLimit     selfstat.Stat
+
+	// dropped metrics of the priority class if the buffer holds a class
+	classDropped selfstat.Stat
 }
 
 // NewBuffer returns a new empty Buffer with the given capacity.
@@ -171,5 +174,15 @@ func (b *BufferStats) metricRejected(m telegraf.Metric) {
 func (b *BufferStats) metricDropped(m telegraf.Metric) {
 	AgentMetricsDropped.Incr(1)
 	b.MetricsDropped.Incr(1)
+	if b.classDropped != nil {
+		b.classDropped.Incr(1)
+	}
 	m.Reject()
 }
+
+// setClassStats replaces the buffer size by the given per-class statistic
+// and additionally counts the drops in the given per-class statistic
+func (b *BufferStats) setClassStats(dropped, size selfstat.Stat) {
+	b.classDropped = dropped
+	b.BufferSize = size
+}
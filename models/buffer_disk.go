@@ -5,8 +5,6 @@ import (
 	"fmt"
 	"log"
 	"path/filepath"
-	"slices"
-	"sort"
 	"sync"
 
 	"github.com/tidwall/wal"
@@ -22,9 +20,6 @@ type DiskBuffer struct {
 	file *wal.Log
 	path string
 
-	batchFirst uint64 // Index of the first metric in the batch
-	batchSize  uint64 // Number of metrics currently in the batch
-
 	// Ending point of metrics read from disk on telegraf launch.
 	// Used to know whether to discard tracking metrics.
 	originalEnd uint64
@@ -34,10 +29,14 @@ type DiskBuffer struct {
 	// write, we can remove the invalid entry (also skipping this entry if it is being read).
 	isEmpty bool
 
-	// The mask contains offsets of metric already removed during a previous
-	// transaction. Metrics at those offsets should not be contained in new
-	// batches.
-	mask []int
+	// The mask contains the WAL indices of metrics already removed during a
+	// previous transaction. Metrics at those indices should not be contained
+	// in new batches.
+	mask map[uint64]bool
+
+	// The WAL indices of metrics contained in unfinished transactions. Those
+	// metrics must not be contained in new batches either.
+	inflight map[uint64]bool
 }
 
 func NewDiskBuffer(name, id, path string, stats BufferStats) (*DiskBuffer, error) {
@@ -57,6 +56,8 @@ func NewDiskBuffer(name, id, path string, stats BufferStats) (*DiskBuffer, error
 		BufferStats: stats,
 		file:        walFile,
 		path:        filePath,
+		mask:        make(map[uint64]bool),
+		inflight:    make(map[uint64]bool),
 	}
 	if buf.length() > 0 {
 		buf.originalEnd = buf.writeIndex()
@@ -139,25 +140,20 @@ func (b *DiskBuffer) BeginTransaction(batchSize int) *Transaction {
 	if b.length() == 0 {
 		return &Transaction{}
 	}
-	b.batchFirst = b.readIndex()
-	b.batchSize = 0
 
 	metrics := make([]telegraf.Metric, 0, batchSize)
-	offsets := make([]int, 0, batchSize)
-	readIndex := b.batchFirst
+	indices := make([]uint64, 0, batchSize)
 	endIndex := b.writeIndex()
-	offset := 0
-	for batchSize > 0 && readIndex < endIndex {
-		data, err := b.file.Read(readIndex)
-		if err != nil {
-			panic(err)
+	for index := b.readIndex(); batchSize > 0 && index < endIndex; index++ {
+		if b.mask[index] || b.inflight[index] {
+			// Metric is either masked by a previous write and is scheduled for
+			// removal or is part of another transaction
+			continue
 		}
-		readIndex++
-		offset++
 
-		if slices.Contains(b.mask, offset) {
-			// Metric is masked by a previous write and is scheduled for removal
-			continue
+		data, err := b.file.Read(index)
+		if err != nil {
+			panic(err)
 		}
 
 		// Validate that a tracking metric is from this instance of telegraf and skip ones from older instances.
@@ -172,23 +168,25 @@ func (b *DiskBuffer) BeginTransaction(batchSize int) *Transaction {
 		if err != nil {
 			if errors.Is(err, metric.ErrSkipTracking) {
 				// could not look up tracking information for metric, skip
+				b.mask[index] = true
 				continue
 			}
 			// non-recoverable error in deserialization, abort
 			log.Printf("E! raw metric data: %v", data)
 			panic(err)
 		}
-		if _, ok := m.(telegraf.TrackingMetric); ok && readIndex < b.originalEnd {
+		if _, ok := m.(telegraf.TrackingMetric); ok && index < b.originalEnd {
 			// tracking metric left over from previous instance, skip
+			b.mask[index] = true
 			continue
 		}
 
 		metrics = append(metrics, m)
-		offsets = append(offsets, offset)
-		b.batchSize++
+		indices = append(indices, index)
+		b.inflight[index] = true
 		batchSize--
 	}
-	return &Transaction{Batch: metrics, valid: true, state: offsets}
+	return &Transaction{Batch: metrics, valid: true, state: indices}
 }
 
 func (b *DiskBuffer) EndTransaction(tx *Transaction) {
@@ -202,70 +200,59 @@ func (b *DiskBuffer) EndTransaction(tx *Transaction) {
 	}
 	tx.valid = false
 
-	// Get the metric offsets from the transaction
-	offsets := tx.state.([]int)
+	// Get the metric indices from the transaction
+	indices := tx.state.([]uint64)
 
 	b.Lock()
 	defer b.Unlock()
 
-	// Mark metrics which should be removed in the internal mask
-	remove := make([]int, 0, len(tx.Accept)+len(tx.Reject))
+	// Release all metrics of the transaction and mark metrics which should be
+	// removed in the internal mask
+	for _, index := range indices {
+		delete(b.inflight, index)
+	}
 	for _, idx := range tx.Accept {
 		b.metricWritten(tx.Batch[idx])
-		remove = append(remove, offsets[idx])
+		b.mask[indices[idx]] = true
 	}
 	for _, idx := range tx.Reject {
 		b.metricRejected(tx.Batch[idx])
-		remove = append(remove, offsets[idx])
+		b.mask[indices[idx]] = true
 	}
-	b.mask = append(b.mask, remove...)
-	sort.Ints(b.mask)
 
 	// Remove the metrics that are marked for removal from the front of the
-	// WAL file. All other metrics must be kept.
-	if len(b.mask) == 0 || b.mask[0] != 0 {
-		// Mask is empty or the first index is not the front of the file, so
-		// exit early as there is nothing to remove
-		return
+	// WAL file. All other metrics must be kept. As transactions can finish in
+	// any order we can only remove the consecutive range of masked metrics.
+	readIndex, writeIndex := b.readIndex(), b.writeIndex()
+	removeIdx := readIndex
+	for removeIdx < writeIndex && b.mask[removeIdx] {
+		removeIdx++
 	}
-
-	// Determine up to which index we can remove the entries from the WAL file
-	var removeIdx int
-	for i, offset := range b.mask {
-		if offset != i {
-			break
-		}
-		removeIdx = offset
+	if removeIdx == readIndex {
+		// The first metric is not masked so exit early as there is nothing to
+		// remove
+		b.BufferSize.Set(int64(b.length()))
+		return
 	}
 
 	// Remove the metrics in front from the WAL file
-	b.isEmpty = b.entries()-removeIdx-1 <= 0
+	b.isEmpty = removeIdx == writeIndex
 	if b.isEmpty {
 		// WAL files cannot be fully empty but need to contain at least one
 		// item to not throw an error
-		if err := b.file.TruncateFront(b.writeIndex()); err != nil {
-			log.Printf("E! batch length: %d, first: %d, size: %d", len(tx.Batch), b.batchFirst, b.batchSize)
-			panic(err)
-		}
-	} else {
-		if err := b.file.TruncateFront(b.batchFirst + uint64(removeIdx+1)); err != nil {
-			log.Printf("E! batch length: %d, first: %d, size: %d", len(tx.Batch), b.batchFirst, b.batchSize)
-			panic(err)
-		}
+		removeIdx = writeIndex - 1
 	}
-
-	// Truncate the mask and update the relative offsets
-	b.mask = b.mask[:removeIdx]
-	for i := range b.mask {
-		b.mask[i] -= removeIdx
+	if err := b.file.TruncateFront(removeIdx); err != nil {
+		log.Printf("E! batch length: %d, read index: %d, remove index: %d", len(tx.Batch), readIndex, removeIdx)
+		panic(err)
 	}
+	b.pruneMask()
 
 	// check if the original end index is still valid, clear if not
 	if b.originalEnd < b.readIndex() {
 		b.originalEnd = 0
 	}
 
-	b.resetBatch()
 	b.BufferSize.Set(int64(b.length()))
 }
 
@@ -277,9 +264,15 @@ func (b *DiskBuffer) Close() error {
 	return b.file.Close()
 }
 
-func (b *DiskBuffer) resetBatch() {
-	b.batchFirst = 0
-	b.batchSize = 0
+// pruneMask removes all indices from the mask that are no longer contained
+// in the WAL file.
+func (b *DiskBuffer) pruneMask() {
+	readIndex := b.readIndex()
+	for index := range b.mask {
+		if index < readIndex {
+			delete(b.mask, index)
+		}
+	}
 }
 
 // This is very messy and not ideal, but serves as the only way I can find currently
@@ -294,5 +287,6 @@ func (b *DiskBuffer) handleEmptyFile() {
 		log.Printf("E! readIndex: %d, buffer len: %d", b.readIndex(), b.length())
 		panic(err)
 	}
+	b.pruneMask()
 	b.isEmpty = false
 }
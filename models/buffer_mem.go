@@ -12,19 +12,21 @@ type MemoryBuffer struct {
 	BufferStats
 
 	buf   []telegraf.Metric
-	first int // index of the first/oldest metric
-	last  int // one after the index of the last/newest metric
-	size  int // number of metrics currently in the buffer
-	cap   int // the capacity of the buffer
-
-	batchFirst int // index of the first metric in the batch
-	batchSize  int // number of metrics currently in the batch
+	seq   []uint64 // insertion sequence of the metric at the same index in buf
+	first int      // index of the first/oldest metric
+	last  int      // one after the index of the last/newest metric
+	size  int      // number of metrics currently in the buffer
+	cap   int      // the capacity of the buffer
+
+	nextSeq  uint64 // sequence number assigned to the next added metric
+	inflight int    // number of metrics in unfinished transactions
 }
 
 func NewMemoryBuffer(capacity int, stats BufferStats) (*MemoryBuffer, error) {
 	return &MemoryBuffer{
 		BufferStats: stats,
 		buf:         make([]telegraf.Metric, capacity),
+		seq:         make([]uint64, capacity),
 		cap:         capacity,
 	}, nil
 }
@@ -60,19 +62,20 @@ func (b *MemoryBuffer) BeginTransaction(batchSize int) *Transaction {
 		return &Transaction{}
 	}
 
-	b.batchFirst = b.first
-	b.batchSize = outLen
-	batchIndex := b.batchFirst
+	batchIndex := b.first
 	batch := make([]telegraf.Metric, outLen)
+	seqs := make([]uint64, outLen)
 	for i := range batch {
 		batch[i] = b.buf[batchIndex]
+		seqs[i] = b.seq[batchIndex]
 		b.buf[batchIndex] = nil
 		batchIndex = b.next(batchIndex)
 	}
 
-	b.first = b.nextby(b.first, b.batchSize)
+	b.first = b.nextby(b.first, outLen)
 	b.size -= outLen
-	return &Transaction{Batch: batch, valid: true}
+	b.inflight += outLen
+	return &Transaction{Batch: batch, valid: true, state: seqs}
 }
 
 func (b *MemoryBuffer) EndTransaction(tx *Transaction) {
@@ -84,6 +87,8 @@ func (b *MemoryBuffer) EndTransaction(tx *Transaction) {
 		return
 	}
 	tx.valid = false
+	seqs := tx.state.([]uint64)
+	b.inflight -= len(tx.Batch)
 
 	// Accept metrics
 	for _, idx := range tx.Accept {
@@ -99,14 +104,15 @@ func (b *MemoryBuffer) EndTransaction(tx *Transaction) {
 	keep := tx.InferKeep()
 	if len(keep) > 0 {
 		restore := min(len(keep), b.cap-b.size)
-		b.first = b.prevby(b.first, restore)
-		b.size = min(b.size+restore, b.cap)
-
-		// Restore the metrics that fit into the buffer
-		current := b.first
-		for i := 0; i < restore; i++ {
-			b.buf[current] = tx.Batch[keep[i]]
-			current = b.next(current)
+		switch {
+		case restore == 0:
+		case b.size == 0 || seqs[keep[restore-1]] < b.seq[b.first]:
+			b.restoreFront(tx.Batch, seqs, keep[:restore])
+		default:
+			// Another transaction finished before this one and put metrics
+			// older than ours back into the buffer, so we need to sort our
+			// metrics into the buffer to preserve the order.
+			b.restoreMerge(tx.Batch, seqs, keep[:restore])
 		}
 
 		// Drop all remaining metrics
@@ -115,10 +121,57 @@ func (b *MemoryBuffer) EndTransaction(tx *Transaction) {
 		}
 	}
 
-	b.resetBatch()
 	b.BufferSize.Set(int64(b.length()))
 }
 
+// drain removes all metrics from the buffer that are not part of an
+// unfinished transaction and returns them ordered from oldest to newest.
+func (b *MemoryBuffer) drain() []telegraf.Metric {
+	b.Lock()
+	defer b.Unlock()
+
+	metrics := make([]telegraf.Metric, 0, b.size)
+	current := b.first
+	for i := 0; i < b.size; i++ {
+		metrics = append(metrics, b.buf[current])
+		b.buf[current] = nil
+		current = b.next(current)
+	}
+	b.first = 0
+	b.last = 0
+	b.size = 0
+
+	b.BufferSize.Set(int64(b.length()))
+	return metrics
+}
+
+// queued returns the number of metrics in the buffer that are not part of
+// an unfinished transaction
+func (b *MemoryBuffer) queued() int {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.size
+}
+
+// dropOldest drops the oldest metric not part of an unfinished transaction
+// and returns false if there is no such metric
+func (b *MemoryBuffer) dropOldest() bool {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.size == 0 {
+		return false
+	}
+	b.metricDropped(b.buf[b.first])
+	b.buf[b.first] = nil
+	b.first = b.next(b.first)
+	b.size--
+
+	b.BufferSize.Set(int64(b.length()))
+	return true
+}
+
 func (*MemoryBuffer) Close() error {
 	return nil
 }
@@ -128,7 +181,7 @@ func (b *MemoryBuffer) Stats() BufferStats {
 }
 
 func (b *MemoryBuffer) length() int {
-	return min(b.size+b.batchSize, b.cap)
+	return min(b.size+b.inflight, b.cap)
 }
 
 func (b *MemoryBuffer) addMetric(m telegraf.Metric) int {
@@ -137,16 +190,13 @@ func (b *MemoryBuffer) addMetric(m telegraf.Metric) int {
 	if b.size == b.cap {
 		b.metricDropped(b.buf[b.last])
 		dropped++
-
-		if b.batchSize > 0 {
-			b.batchSize--
-			b.batchFirst = b.next(b.batchFirst)
-		}
 	}
 
 	b.metricAdded()
 
 	b.buf[b.last] = m
+	b.seq[b.last] = b.nextSeq
+	b.nextSeq++
 	b.last = b.next(b.last)
 
 	if b.size == b.cap {
@@ -157,6 +207,53 @@ func (b *MemoryBuffer) addMetric(m telegraf.Metric) int {
 	return dropped
 }
 
+// restoreFront puts the given metrics in front of the oldest metric in the
+// buffer. The caller must make sure that all metrics are older than the ones
+// in the buffer and that there is enough room.
+func (b *MemoryBuffer) restoreFront(batch []telegraf.Metric, seqs []uint64, indices []int) {
+	b.first = b.prevby(b.first, len(indices))
+	b.size += len(indices)
+
+	current := b.first
+	for _, idx := range indices {
+		b.buf[current] = batch[idx]
+		b.seq[current] = seqs[idx]
+		current = b.next(current)
+	}
+}
+
+// restoreMerge sorts the given metrics into the buffer according to their
+// insertion sequence. The caller must make sure that there is enough room.
+func (b *MemoryBuffer) restoreMerge(batch []telegraf.Metric, seqs []uint64, indices []int) {
+	total := b.size + len(indices)
+	metrics := make([]telegraf.Metric, 0, total)
+	order := make([]uint64, 0, total)
+
+	current := b.first
+	var k int
+	for i := 0; i < b.size; i++ {
+		for k < len(indices) && seqs[indices[k]] < b.seq[current] {
+			metrics = append(metrics, batch[indices[k]])
+			order = append(order, seqs[indices[k]])
+			k++
+		}
+		metrics = append(metrics, b.buf[current])
+		order = append(order, b.seq[current])
+		b.buf[current] = nil
+		current = b.next(current)
+	}
+	for ; k < len(indices); k++ {
+		metrics = append(metrics, batch[indices[k]])
+		order = append(order, seqs[indices[k]])
+	}
+
+	copy(b.buf, metrics)
+	copy(b.seq, order)
+	b.first = 0
+	b.last = b.nextby(0, total)
+	b.size = total
+}
+
 // next returns the next index with wrapping.
 func (b *MemoryBuffer) next(index int) int {
 	index++
@@ -183,8 +280,3 @@ func (b *MemoryBuffer) prevby(index, count int) int {
 	index %= b.cap
 	return index
 }
-
-func (b *MemoryBuffer) resetBatch() {
-	b.batchFirst = 0
-	b.batchSize = 0
-}
@@ -0,0 +1,349 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// Priority classes of metrics, lower values are written first and dropped last
+const (
+	PriorityHigh = iota
+	PriorityNormal
+	PriorityLow
+	numPriorities
+)
+
+var priorityNames = [numPriorities]string{"high", "normal", "low"}
+
+// ParsePriority returns the priority class for the given name where an empty
+// name denotes the normal priority
+func ParsePriority(name string) (int, error) {
+	if name == "" {
+		return PriorityNormal, nil
+	}
+	for class, n := range priorityNames {
+		if n == name {
+			return class, nil
+		}
+	}
+	return PriorityNormal, fmt.Errorf("invalid priority %q", name)
+}
+
+// priorityOf returns the priority class of the metric as set by the input
+func priorityOf(m telegraf.Metric) int {
+	class := PriorityNormal + metric.Priority(m)
+	if class < 0 || class >= numPriorities {
+		return PriorityNormal
+	}
+	return class
+}
+
+// setPriority sets the priority class of the metric
+func setPriority(m telegraf.Metric, class int) {
+	metric.SetPriority(m, class-PriorityNormal)
+}
+
+// priorityBuffer keeps metrics in one buffer per priority class. Metrics of
+// higher priority classes are batched first and, if the capacity is
+// exceeded, metrics of lower priority classes are dropped first. The buffers
+// of non-normal classes are only created once a metric of that class arrives.
+type priorityBuffer struct {
+	sync.Mutex
+
+	name     string
+	id       string
+	alias    string
+	capacity int
+	strategy string
+	path     string
+
+	stats   BufferStats
+	classes [numPriorities]Buffer
+}
+
+// priorityTransaction holds the transaction of a single priority class with
+// the offset of its metrics in the combined batch
+type priorityTransaction struct {
+	class  int
+	offset int
+	tx     *Transaction
+}
+
+func newPriorityBuffer(name, id, alias string, capacity int, strategy, path string) (*priorityBuffer, error) {
+	b, err := NewBuffer(name, id, alias, capacity, strategy, path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &priorityBuffer{
+		name:     name,
+		id:       id,
+		alias:    alias,
+		capacity: capacity,
+		strategy: strategy,
+		path:     path,
+		stats:    b.Stats(),
+	}
+	p.classes[PriorityNormal] = b
+
+	// Pick up the metrics of other classes persisted in a previous run
+	if strategy == "disk" {
+		for class, n := range priorityNames {
+			if class == PriorityNormal {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(path, id+"_"+n)); err == nil {
+				p.buffer(class)
+			}
+		}
+	}
+
+	return p, nil
+}
+
+func (p *priorityBuffer) Len() int {
+	p.Lock()
+	defer p.Unlock()
+
+	return p.length()
+}
+
+// Add adds the metrics to the normal priority class
+func (p *priorityBuffer) Add(metrics ...telegraf.Metric) int {
+	return p.addPriority(PriorityNormal, metrics...)
+}
+
+func (p *priorityBuffer) addPriority(class int, metrics ...telegraf.Metric) int {
+	p.Lock()
+	defer p.Unlock()
+
+	dropped := p.buffer(class).Add(metrics...)
+	dropped += p.evict()
+
+	p.updateSizes()
+	return dropped
+}
+
+func (p *priorityBuffer) BeginTransaction(batchSize int) *Transaction {
+	p.Lock()
+	defer p.Unlock()
+
+	var batch []telegraf.Metric
+	var state []priorityTransaction
+	for class, b := range p.classes {
+		if b == nil || len(batch) >= batchSize {
+			continue
+		}
+		tx := b.BeginTransaction(batchSize - len(batch))
+		if len(tx.Batch) == 0 {
+			continue
+		}
+		state = append(state, priorityTransaction{class: class, offset: len(batch), tx: tx})
+		batch = append(batch, tx.Batch...)
+	}
+
+	if len(batch) == 0 {
+		return &Transaction{}
+	}
+	return &Transaction{Batch: batch, valid: true, state: state}
+}
+
+func (p *priorityBuffer) EndTransaction(tx *Transaction) {
+	p.Lock()
+	defer p.Unlock()
+
+	// Ignore invalid transactions and make sure they can only be finished once
+	if !tx.valid {
+		return
+	}
+	tx.valid = false
+
+	// Split the accepted and rejected metrics to the class transactions
+	state := tx.state.([]priorityTransaction)
+	lookup := make([]int, len(tx.Batch))
+	for i, s := range state {
+		for j := range s.tx.Batch {
+			lookup[s.offset+j] = i
+		}
+	}
+	for _, idx := range tx.Accept {
+		s := state[lookup[idx]]
+		s.tx.Accept = append(s.tx.Accept, idx-s.offset)
+	}
+	for _, idx := range tx.Reject {
+		s := state[lookup[idx]]
+		s.tx.Reject = append(s.tx.Reject, idx-s.offset)
+	}
+
+	for _, s := range state {
+		p.classes[s.class].EndTransaction(s.tx)
+	}
+	p.evict()
+
+	p.updateSizes()
+}
+
+func (p *priorityBuffer) Stats() BufferStats {
+	return p.stats
+}
+
+func (p *priorityBuffer) Close() error {
+	p.Lock()
+	defer p.Unlock()
+
+	var err error
+	for _, b := range p.classes {
+		if b == nil {
+			continue
+		}
+		if cerr := b.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// drain removes all metrics from the memory buffers that are not part of an
+// unfinished transaction. The metrics are returned with their priority class
+// set so the class can be restored on adding.
+func (p *priorityBuffer) drain() []telegraf.Metric {
+	p.Lock()
+	defer p.Unlock()
+
+	var metrics []telegraf.Metric
+	for class, b := range p.classes {
+		buf, ok := b.(*MemoryBuffer)
+		if !ok {
+			continue
+		}
+		drained := buf.drain()
+		for _, m := range drained {
+			setPriority(m, class)
+		}
+		metrics = append(metrics, drained...)
+	}
+
+	p.updateSizes()
+	return metrics
+}
+
+// updateSizes sets the buffer size statistics of the classes and the total
+func (p *priorityBuffer) updateSizes() {
+	for _, b := range p.classes {
+		if b != nil {
+			b.Stats().BufferSize.Set(int64(b.Len()))
+		}
+	}
+	p.stats.BufferSize.Set(int64(p.length()))
+}
+
+// memoryBuffers returns true if the metrics are kept in memory
+func (p *priorityBuffer) memoryBuffers() bool {
+	_, ok := p.classes[PriorityNormal].(*MemoryBuffer)
+	return ok
+}
+
+// metricDropped accounts a drained metric as dropped for its class
+func (p *priorityBuffer) metricDropped(m telegraf.Metric) {
+	if buf, ok := p.classes[priorityOf(m)].(*MemoryBuffer); ok {
+		buf.metricDropped(m)
+		return
+	}
+	p.stats.metricDropped(m)
+}
+
+func (p *priorityBuffer) length() int {
+	var n int
+	for _, b := range p.classes {
+		if b != nil {
+			n += b.Len()
+		}
+	}
+	if p.memoryBuffers() {
+		return min(n, p.capacity)
+	}
+	return n
+}
+
+// buffer returns the buffer of the given class and creates it if necessary.
+// Metrics of classes where the buffer cannot be created are added to the
+// normal class.
+func (p *priorityBuffer) buffer(class int) Buffer {
+	if b := p.classes[class]; b != nil {
+		return b
+	}
+
+	// Start reporting the statistics per class as soon as more than the
+	// normal class is used. Initialize the normal class with the drops so far.
+	if p.classes[PriorityNormal].Stats().classDropped == nil {
+		dropped := p.registerClassStats(p.classes[PriorityNormal], PriorityNormal)
+		dropped.Set(p.stats.MetricsDropped.Get())
+	}
+
+	id := p.id + "_" + priorityNames[class]
+	b, err := NewBuffer(p.name, id, p.alias, p.capacity, p.strategy, p.path)
+	if err != nil {
+		log.Printf("E! [outputs.%s] Creating buffer for priority %q failed: %v", p.name, priorityNames[class], err)
+		return p.classes[PriorityNormal]
+	}
+	p.registerClassStats(b, class)
+	p.classes[class] = b
+
+	return b
+}
+
+// registerClassStats registers the per-class statistics and sets them for
+// the given buffer. The counters of all classes are accumulated in the
+// statistics of the output, while the buffer size of each class is reported
+// separately as the output's buffer size is the total of all classes.
+func (p *priorityBuffer) registerClassStats(b Buffer, class int) selfstat.Stat {
+	tags := map[string]string{"output": p.name, "priority": priorityNames[class]}
+	if p.alias != "" {
+		tags["alias"] = p.alias
+	}
+	dropped := selfstat.Register("write", "metrics_dropped", tags)
+	size := selfstat.Register("write", "buffer_size", tags)
+	size.Set(int64(b.Len()))
+
+	if s, ok := b.(interface {
+		setClassStats(dropped, size selfstat.Stat)
+	}); ok {
+		s.setClassStats(dropped, size)
+	}
+	return dropped
+}
+
+// evict drops the oldest metrics of the lowest priority classes until the
+// memory buffers fit the capacity and returns the number of dropped metrics
+func (p *priorityBuffer) evict() int {
+	if !p.memoryBuffers() {
+		return 0
+	}
+
+	var queued int
+	for _, b := range p.classes {
+		if b != nil {
+			queued += b.(*MemoryBuffer).queued()
+		}
+	}
+
+	var dropped int
+	for class := numPriorities - 1; class >= 0 && queued > p.capacity; class-- {
+		b := p.classes[class]
+		if b == nil {
+			continue
+		}
+		for queued > p.capacity && b.(*MemoryBuffer).dropOldest() {
+			queued--
+			dropped++
+		}
+	}
+	return dropped
+}
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/selfstat"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func priorityTestMetric(name string, value int) telegraf.Metric {
+	return metric.New(name, map[string]string{}, map[string]interface{}{"value": value}, time.Unix(int64(value), 0))
+}
+
+func TestPriorityBufferBatchHighFirst(t *testing.T) {
+	buf, err := newPriorityBuffer("priority_batch", "123", "", 10, "memory", "")
+	require.NoError(t, err)
+	defer buf.Close()
+
+	buf.addPriority(PriorityLow, priorityTestMetric("low", 1))
+	buf.addPriority(PriorityNormal, priorityTestMetric("normal", 2))
+	buf.addPriority(PriorityHigh, priorityTestMetric("high", 3))
+	buf.addPriority(PriorityNormal, priorityTestMetric("normal", 4))
+	buf.addPriority(PriorityHigh, priorityTestMetric("high", 5))
+	require.Equal(t, 5, buf.Len())
+
+	tx := buf.BeginTransaction(3)
+	expected := []telegraf.Metric{
+		priorityTestMetric("high", 3),
+		priorityTestMetric("high", 5),
+		priorityTestMetric("normal", 2),
+	}
+	testutil.RequireMetricsEqual(t, expected, tx.Batch)
+
+	// Keep the normal metric and accept the rest
+	tx.Accept = []int{0, 1}
+	buf.EndTransaction(tx)
+	require.Equal(t, 3, buf.Len())
+
+	tx = buf.BeginTransaction(3)
+	expected = []telegraf.Metric{
+		priorityTestMetric("normal", 2),
+		priorityTestMetric("normal", 4),
+		priorityTestMetric("low", 1),
+	}
+	testutil.RequireMetricsEqual(t, expected, tx.Batch)
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+	require.Zero(t, buf.Len())
+}
+
+func TestPriorityBufferEvictLowFirst(t *testing.T) {
+	buf, err := newPriorityBuffer("priority_evict", "123", "", 4, "memory", "")
+	require.NoError(t, err)
+	defer buf.Close()
+
+	var dropped int
+	dropped += buf.addPriority(PriorityNormal, priorityTestMetric("normal", 1))
+	dropped += buf.addPriority(PriorityHigh, priorityTestMetric("high", 2))
+	dropped += buf.addPriority(PriorityLow, priorityTestMetric("low", 3))
+	dropped += buf.addPriority(PriorityLow, priorityTestMetric("low", 4))
+	require.Zero(t, dropped)
+
+	// Exceeding the capacity drops the low priority metrics first
+	dropped += buf.addPriority(PriorityHigh, priorityTestMetric("high", 5))
+	dropped += buf.addPriority(PriorityHigh, priorityTestMetric("high", 6))
+	dropped += buf.addPriority(PriorityHigh, priorityTestMetric("high", 7))
+	require.Equal(t, 3, dropped)
+	require.Equal(t, 4, buf.Len())
+
+	// Check the buffer size per class and in total
+	sizes := make(map[string]int64)
+	for _, m := range selfstat.Metrics() {
+		if m.Name() != "internal_write" || m.Tags()["output"] != "priority_evict" {
+			continue
+		}
+		class, found := m.GetTag("priority")
+		if !found {
+			class = "total"
+		}
+		v, _ := m.GetField("buffer_size")
+		sizes[class] = v.(int64)
+	}
+	require.Equal(t, map[string]int64{"high": 4, "normal": 0, "low": 0, "total": 4}, sizes)
+
+	tx := buf.BeginTransaction(10)
+	expected := []telegraf.Metric{
+		priorityTestMetric("high", 2),
+		priorityTestMetric("high", 5),
+		priorityTestMetric("high", 6),
+		priorityTestMetric("high", 7),
+	}
+	testutil.RequireMetricsEqual(t, expected, tx.Batch)
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+
+	// Check the drop statistics per class
+	stats := make(map[string]int64)
+	for _, m := range selfstat.Metrics() {
+		if m.Name() != "internal_write" || m.Tags()["output"] != "priority_evict" {
+			continue
+		}
+		if class, found := m.GetTag("priority"); found {
+			v, _ := m.GetField("metrics_dropped")
+			stats[class] = v.(int64)
+		}
+	}
+	require.Equal(t, map[string]int64{"high": 0, "normal": 1, "low": 2}, stats)
+}
+
+func TestPriorityBufferDiskRestore(t *testing.T) {
+	path := t.TempDir()
+
+	buf, err := newPriorityBuffer("priority_disk", "123", "", 0, "disk", path)
+	require.NoError(t, err)
+	buf.addPriority(PriorityLow, priorityTestMetric("low", 1))
+	buf.addPriority(PriorityHigh, priorityTestMetric("high", 2))
+	require.NoError(t, buf.Close())
+
+	// A new buffer must pick up the metrics of all classes
+	buf, err = newPriorityBuffer("priority_disk", "123", "", 0, "disk", path)
+	require.NoError(t, err)
+	defer buf.Close()
+	require.Equal(t, 2, buf.Len())
+
+	tx := buf.BeginTransaction(10)
+	expected := []telegraf.Metric{
+		priorityTestMetric("high", 2),
+		priorityTestMetric("low", 1),
+	}
+	testutil.RequireMetricsEqual(t, expected, tx.Batch)
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+	require.Zero(t, buf.Len())
+}
+
+func TestPriorityDrainRestoresClass(t *testing.T) {
+	buf, err := newPriorityBuffer("priority_drain", "123", "", 10, "memory", "")
+	require.NoError(t, err)
+	defer buf.Close()
+
+	buf.addPriority(PriorityLow, priorityTestMetric("low", 1))
+	buf.addPriority(PriorityNormal, priorityTestMetric("normal", 2))
+
+	metrics := buf.drain()
+	require.Len(t, metrics, 2)
+	require.Zero(t, buf.Len())
+
+	classes := make([]int, 0, len(metrics))
+	for _, m := range metrics {
+		classes = append(classes, priorityOf(m))
+		require.Empty(t, m.TagList())
+	}
+	require.ElementsMatch(t, []int{PriorityNormal, PriorityLow}, classes)
+}
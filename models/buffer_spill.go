@@ -0,0 +1,99 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/tidwall/wal"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+// spillMetrics appends the given metrics to the WAL file at the given path
+// using the same format as the disk buffer. Tracking metrics cannot be
+// restored across Telegraf runs and are returned for further handling.
+func spillMetrics(path string, metrics []telegraf.Metric) (spilled int, skipped []telegraf.Metric, err error) {
+	registerGob()
+
+	walFile, err := wal.Open(path, nil)
+	if err != nil {
+		return 0, metrics, fmt.Errorf("failed to open wal file: %w", err)
+	}
+	defer walFile.Close()
+
+	index, err := walFile.LastIndex()
+	if err != nil {
+		return 0, metrics, fmt.Errorf("failed to determine last index: %w", err)
+	}
+
+	for i, m := range metrics {
+		if _, ok := m.(telegraf.TrackingMetric); ok {
+			skipped = append(skipped, m)
+			continue
+		}
+
+		data, err := metric.ToBytes(m)
+		if err != nil {
+			return spilled, append(skipped, metrics[i:]...), fmt.Errorf("serializing metric failed: %w", err)
+		}
+		index++
+		if err := walFile.Write(index, data); err != nil {
+			return spilled, append(skipped, metrics[i:]...), fmt.Errorf("writing metric failed: %w", err)
+		}
+		spilled++
+	}
+	return spilled, skipped, nil
+}
+
+// restoreSpilledMetrics reads all metrics from the WAL file at the given path
+// and removes the file afterwards. A non-existing file is not an error.
+func restoreSpilledMetrics(path string) ([]telegraf.Metric, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	registerGob()
+
+	walFile, err := wal.Open(path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open wal file: %w", err)
+	}
+
+	first, err := walFile.FirstIndex()
+	if err != nil {
+		walFile.Close()
+		return nil, fmt.Errorf("failed to determine first index: %w", err)
+	}
+	last, err := walFile.LastIndex()
+	if err != nil {
+		walFile.Close()
+		return nil, fmt.Errorf("failed to determine last index: %w", err)
+	}
+
+	var metrics []telegraf.Metric
+	for index := first; first > 0 && index <= last; index++ {
+		data, err := walFile.Read(index)
+		if err != nil {
+			walFile.Close()
+			return nil, fmt.Errorf("reading index %d failed: %w", index, err)
+		}
+		m, err := metric.FromBytes(data)
+		if err != nil {
+			if errors.Is(err, metric.ErrSkipTracking) {
+				continue
+			}
+			walFile.Close()
+			return nil, fmt.Errorf("deserializing index %d failed: %w", index, err)
+		}
+		metrics = append(metrics, m)
+	}
+
+	if err := walFile.Close(); err != nil {
+		return nil, fmt.Errorf("closing wal file failed: %w", err)
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return nil, fmt.Errorf("removing wal file failed: %w", err)
+	}
+	return metrics, nil
+}
@@ -934,6 +934,82 @@ func (s *BufferSuiteTest) TestPartialWriteBackToFront() {
 	s.Equal(int64(0), buf.Stats().MetricsDropped.Get(), "metrics dropped")
 }
 
+func (s *BufferSuiteTest) TestBufferConcurrentTransactions() {
+	buf := s.newTestBuffer(10)
+	defer buf.Close()
+
+	for i := range 6 {
+		buf.Add(metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(int64(i+1), 0)))
+	}
+
+	tx1 := buf.BeginTransaction(2)
+	tx2 := buf.BeginTransaction(2)
+	tx3 := buf.BeginTransaction(2)
+	testutil.RequireMetricsEqual(s.T(),
+		[]telegraf.Metric{
+			metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(3, 0)),
+			metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(4, 0)),
+		}, tx2.Batch)
+	s.Equal(6, buf.Len())
+
+	// The transactions are finished out of order
+	tx3.AcceptAll()
+	buf.EndTransaction(tx3)
+	s.Equal(4, buf.Len())
+	tx1.AcceptAll()
+	buf.EndTransaction(tx1)
+	s.Equal(2, buf.Len())
+	tx2.AcceptAll()
+	buf.EndTransaction(tx2)
+	s.Equal(0, buf.Len())
+
+	s.Equal(int64(6), buf.Stats().MetricsWritten.Get())
+	s.Equal(int64(0), buf.Stats().MetricsDropped.Get())
+}
+
+func (s *BufferSuiteTest) TestBufferConcurrentTransactionsKeepOrder() {
+	buf := s.newTestBuffer(10)
+	defer buf.Close()
+
+	for i := range 6 {
+		buf.Add(metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(int64(i+1), 0)))
+	}
+
+	tx1 := buf.BeginTransaction(2)
+	tx2 := buf.BeginTransaction(2)
+	buf.Add(metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(7, 0)))
+
+	// Finish the older transaction first and retry parts of it while the
+	// newer one is still in flight
+	tx1.KeepAll()
+	buf.EndTransaction(tx1)
+	tx3 := buf.BeginTransaction(1)
+	testutil.RequireMetricsEqual(s.T(),
+		[]telegraf.Metric{
+			metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(1, 0)),
+		}, tx3.Batch)
+	tx2.Reject = []int{0}
+	buf.EndTransaction(tx2)
+	tx3.KeepAll()
+	buf.EndTransaction(tx3)
+	s.Equal(6, buf.Len())
+
+	tx := buf.BeginTransaction(10)
+	testutil.RequireMetricsEqual(s.T(),
+		[]telegraf.Metric{
+			metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(1, 0)),
+			metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(2, 0)),
+			metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(4, 0)),
+			metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(5, 0)),
+			metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(6, 0)),
+			metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 42.0}, time.Unix(7, 0)),
+		}, tx.Batch)
+	tx.AcceptAll()
+	buf.EndTransaction(tx)
+	s.Equal(0, buf.Len())
+	s.Equal(int64(1), buf.Stats().MetricsRejected.Get())
+}
+
 type mockMetric struct {
 	telegraf.Metric
 	AcceptF func()
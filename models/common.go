@@ -14,6 +14,14 @@ func logName(pluginType, name, alias string) string {
 	return pluginType + "." + name + "::" + alias
 }
 
+// SetAliasOnPlugin passes the alias to plugins implementing the
+// telegraf.PluginWithAlias interface.
+func SetAliasOnPlugin(i interface{}, alias string) {
+	if p, ok := i.(telegraf.PluginWithAlias); ok {
+		p.SetAlias(alias)
+	}
+}
+
 func SetLoggerOnPlugin(i interface{}, logger telegraf.Logger) {
 	valI := reflect.ValueOf(i)
 
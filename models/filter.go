@@ -3,16 +3,10 @@ package models
 import (
 	"errors"
 	"fmt"
-	"time"
-
-	"github.com/google/cel-go/cel"
-	"github.com/google/cel-go/common/decls"
-	"github.com/google/cel-go/common/types"
-	"github.com/google/cel-go/common/types/ref"
-	"github.com/google/cel-go/ext"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/filter"
+	common_cel "github.com/influxdata/telegraf/plugins/common/cel"
 )
 
 // TagFilter is the name of a tag, and the values on which to filter
@@ -55,7 +49,7 @@ type Filter struct {
 
 	// New metric-filtering interface
 	MetricPass   string
-	metricFilter cel.Program
+	metricFilter *common_cel.Program
 
 	selectActive bool
 	modifyActive bool
@@ -142,19 +136,12 @@ func (f *Filter) Select(metric telegraf.Metric) (bool, error) {
 	}
 
 	if f.metricFilter != nil {
-		result, _, err := f.metricFilter.Eval(map[string]interface{}{
-			"name":   metric.Name(),
-			"tags":   metric.Tags(),
-			"fields": metric.Fields(),
-			"time":   metric.Time(),
-		})
+		vars := common_cel.Variables(metric.Name(), metric.Tags(), metric.Fields(), metric.Time())
+		r, err := f.metricFilter.EvalBool(vars)
 		if err != nil {
 			return true, err
 		}
-		if r, ok := result.Value().(bool); ok {
-			return r, nil
-		}
-		return true, fmt.Errorf("invalid result type %T", result.Value())
+		return r, nil
 	}
 
 	return true, nil
@@ -246,42 +233,23 @@ func (f *Filter) compileMetricFilter() error {
 	}
 
 	// Declare the computation environment for the filter including custom functions
-	env, err := cel.NewEnv(
-		cel.VariableDecls(
-			decls.NewVariable("name", types.StringType),
-			decls.NewVariable("tags", types.NewMapType(types.StringType, types.StringType)),
-			decls.NewVariable("fields", types.NewMapType(types.StringType, types.DynType)),
-			decls.NewVariable("time", types.TimestampType),
-		),
-		cel.Function(
-			"now",
-			cel.Overload("now", nil, cel.TimestampType),
-			cel.SingletonFunctionBinding(func(_ ...ref.Val) ref.Val { return types.Timestamp{Time: time.Now()} }),
-		),
-		ext.Encoders(),
-		ext.Math(),
-		ext.Strings(),
-	)
+	env, err := common_cel.NewEnvironment()
 	if err != nil {
-		return fmt.Errorf("creating environment failed: %w", err)
+		return err
 	}
 
 	// Compile the program
-	ast, issues := env.Compile(expression)
-	if issues.Err() != nil {
-		return issues.Err()
+	program, err := env.Compile(expression)
+	if err != nil {
+		return err
 	}
 	// Check if we got a boolean expression needed for filtering
-	if ast.OutputType() != cel.BoolType {
+	if !program.IsBool() {
 		return errors.New("expression needs to return a boolean")
 	}
+	f.metricFilter = program
 
-	// Get the final program
-	options := cel.EvalOptions(
-		cel.OptOptimize,
-	)
-	f.metricFilter, err = env.Program(ast, options)
-	return err
+	return nil
 }
 
 func ShouldPassFilters(include, exclude filter.Filter, key string) bool {
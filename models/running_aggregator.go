@@ -1,6 +1,8 @@
 package models
 
 import (
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -10,6 +12,15 @@ import (
 	"github.com/influxdata/telegraf/selfstat"
 )
 
+const (
+	// DefaultMaxOpenWindows is the default limit of simultaneously open
+	// windows in event-time aggregation
+	DefaultMaxOpenWindows = 1000
+	// DefaultFutureTolerance is the default time metrics may be ahead of the
+	// wall-clock in event-time aggregation
+	DefaultFutureTolerance = time.Hour
+)
+
 type RunningAggregator struct {
 	sync.Mutex
 	Aggregator  telegraf.Aggregator
@@ -18,13 +29,45 @@ type RunningAggregator struct {
 	periodEnd   time.Time
 	log         telegraf.Logger
 
+	// Open windows ordered by their end and idle aggregator instances for
+	// sliding and hopping windows as well as for event-time aggregation
+	windows   []*aggregatorWindow
+	instances []telegraf.Aggregator
+	creator   func() (telegraf.Aggregator, error)
+
+	// Maximum metric time observed in event-time aggregation
+	maxEventTime time.Time
+
+	// Boundaries of the window currently pushed and if the push is an update
+	// of a previously pushed window
+	pushStart  time.Time
+	pushEnd    time.Time
+	pushUpdate bool
+
 	MetricsPushed   selfstat.Stat
 	MetricsFiltered selfstat.Stat
 	MetricsDropped  selfstat.Stat
 	PushTime        selfstat.Stat
 }
 
-func NewRunningAggregator(aggregator telegraf.Aggregator, config *AggregatorConfig) *RunningAggregator {
+// aggregatorWindow is a single open window with its aggregator instance
+type aggregatorWindow struct {
+	aggregator telegraf.Aggregator
+	start      time.Time
+	end        time.Time
+
+	// State of windows in event-time aggregation, closed windows were
+	// already pushed and dirty windows received late metrics since
+	closed bool
+	dirty  bool
+}
+
+// NewRunningAggregator creates a new running aggregator. For sliding and
+// hopping windows, the additional instances are used for aggregating the
+// overlapping windows and must be configured in the same way as the given
+// aggregator. See AggregatorConfig.WindowCount for the number of required
+// instances.
+func NewRunningAggregator(aggregator telegraf.Aggregator, config *AggregatorConfig, instances ...telegraf.Aggregator) *RunningAggregator {
 	tags := map[string]string{"aggregator": config.Name}
 	if config.Alias != "" {
 		tags["alias"] = config.Alias
@@ -39,10 +82,16 @@ func NewRunningAggregator(aggregator telegraf.Aggregator, config *AggregatorConf
 		logger.Error(err)
 	}
 	SetLoggerOnPlugin(aggregator, logger)
+	SetAliasOnPlugin(aggregator, config.Alias)
+	for _, instance := range instances {
+		SetLoggerOnPlugin(instance, logger)
+		SetAliasOnPlugin(instance, config.Alias)
+	}
 
 	return &RunningAggregator{
 		Aggregator: aggregator,
 		Config:     config,
+		instances:  append([]telegraf.Aggregator{aggregator}, instances...),
 		MetricsPushed: selfstat.Register(
 			"aggregate",
 			"metrics_pushed",
@@ -79,6 +128,23 @@ type AggregatorConfig struct {
 	Grace        time.Duration
 	LogLevel     string
 
+	// Window type, advance interval of sliding and hopping windows and the
+	// timestamp used for the aggregated metrics
+	Window          string
+	Advance         time.Duration
+	WindowTimestamp string
+
+	// Event-time aggregation advancing the windows by the watermark, i.e.
+	// the maximum metric time minus the allowed lateness, and the duration
+	// for which windows are updated on late metrics after being pushed. The
+	// number of open windows and the time metrics may be ahead of the
+	// wall-clock are limited to protect against bogus timestamps.
+	EventTime        bool
+	AllowedLateness  time.Duration
+	LateUpdateWindow time.Duration
+	MaxOpenWindows   int
+	FutureTolerance  time.Duration
+
 	NameOverride      string
 	MeasurementPrefix string
 	MeasurementSuffix string
@@ -90,16 +156,127 @@ func (r *RunningAggregator) LogName() string {
 	return logName("aggregators", r.Config.Name, r.Config.Alias)
 }
 
+// WindowCount returns the maximum number of simultaneously open windows
+// requiring a separate aggregator instance each
+func (c *AggregatorConfig) WindowCount() int {
+	switch c.Window {
+	case "sliding", "hopping":
+		if c.Advance <= 0 || c.Advance >= c.Period {
+			return 1
+		}
+		return int((c.Period + c.Advance - 1) / c.Advance)
+	}
+	return 1
+}
+
+// SetInstanceCreator sets the function to create additional aggregator
+// instances on demand for event-time aggregation where the number of open
+// windows depends on the metric time. The instances must be configured in
+// the same way as the original aggregator.
+func (r *RunningAggregator) SetInstanceCreator(creator func() (telegraf.Aggregator, error)) {
+	r.creator = creator
+}
+
 func (r *RunningAggregator) Init() error {
-	if p, ok := r.Aggregator.(telegraf.Initializer); ok {
-		err := p.Init()
-		if err != nil {
-			return err
+	switch r.Config.Window {
+	case "", "tumbling":
+	case "sliding":
+		if r.Config.Advance <= 0 || r.Config.Advance >= r.Config.Period {
+			return errors.New("'advance' must be greater than zero and less than 'period' for sliding windows")
+		}
+	case "hopping":
+		if r.Config.Advance <= 0 {
+			return errors.New("'advance' must be greater than zero for hopping windows")
+		}
+	default:
+		return fmt.Errorf("invalid 'window' setting %q", r.Config.Window)
+	}
+
+	switch r.Config.WindowTimestamp {
+	case "":
+		// Pushing happens independent of the metric time in event-time mode
+		if r.Config.EventTime {
+			r.Config.WindowTimestamp = "end"
+		}
+	case "start", "end":
+	default:
+		return fmt.Errorf("invalid 'window_timestamp' setting %q", r.Config.WindowTimestamp)
+	}
+
+	if r.Config.AllowedLateness < 0 {
+		return errors.New("'allowed_lateness' must not be negative")
+	}
+	if r.Config.LateUpdateWindow < 0 {
+		return errors.New("'late_update_window' must not be negative")
+	}
+
+	if r.Config.MaxOpenWindows < 0 {
+		return errors.New("'max_open_windows' must not be negative")
+	}
+	if r.Config.MaxOpenWindows == 0 {
+		r.Config.MaxOpenWindows = DefaultMaxOpenWindows
+	}
+	if r.Config.FutureTolerance < 0 {
+		return errors.New("'future_tolerance' must not be negative")
+	}
+	if r.Config.FutureTolerance == 0 {
+		r.Config.FutureTolerance = DefaultFutureTolerance
+	}
+
+	if r.Config.EventTime {
+		if r.creator == nil {
+			return errors.New("event-time aggregation requires creating aggregator instances")
+		}
+	} else if r.windowed() && len(r.instances) < r.Config.WindowCount() {
+		return fmt.Errorf("%d aggregator instances required for the windows but got %d", r.Config.WindowCount(), len(r.instances))
+	}
+
+	for _, instance := range r.instances {
+		if p, ok := instance.(telegraf.Initializer); ok {
+			if err := p.Init(); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
+// windowed returns true for sliding and hopping windows
+func (r *RunningAggregator) windowed() bool {
+	return r.Config.Window == "sliding" || r.Config.Window == "hopping"
+}
+
+// newInstance returns an idle aggregator instance or creates a new one
+func (r *RunningAggregator) newInstance() (telegraf.Aggregator, error) {
+	if n := len(r.instances); n > 0 {
+		instance := r.instances[n-1]
+		r.instances = r.instances[:n-1]
+		return instance, nil
+	}
+	if r.creator == nil {
+		return nil, errors.New("no aggregator instance available")
+	}
+
+	instance, err := r.creator()
+	if err != nil {
+		return nil, err
+	}
+	SetLoggerOnPlugin(instance, r.log)
+	SetAliasOnPlugin(instance, r.Config.Alias)
+	if p, ok := instance.(telegraf.Initializer); ok {
+		if err := p.Init(); err != nil {
+			return nil, err
+		}
+	}
+	return instance, nil
+}
+
+// releaseInstance resets the aggregator instance and keeps it for reuse
+func (r *RunningAggregator) releaseInstance(instance telegraf.Aggregator) {
+	instance.Reset()
+	r.instances = append(r.instances, instance)
+}
+
 func (r *RunningAggregator) ID() string {
 	if p, ok := r.Aggregator.(telegraf.PluginWithID); ok {
 		return p.ID()
@@ -107,7 +284,11 @@ func (r *RunningAggregator) ID() string {
 	return r.Config.ID
 }
 
+// Period returns the interval between two pushes of the aggregator
 func (r *RunningAggregator) Period() time.Duration {
+	if r.windowed() {
+		return r.Config.Advance
+	}
 	return r.Config.Period
 }
 
@@ -119,6 +300,40 @@ func (r *RunningAggregator) UpdateWindow(start, until time.Time) {
 	r.periodStart = start
 	r.periodEnd = until
 	r.log.Debugf("Updated aggregation range [%s, %s]", start, until)
+
+	if r.windowed() && !r.Config.EventTime {
+		r.updateWindows(until)
+	}
+}
+
+// updateWindows makes sure the windows ending at the given time and all
+// following overlapping windows are open. If the windows are not aligned
+// with the given time, e.g. due to clock changes, all windows are discarded.
+func (r *RunningAggregator) updateWindows(until time.Time) {
+	if len(r.windows) > 0 && !r.windows[0].end.Equal(until) {
+		for _, w := range r.windows {
+			r.releaseInstance(w.aggregator)
+		}
+		r.windows = nil
+	}
+
+	for len(r.windows) < r.Config.WindowCount() {
+		end := until
+		if len(r.windows) > 0 {
+			end = r.windows[len(r.windows)-1].end.Add(r.Config.Advance)
+		}
+
+		aggregator, err := r.newInstance()
+		if err != nil {
+			r.log.Errorf("Creating aggregator instance failed: %v", err)
+			return
+		}
+		r.windows = append(r.windows, &aggregatorWindow{
+			aggregator: aggregator,
+			start:      end.Add(-r.Config.Period),
+			end:        end,
+		})
+	}
 }
 
 func (r *RunningAggregator) MakeMetric(telegrafMetric telegraf.Metric) telegraf.Metric {
@@ -130,6 +345,16 @@ func (r *RunningAggregator) MakeMetric(telegrafMetric telegraf.Metric) telegraf.
 		r.Config.Tags,
 		nil)
 
+	switch r.Config.WindowTimestamp {
+	case "start":
+		m.SetTime(r.pushStart)
+	case "end":
+		m.SetTime(r.pushEnd)
+	}
+	if r.pushUpdate {
+		m.AddTag("update", "true")
+	}
+
 	r.MetricsPushed.Incr(1)
 
 	return m
@@ -160,6 +385,15 @@ func (r *RunningAggregator) Add(m telegraf.Metric) bool {
 	r.Lock()
 	defer r.Unlock()
 
+	switch {
+	case r.Config.EventTime:
+		r.addEventTime(m)
+		return r.Config.DropOriginal
+	case r.windowed():
+		r.addWindowed(m)
+		return r.Config.DropOriginal
+	}
+
 	if m.Time().Before(r.periodStart.Add(-r.Config.Grace)) || m.Time().After(r.periodEnd.Add(r.Config.Delay)) {
 		r.log.Debugf("Metric is outside aggregation window; discarding. %s: m: %s e: %s g: %s",
 			m.Time(), r.periodStart, r.periodEnd, r.Config.Grace)
@@ -171,12 +405,35 @@ func (r *RunningAggregator) Add(m telegraf.Metric) bool {
 	return r.Config.DropOriginal
 }
 
+// addWindowed adds the metric to all open windows it belongs to
+func (r *RunningAggregator) addWindowed(m telegraf.Metric) {
+	var matching []telegraf.Aggregator
+	for _, w := range r.windows {
+		if m.Time().Before(w.start.Add(-r.Config.Grace)) || m.Time().After(w.end.Add(r.Config.Delay)) {
+			continue
+		}
+		matching = append(matching, w.aggregator)
+	}
+
+	if len(matching) == 0 {
+		r.log.Debugf("Metric is outside all aggregation windows; discarding. %s: g: %s", m.Time(), r.Config.Grace)
+		r.MetricsDropped.Incr(1)
+		return
+	}
+
+	// Each aggregator gets its own copy as aggregators might keep the metric
+	for _, aggregator := range matching[1:] {
+		aggregator.Add(m.Copy())
+	}
+	matching[0].Add(m)
+}
+
 func (r *RunningAggregator) Push(acc telegraf.Accumulator) {
 	r.Lock()
 	defer r.Unlock()
 
 	since := r.periodEnd
-	until := r.periodEnd.Add(r.Config.Period)
+	until := r.periodEnd.Add(r.Period())
 
 	// Check if the next aggregation window will contain "now". This might
 	// not be the case if the machine's clock was adjusted or the machine
@@ -184,17 +441,47 @@ func (r *RunningAggregator) Push(acc telegraf.Accumulator) {
 	// after the initial aggregation window.
 	nowWall := time.Now().Truncate(-1)
 	if nowWall.Before(since.Truncate(-1)) || nowWall.After(until.Truncate(-1)) {
-		since = nowWall.Truncate(r.Config.Period)
-		until = since.Add(r.Config.Period)
+		since = nowWall.Truncate(r.Period())
+		until = since.Add(r.Period())
+	}
+
+	// In event-time mode the wall-clock only determines when to push
+	if r.Config.EventTime {
+		r.UpdateWindow(since, until)
+		r.pushEventTime(acc)
+		return
 	}
 
+	if !r.windowed() {
+		start, end := r.periodStart, r.periodEnd
+		r.UpdateWindow(since, until)
+		r.push(acc, r.Aggregator, start, end)
+		r.Aggregator.Reset()
+		return
+	}
+
+	// Push the oldest window and reuse its aggregator for the next window
+	if len(r.windows) == 0 {
+		r.UpdateWindow(since, until)
+		return
+	}
+	w := r.windows[0]
+	r.windows = r.windows[1:]
+	r.push(acc, w.aggregator, w.start, w.end)
+	r.releaseInstance(w.aggregator)
 	r.UpdateWindow(since, until)
+}
+
+// push outputs the aggregates of the given instance for the given window
+// without resetting the instance
+func (r *RunningAggregator) push(acc telegraf.Accumulator, aggregator telegraf.Aggregator, start, end time.Time) {
+	r.pushStart = start
+	r.pushEnd = end
 
-	start := time.Now()
-	r.Aggregator.Push(acc)
-	elapsed := time.Since(start)
+	begin := time.Now()
+	aggregator.Push(acc)
+	elapsed := time.Since(begin)
 	r.PushTime.Incr(elapsed.Nanoseconds())
-	r.Aggregator.Reset()
 }
 
 func (r *RunningAggregator) Log() telegraf.Logger {
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// watermark returns the time up to which all windows are considered complete
+// in event-time aggregation
+func (r *RunningAggregator) watermark() time.Time {
+	return r.maxEventTime.Add(-r.Config.AllowedLateness)
+}
+
+// eventWindows returns the boundaries of all windows containing the given
+// time. Windows are aligned to multiples of the period or, for sliding and
+// hopping windows, to multiples of the advance interval.
+func (r *RunningAggregator) eventWindows(t time.Time) (starts []time.Time) {
+	if !r.windowed() {
+		return []time.Time{t.Truncate(r.Config.Period)}
+	}
+
+	for start := t.Truncate(r.Config.Advance); t.Before(start.Add(r.Config.Period)); start = start.Add(-r.Config.Advance) {
+		starts = append(starts, start)
+	}
+	return starts
+}
+
+// addEventTime adds the metric to all windows it belongs to according to
+// the metric time, opening the windows if necessary. Metrics for windows
+// already pushed are only added if the window is still kept for updates.
+// Metrics too far in the future are dropped as they would advance the
+// watermark and thus close all open windows prematurely.
+func (r *RunningAggregator) addEventTime(m telegraf.Metric) {
+	if limit := time.Now().Add(r.Config.FutureTolerance); m.Time().After(limit) {
+		r.log.Debugf("Metric is too far in the future; discarding. %s: l: %s", m.Time(), limit)
+		r.MetricsDropped.Incr(1)
+		return
+	}
+	if m.Time().After(r.maxEventTime) {
+		r.maxEventTime = m.Time()
+	}
+
+	// Windows ending before this limit might have been pushed and discarded
+	// already so we cannot accept metrics for those anymore
+	limit := r.watermark().Add(-r.Config.LateUpdateWindow)
+
+	var matching []*aggregatorWindow
+	for _, start := range r.eventWindows(m.Time()) {
+		end := start.Add(r.Config.Period)
+		idx := sort.Search(len(r.windows), func(i int) bool { return !r.windows[i].end.Before(end) })
+		if idx < len(r.windows) && r.windows[idx].end.Equal(end) {
+			matching = append(matching, r.windows[idx])
+			continue
+		}
+		if !end.After(limit) {
+			continue
+		}
+		if len(r.windows) >= r.Config.MaxOpenWindows {
+			r.log.Debugf("Maximum number of %d open windows reached; not opening window [%s, %s]", r.Config.MaxOpenWindows, start, end)
+			continue
+		}
+
+		aggregator, err := r.newInstance()
+		if err != nil {
+			r.log.Errorf("Creating aggregator instance failed: %v", err)
+			continue
+		}
+		w := &aggregatorWindow{aggregator: aggregator, start: start, end: end}
+		r.windows = append(r.windows, nil)
+		copy(r.windows[idx+1:], r.windows[idx:])
+		r.windows[idx] = w
+		matching = append(matching, w)
+	}
+
+	if len(matching) == 0 {
+		r.log.Debugf("Metric is outside all open windows; discarding. %s: w: %s", m.Time(), r.watermark())
+		r.MetricsDropped.Incr(1)
+		return
+	}
+
+	// Each aggregator gets its own copy as aggregators might keep the metric
+	for i, w := range matching {
+		if w.closed {
+			w.dirty = true
+		}
+		if i > 0 {
+			w.aggregator.Add(m.Copy())
+		} else {
+			w.aggregator.Add(m)
+		}
+	}
+}
+
+// pushEventTime pushes all windows complete according to the watermark and
+// the updates of windows receiving late metrics. Windows are discarded once
+// they cannot receive updates anymore.
+func (r *RunningAggregator) pushEventTime(acc telegraf.Accumulator) {
+	watermark := r.watermark()
+	limit := watermark.Add(-r.Config.LateUpdateWindow)
+
+	open := make([]*aggregatorWindow, 0, len(r.windows))
+	for _, w := range r.windows {
+		switch {
+		case !w.closed && !w.end.After(watermark):
+			r.push(acc, w.aggregator, w.start, w.end)
+			w.closed = true
+		case w.dirty:
+			r.pushUpdate = true
+			r.push(acc, w.aggregator, w.start, w.end)
+			r.pushUpdate = false
+			w.dirty = false
+		}
+
+		if w.closed && !w.end.After(limit) {
+			r.releaseInstance(w.aggregator)
+			continue
+		}
+		open = append(open, w)
+	}
+	r.windows = open
+}
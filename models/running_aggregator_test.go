@@ -265,3 +265,219 @@ func (t *mockAggregator) Add(in telegraf.Metric) {
 		}
 	}
 }
+
+func TestRunningAggregatorSlidingWindow(t *testing.T) {
+	instances := []telegraf.Aggregator{&mockAggregator{}, &mockAggregator{}}
+	ra := NewRunningAggregator(&mockAggregator{}, &AggregatorConfig{
+		Name:            "TestRunningAggregator",
+		Period:          3 * time.Second,
+		Advance:         time.Second,
+		Window:          "sliding",
+		WindowTimestamp: "end",
+	}, instances...)
+	require.NoError(t, ra.Init())
+	require.Equal(t, 3, ra.Config.WindowCount())
+	require.Equal(t, time.Second, ra.Period())
+
+	// Use a window in the future to avoid clock checks on push
+	start := time.Now().Truncate(time.Second).Add(time.Hour)
+	ra.UpdateWindow(start.Add(-time.Second), start)
+	require.Len(t, ra.windows, 3)
+
+	// Each metric must end up in all windows covering its timestamp
+	for i := range 4 {
+		m := testutil.MustMetric("RITest",
+			map[string]string{},
+			map[string]interface{}{"value": int64(1) << i},
+			start.Add(time.Duration(i)*time.Second-500*time.Millisecond),
+		)
+		require.False(t, ra.Add(m))
+	}
+
+	var acc testutil.Accumulator
+	var sums []int64
+	var timestamps []time.Time
+	for range 3 {
+		w := ra.windows[0]
+		require.Equal(t, ra.EndPeriod(), w.end)
+		w.aggregator.Push(&acc)
+		sums = append(sums, acc.Metrics[len(acc.Metrics)-1].Fields["sum"].(int64))
+		timestamps = append(timestamps, w.end)
+
+		// Advance the windows without relying on the wall-clock
+		ra.windows = ra.windows[1:]
+		w.aggregator.Reset()
+		ra.instances = append(ra.instances, w.aggregator)
+		ra.UpdateWindow(w.end, w.end.Add(ra.Period()))
+		require.Len(t, ra.windows, 3)
+	}
+	// Windows: [start-3s, start), [start-2s, start+1s), [start-1s, start+2s)
+	require.Equal(t, []int64{1, 3, 7}, sums)
+	require.Equal(t, []time.Time{start, start.Add(time.Second), start.Add(2 * time.Second)}, timestamps)
+}
+
+func TestRunningAggregatorHoppingWindowPush(t *testing.T) {
+	ra := NewRunningAggregator(&mockAggregator{}, &AggregatorConfig{
+		Name:            "TestRunningAggregator",
+		Period:          500 * time.Millisecond,
+		Advance:         time.Second,
+		Window:          "hopping",
+		WindowTimestamp: "start",
+	})
+	require.NoError(t, ra.Init())
+	require.Equal(t, 1, ra.Config.WindowCount())
+
+	now := time.Now()
+	ra.UpdateWindow(now, now.Add(ra.Period()))
+	w := ra.windows[0]
+	require.Equal(t, now.Add(500*time.Millisecond), w.start)
+
+	// Metrics in the gap between windows are ignored
+	m := testutil.MustMetric("RITest", map[string]string{}, map[string]interface{}{"value": int64(1)}, now.Add(100*time.Millisecond))
+	require.False(t, ra.Add(m))
+	m = testutil.MustMetric("RITest", map[string]string{}, map[string]interface{}{"value": int64(2)}, now.Add(600*time.Millisecond))
+	require.False(t, ra.Add(m))
+
+	var acc testutil.Accumulator
+	ra.Push(&acc)
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, int64(2), acc.Metrics[0].Fields["sum"])
+
+	// Metrics created during the push get the start of the window
+	pushed := ra.MakeMetric(testutil.MustMetric("TestMetric", map[string]string{}, map[string]interface{}{"sum": int64(2)}, now))
+	require.Equal(t, w.start, pushed.Time())
+}
+
+func TestRunningAggregatorWindowInvalid(t *testing.T) {
+	ra := NewRunningAggregator(&mockAggregator{}, &AggregatorConfig{
+		Name:    "TestRunningAggregator",
+		Period:  time.Second,
+		Advance: time.Second,
+		Window:  "sliding",
+	})
+	require.ErrorContains(t, ra.Init(), "'advance' must be greater than zero and less than 'period'")
+
+	ra = NewRunningAggregator(&mockAggregator{}, &AggregatorConfig{
+		Name:    "TestRunningAggregator",
+		Period:  time.Second,
+		Advance: 100 * time.Millisecond,
+		Window:  "sliding",
+	})
+	require.ErrorContains(t, ra.Init(), "10 aggregator instances required")
+
+	ra = NewRunningAggregator(&mockAggregator{}, &AggregatorConfig{
+		Name:      "TestRunningAggregator",
+		Period:    time.Second,
+		EventTime: true,
+	})
+	require.ErrorContains(t, ra.Init(), "requires creating aggregator instances")
+}
+
+func TestRunningAggregatorEventTime(t *testing.T) {
+	ra := NewRunningAggregator(&mockAggregator{}, &AggregatorConfig{
+		Name:             "TestRunningAggregator",
+		Period:           10 * time.Second,
+		EventTime:        true,
+		AllowedLateness:  5 * time.Second,
+		LateUpdateWindow: 20 * time.Second,
+	})
+	ra.SetInstanceCreator(func() (telegraf.Aggregator, error) { return &mockAggregator{}, nil })
+	require.NoError(t, ra.Init())
+	require.Equal(t, "end", ra.Config.WindowTimestamp)
+
+	// Metrics far behind the wall-clock must be accepted
+	base := time.Unix(1700000000, 0)
+	add := func(offset time.Duration, value int64) {
+		m := testutil.MustMetric("RITest", map[string]string{}, map[string]interface{}{"value": value}, base.Add(offset))
+		require.False(t, ra.Add(m))
+	}
+	add(1*time.Second, 1)
+	add(12*time.Second, 2)
+	add(14*time.Second, 4)
+	require.Len(t, ra.windows, 2)
+
+	// The watermark is at 9s so no window is complete yet
+	var acc testutil.Accumulator
+	ra.pushEventTime(&acc)
+	require.Empty(t, acc.Metrics)
+
+	// Advance the watermark to 21s completing both windows
+	add(26*time.Second, 8)
+	ra.pushEventTime(&acc)
+	require.Len(t, acc.Metrics, 2)
+	require.Equal(t, int64(1), acc.Metrics[0].Fields["sum"])
+	require.Equal(t, int64(6), acc.Metrics[1].Fields["sum"])
+
+	// A late metric updates the closed window and is pushed as an update
+	add(3*time.Second, 16)
+	require.True(t, ra.windows[0].dirty)
+	acc.ClearMetrics()
+	ra.pushEventTime(&acc)
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, int64(17), acc.Metrics[0].Fields["sum"])
+
+	// Pushing an update marks the metrics
+	ra.pushUpdate = true
+	m := ra.MakeMetric(testutil.MustMetric("TestMetric", map[string]string{}, map[string]interface{}{"sum": int64(17)}, base))
+	ra.pushUpdate = false
+	require.Equal(t, map[string]string{"update": "true"}, m.Tags())
+
+	// Once the watermark passed the update window, late metrics are dropped
+	add(60*time.Second, 32)
+	ra.pushEventTime(&acc)
+	dropped := ra.MetricsDropped.Get()
+	add(2*time.Second, 64)
+	require.Equal(t, dropped+1, ra.MetricsDropped.Get())
+}
+
+func TestRunningAggregatorEventTimeLimits(t *testing.T) {
+	ra := NewRunningAggregator(&mockAggregator{}, &AggregatorConfig{
+		Name:            "TestRunningAggregator",
+		Period:          10 * time.Second,
+		EventTime:       true,
+		AllowedLateness: time.Hour,
+		MaxOpenWindows:  2,
+		FutureTolerance: time.Minute,
+	})
+	ra.SetInstanceCreator(func() (telegraf.Aggregator, error) { return &mockAggregator{}, nil })
+	require.NoError(t, ra.Init())
+
+	base := time.Now().Truncate(10 * time.Second)
+	add := func(ts time.Time) {
+		m := testutil.MustMetric("RITest", map[string]string{}, map[string]interface{}{"value": int64(1)}, ts)
+		require.False(t, ra.Add(m))
+	}
+
+	// Metrics too far in the future neither open windows nor advance the
+	// watermark
+	dropped := ra.MetricsDropped.Get()
+	add(base.Add(24 * time.Hour))
+	require.Empty(t, ra.windows)
+	require.True(t, ra.maxEventTime.IsZero())
+	require.Equal(t, dropped+1, ra.MetricsDropped.Get())
+
+	// Only the maximum number of windows is opened
+	add(base.Add(-30 * time.Second))
+	add(base.Add(-20 * time.Second))
+	add(base.Add(-10 * time.Second))
+	require.Len(t, ra.windows, 2)
+	require.Equal(t, dropped+2, ra.MetricsDropped.Get())
+
+	// Metrics for the open windows are still accepted
+	add(base.Add(-25 * time.Second))
+	require.Equal(t, dropped+2, ra.MetricsDropped.Get())
+}
+
+func TestRunningAggregatorEventTimeSlidingWindows(t *testing.T) {
+	ra := NewRunningAggregator(&mockAggregator{}, &AggregatorConfig{
+		Name:      "TestRunningAggregator",
+		Period:    3 * time.Second,
+		Advance:   time.Second,
+		Window:    "sliding",
+		EventTime: true,
+	})
+
+	base := time.Unix(1700000000, 0)
+	expected := []time.Time{base, base.Add(-time.Second), base.Add(-2 * time.Second)}
+	require.Equal(t, expected, ra.eventWindows(base.Add(500*time.Millisecond)))
+}
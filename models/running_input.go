@@ -24,6 +24,7 @@ type RunningInput struct {
 	log         telegraf.Logger
 	defaultTags map[string]string
 
+	priority    int
 	startAcc    telegraf.Accumulator
 	started     bool
 	retries     uint64
@@ -52,6 +53,7 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 		logger.Error(err)
 	}
 	SetLoggerOnPlugin(input, logger)
+	SetAliasOnPlugin(input, config.Alias)
 
 	return &RunningInput{
 		Input:  input,
@@ -76,7 +78,8 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 			"startup_errors",
 			tags,
 		),
-		log: logger,
+		log:      logger,
+		priority: PriorityNormal,
 	}
 }
 
@@ -93,6 +96,7 @@ type InputConfig struct {
 	TimeSource           string
 	StartupErrorBehavior string
 	LogLevel             string
+	Priority             string
 
 	NameOverride            string
 	MeasurementPrefix       string
@@ -126,6 +130,12 @@ func (r *RunningInput) Init() error {
 		return fmt.Errorf("invalid 'time_source' setting %q", r.Config.TimeSource)
 	}
 
+	priority, err := ParsePriority(r.Config.Priority)
+	if err != nil {
+		return fmt.Errorf("invalid 'priority' setting %q", r.Config.Priority)
+	}
+	r.priority = priority
+
 	if p, ok := r.Input.(telegraf.Initializer); ok {
 		return p.Init()
 	}
@@ -234,6 +244,11 @@ func (r *RunningInput) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 	default:
 	}
 
+	// Mark the metric with its priority class for the outputs
+	if r.priority != PriorityNormal {
+		setPriority(metric, r.priority)
+	}
+
 	r.MetricsGathered.Incr(1)
 	GlobalMetricsGathered.Incr(1)
 	return metric
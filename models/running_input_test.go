@@ -117,6 +117,19 @@ func TestRunningInputMakeMetricWithPluginTags(t *testing.T) {
 	require.Equal(t, expected, actual)
 }
 
+func TestRunningInputPriority(t *testing.T) {
+	ri := NewRunningInput(&mockInput{}, &InputConfig{Name: "TestRunningInput", Priority: "high"})
+	require.NoError(t, ri.Init())
+
+	m := ri.MakeMetric(testutil.TestMetric(101, "RITest"))
+	require.Equal(t, PriorityHigh, priorityOf(m))
+	require.Equal(t, map[string]string{"tag1": "value1"}, m.Tags())
+	require.Equal(t, PriorityHigh, priorityOf(m.Copy()))
+
+	ri = NewRunningInput(&mockInput{}, &InputConfig{Name: "TestRunningInput", Priority: "urgent"})
+	require.ErrorContains(t, ri.Init(), "invalid 'priority' setting")
+}
+
 func TestRunningInputMakeMetricFilteredOut(t *testing.T) {
 	now := time.Now()
 	ri := NewRunningInput(&mockInput{}, &InputConfig{
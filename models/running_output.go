@@ -3,6 +3,7 @@ package models
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -34,6 +35,7 @@ type OutputConfig struct {
 	FlushJitter       time.Duration
 	MetricBufferLimit int
 	MetricBatchSize   int
+	MaxInFlight       int
 
 	NameOverride string
 	NamePrefix   string
@@ -41,6 +43,7 @@ type OutputConfig struct {
 
 	BufferStrategy  string
 	BufferDirectory string
+	DrainPolicy     string
 
 	LogLevel string
 }
@@ -55,6 +58,7 @@ type RunningOutput struct {
 	Config            *OutputConfig
 	MetricBufferLimit int
 	MetricBatchSize   int
+	MaxInFlight       int
 
 	MetricsFiltered selfstat.Stat
 	WriteTime       selfstat.Stat
@@ -62,7 +66,7 @@ type RunningOutput struct {
 
 	BatchReady chan time.Time
 
-	buffer Buffer
+	buffer *priorityBuffer
 	log    telegraf.Logger
 
 	started bool
@@ -86,6 +90,7 @@ func NewRunningOutput(output telegraf.Output, config *OutputConfig, batchSize, b
 		logger.Error(err)
 	}
 	SetLoggerOnPlugin(output, logger)
+	SetAliasOnPlugin(output, config.Alias)
 
 	if config.MetricBufferLimit > 0 {
 		bufferLimit = config.MetricBufferLimit
@@ -100,7 +105,16 @@ func NewRunningOutput(output telegraf.Output, config *OutputConfig, batchSize, b
 		batchSize = DefaultMetricBatchSize
 	}
 
-	b, err := NewBuffer(config.Name, config.ID, config.Alias, bufferLimit, config.BufferStrategy, config.BufferDirectory)
+	maxInFlight := config.MaxInFlight
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+	if p, ok := output.(telegraf.OrderedOutput); ok && p.RequiresOrdering() && maxInFlight > 1 {
+		logger.Warn("Output requires ordered writes, ignoring 'max_in_flight' setting")
+		maxInFlight = 1
+	}
+
+	b, err := newPriorityBuffer(config.Name, config.ID, config.Alias, bufferLimit, config.BufferStrategy, config.BufferDirectory)
 	if err != nil {
 		panic(err)
 	}
@@ -112,6 +126,7 @@ func NewRunningOutput(output telegraf.Output, config *OutputConfig, batchSize, b
 		Config:            config,
 		MetricBufferLimit: bufferLimit,
 		MetricBatchSize:   batchSize,
+		MaxInFlight:       maxInFlight,
 		MetricsFiltered: selfstat.Register(
 			"write",
 			"metrics_filtered",
@@ -156,6 +171,19 @@ func (r *RunningOutput) Init() error {
 		return fmt.Errorf("invalid 'startup_error_behavior' setting %q", r.Config.StartupErrorBehavior)
 	}
 
+	switch r.Config.DrainPolicy {
+	case "", "flush", "discard":
+	case "spill":
+		if r.Config.BufferDirectory == "" {
+			return errors.New("drain policy 'spill' requires a 'buffer_directory' setting")
+		}
+		if r.Config.ID == "" {
+			return errors.New("drain policy 'spill' requires a plugin ID")
+		}
+	default:
+		return fmt.Errorf("invalid 'drain_policy' setting %q", r.Config.DrainPolicy)
+	}
+
 	if p, ok := r.Output.(telegraf.Initializer); ok {
 		err := p.Init()
 		if err != nil {
@@ -260,7 +288,7 @@ func (r *RunningOutput) add(metric telegraf.Metric) {
 		metric.AddSuffix(r.Config.NameSuffix)
 	}
 
-	dropped := r.buffer.Add(metric)
+	dropped := r.buffer.addPriority(priorityOf(metric), metric)
 	atomic.AddInt64(&r.droppedMetrics, int64(dropped))
 
 	count := atomic.AddInt64(&r.newMetricsCount, 1)
@@ -306,6 +334,10 @@ func (r *RunningOutput) Write() error {
 	// writing will be sent on the next call.
 	nBuffer := r.buffer.Len()
 	nBatches := nBuffer/r.MetricBatchSize + 1
+	if r.MaxInFlight > 1 {
+		return r.writeInFlight(nBatches)
+	}
+
 	for i := 0; i < nBatches; i++ {
 		tx := r.buffer.BeginTransaction(r.MetricBatchSize)
 		if len(tx.Batch) == 0 {
@@ -321,6 +353,54 @@ func (r *RunningOutput) Write() error {
 	return nil
 }
 
+// writeInFlight writes up to the given number of batches to the output with
+// at most MaxInFlight batches being written concurrently. Each batch is a
+// separate transaction so failed batches are returned to the buffer for
+// retrying independently of the other batches. No new batches are started
+// after a write failed.
+func (r *RunningOutput) writeInFlight(nBatches int) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+
+	slots := make(chan struct{}, r.MaxInFlight)
+	for i := 0; i < nBatches; i++ {
+		slots <- struct{}{}
+
+		mu.Lock()
+		failed := len(errs) > 0
+		mu.Unlock()
+		if failed {
+			<-slots
+			break
+		}
+
+		tx := r.buffer.BeginTransaction(r.MetricBatchSize)
+		if len(tx.Batch) == 0 {
+			<-slots
+			break
+		}
+
+		wg.Add(1)
+		go func(tx *Transaction) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			err := r.writeMetrics(tx.Batch)
+			r.updateTransaction(tx, err)
+			r.buffer.EndTransaction(tx)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(tx)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
 // WriteBatch writes a single batch of metrics to the output.
 func (r *RunningOutput) WriteBatch() error {
 	// Try to connect if we are not yet started up
@@ -392,6 +472,64 @@ func (r *RunningOutput) LogBufferStatus() {
 	}
 }
 
+// RestoreSpilled adds the metrics spilled to the buffer directory during a
+// previous shutdown to the output's buffer. Outputs using the disk buffer
+// strategy pick up the metrics directly so nothing is done for those.
+func (r *RunningOutput) RestoreSpilled() (int, error) {
+	if !r.buffer.memoryBuffers() || r.Config.BufferDirectory == "" || r.Config.ID == "" {
+		return 0, nil
+	}
+
+	metrics, err := restoreSpilledMetrics(r.spillPath())
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range metrics {
+		dropped := r.buffer.addPriority(priorityOf(m), m)
+		atomic.AddInt64(&r.droppedMetrics, int64(dropped))
+	}
+
+	return len(metrics), nil
+}
+
+// Spill moves all metrics of the memory buffer, not currently written, to the
+// buffer directory to be restored on the next start. Tracking metrics cannot
+// be restored and are dropped, so the corresponding input can redeliver them.
+// Outputs using the disk buffer strategy already persist all metrics so
+// nothing is done for those.
+func (r *RunningOutput) Spill() (int, error) {
+	if !r.buffer.memoryBuffers() {
+		return 0, nil
+	}
+
+	metrics := r.buffer.drain()
+	spilled, skipped, err := spillMetrics(r.spillPath(), metrics)
+	for _, m := range skipped {
+		r.buffer.metricDropped(m)
+	}
+	return spilled, err
+}
+
+// spillPath returns the location of the spilled metrics of the output kept
+// apart from the files of the disk buffer in the same directory.
+func (r *RunningOutput) spillPath() string {
+	return filepath.Join(r.Config.BufferDirectory, "spill", r.Config.ID)
+}
+
+// Discard drops all metrics of the memory buffer not currently written.
+// Outputs using the disk buffer strategy keep their metrics on disk.
+func (r *RunningOutput) Discard() int {
+	if !r.buffer.memoryBuffers() {
+		return 0
+	}
+
+	metrics := r.buffer.drain()
+	for _, m := range metrics {
+		r.buffer.metricDropped(m)
+	}
+	return len(metrics)
+}
+
 func (r *RunningOutput) Log() telegraf.Logger {
 	return r.log
 }
@@ -2,6 +2,7 @@ package models
 
 import (
 	"errors"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/selfstat"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -182,6 +184,36 @@ func TestRunningOutputNameOverride(t *testing.T) {
 	require.Equal(t, "new_metric_name", m.Metrics()[0].Name())
 }
 
+// Test that metrics of high priority inputs are written first even if the
+// tags are filtered
+func TestRunningOutputPriority(t *testing.T) {
+	low := NewRunningInput(&mockInput{}, &InputConfig{Name: "low", Priority: "low"})
+	require.NoError(t, low.Init())
+	high := NewRunningInput(&mockInput{}, &InputConfig{Name: "high", Priority: "high"})
+	require.NoError(t, high.Init())
+
+	conf := &OutputConfig{
+		Filter: Filter{
+			TagExclude: []string{"tag1"},
+		},
+	}
+	require.NoError(t, conf.Filter.Compile())
+
+	m := &mockOutput{}
+	ro := NewRunningOutput(m, conf, 2, 10000)
+	ro.AddMetric(low.MakeMetric(testutil.TestMetric(1, "low")))
+	ro.AddMetric(high.MakeMetric(testutil.TestMetric(2, "high")))
+	ro.AddMetric(testutil.TestMetric(3, "normal"))
+
+	require.NoError(t, ro.WriteBatch())
+	require.Len(t, m.Metrics(), 2)
+	require.Equal(t, "high", m.Metrics()[0].Name())
+	require.Equal(t, "normal", m.Metrics()[1].Name())
+	for _, metric := range m.Metrics() {
+		require.Empty(t, metric.Tags())
+	}
+}
+
 // Test that measurement name prefix is added correctly
 func TestRunningOutputNamePrefix(t *testing.T) {
 	conf := &OutputConfig{
@@ -864,6 +896,133 @@ func TestRunningOutputWriteBatchPartialSuccessAndLoss(t *testing.T) {
 	require.Zero(t, model.buffer.Len())
 }
 
+func TestRunningOutputWriteInFlight(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:      Filter{},
+		MaxInFlight: 3,
+	}
+
+	m := &concurrentOutput{delay: 50 * time.Millisecond}
+	ro := NewRunningOutput(m, conf, 2, 100)
+	require.Equal(t, 3, ro.MaxInFlight)
+
+	for _, metric := range append(first5, next5...) {
+		ro.AddMetric(metric)
+	}
+	require.NoError(t, ro.Write())
+	require.Equal(t, 10, m.written)
+	require.Equal(t, 5, m.writes)
+	require.Equal(t, 3, m.maxActive)
+	require.Zero(t, ro.BufferLength())
+}
+
+func TestRunningOutputWriteInFlightFail(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:      Filter{},
+		MaxInFlight: 2,
+	}
+
+	m := &concurrentOutput{fail: true}
+	ro := NewRunningOutput(m, conf, 2, 100)
+
+	for _, metric := range append(first5, next5...) {
+		ro.AddMetric(metric)
+	}
+	require.ErrorContains(t, ro.Write(), "failed write")
+	require.LessOrEqual(t, m.writes, 2)
+	require.Zero(t, m.written)
+	require.Equal(t, 10, ro.BufferLength())
+
+	// Failed batches must be kept in order for the next write
+	m.fail = false
+	conf.MaxInFlight = 1
+	ro.MaxInFlight = 1
+	require.NoError(t, ro.Write())
+	testutil.RequireMetricsEqual(t, append(first5, next5...), m.metrics)
+}
+
+func TestRunningOutputWriteInFlightOrdered(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:      Filter{},
+		MaxInFlight: 3,
+	}
+
+	m := &concurrentOutput{ordered: true}
+	ro := NewRunningOutput(m, conf, 2, 100)
+	require.Equal(t, 1, ro.MaxInFlight)
+
+	for _, metric := range append(first5, next5...) {
+		ro.AddMetric(metric)
+	}
+	require.NoError(t, ro.Write())
+	require.Equal(t, 1, m.maxActive)
+	testutil.RequireMetricsEqual(t, append(first5, next5...), m.metrics)
+}
+
+func TestRunningOutputDrainPolicyInvalid(t *testing.T) {
+	ro := NewRunningOutput(&mockOutput{}, &OutputConfig{DrainPolicy: "foo"}, 1000, 10000)
+	require.ErrorContains(t, ro.Init(), "invalid 'drain_policy' setting")
+
+	ro = NewRunningOutput(&mockOutput{}, &OutputConfig{DrainPolicy: "spill"}, 1000, 10000)
+	require.ErrorContains(t, ro.Init(), "requires a 'buffer_directory' setting")
+
+	ro = NewRunningOutput(&mockOutput{}, &OutputConfig{DrainPolicy: "spill", BufferDirectory: t.TempDir()}, 1000, 10000)
+	require.ErrorContains(t, ro.Init(), "requires a plugin ID")
+}
+
+func TestRunningOutputSpillRestore(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:          Filter{},
+		ID:              "spill",
+		DrainPolicy:     "spill",
+		BufferDirectory: t.TempDir(),
+	}
+
+	m := &mockOutput{batchAcceptSize: -1}
+	ro := NewRunningOutput(m, conf, 1000, 10000)
+	require.NoError(t, ro.Init())
+
+	for _, x := range first5 {
+		ro.AddMetric(x)
+	}
+	tm, _ := metric.WithTracking(testutil.TestMetric(101, "tracking"), func(telegraf.DeliveryInfo) {})
+	ro.AddMetric(tm)
+	require.Error(t, ro.Write())
+
+	n, err := ro.Spill()
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+	require.Zero(t, ro.BufferLength())
+	require.DirExists(t, filepath.Join(conf.BufferDirectory, "spill", conf.ID))
+
+	// Restore the metrics in a new instance
+	m = &mockOutput{}
+	ro = NewRunningOutput(m, conf, 1000, 10000)
+	require.NoError(t, ro.Init())
+	n, err = ro.RestoreSpilled()
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+	require.NoDirExists(t, filepath.Join(conf.BufferDirectory, "spill", conf.ID))
+
+	require.NoError(t, ro.Write())
+	testutil.RequireMetricsEqual(t, first5, m.Metrics())
+}
+
+func TestRunningOutputDiscard(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:      Filter{},
+		DrainPolicy: "discard",
+	}
+
+	ro := NewRunningOutput(&mockOutput{}, conf, 1000, 10000)
+	require.NoError(t, ro.Init())
+	for _, x := range first5 {
+		ro.AddMetric(x)
+	}
+	require.Equal(t, 5, ro.Discard())
+	require.Zero(t, ro.BufferLength())
+}
+
 // Benchmark adding metrics.
 func BenchmarkRunningOutputAddWrite(b *testing.B) {
 	conf := &OutputConfig{
@@ -940,11 +1099,11 @@ func (*mockOutput) SampleConfig() string {
 }
 
 func (m *mockOutput) Write(metrics []telegraf.Metric) error {
-	m.writes++
-
 	m.Lock()
 	defer m.Unlock()
 
+	m.writes++
+
 	// Simulate a failed write
 	if m.batchAcceptSize < 0 {
 		return errors.New("failed write")
@@ -998,3 +1157,53 @@ func (m *perfOutput) Write([]telegraf.Metric) error {
 	}
 	return nil
 }
+
+type concurrentOutput struct {
+	sync.Mutex
+
+	metrics   []telegraf.Metric
+	written   int
+	writes    int
+	active    int
+	maxActive int
+
+	delay   time.Duration
+	fail    bool
+	ordered bool
+}
+
+func (*concurrentOutput) Connect() error {
+	return nil
+}
+
+func (*concurrentOutput) Close() error {
+	return nil
+}
+
+func (*concurrentOutput) SampleConfig() string {
+	return ""
+}
+
+func (m *concurrentOutput) RequiresOrdering() bool {
+	return m.ordered
+}
+
+func (m *concurrentOutput) Write(metrics []telegraf.Metric) error {
+	m.Lock()
+	m.writes++
+	m.active++
+	m.maxActive = max(m.maxActive, m.active)
+	m.Unlock()
+
+	time.Sleep(m.delay)
+
+	m.Lock()
+	defer m.Unlock()
+	m.active--
+	if m.fail {
+		return errors.New("failed write")
+	}
+	m.metrics = append(m.metrics, metrics...)
+	m.written += len(metrics)
+	return nil
+}
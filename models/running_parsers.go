@@ -1,6 +1,7 @@
 package models
 
 import (
+	"io"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -93,6 +94,30 @@ func (r *RunningParser) ParseLine(line string) (telegraf.Metric, error) {
 	return m, err
 }
 
+// ParseStream parses the data incrementally if the parser supports streaming
+// and falls back to parsing all data at once otherwise.
+func (r *RunningParser) ParseStream(reader io.Reader, fn func(telegraf.Metric) error) error {
+	sp, ok := r.Parser.(telegraf.StreamingParser)
+	if !ok {
+		return telegraf.ParseAll(r, reader, fn)
+	}
+
+	// Do not account the time spent in the callback, e.g. when waiting for
+	// the accumulator, as parse time
+	start := time.Now()
+	var waiting time.Duration
+	err := sp.ParseStream(reader, func(m telegraf.Metric) error {
+		r.MetricsParsed.Incr(1)
+		t := time.Now()
+		err := fn(m)
+		waiting += time.Since(t)
+		return err
+	})
+	r.ParseTime.Incr((time.Since(start) - waiting).Nanoseconds())
+
+	return err
+}
+
 func (r *RunningParser) SetDefaultTags(tags map[string]string) {
 	r.Parser.SetDefaultTags(tags)
 }
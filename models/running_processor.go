@@ -47,6 +47,7 @@ func NewRunningProcessor(processor telegraf.StreamingProcessor, config *Processo
 		logger.Error(err)
 	}
 	SetLoggerOnPlugin(processor, logger)
+	SetAliasOnPlugin(processor, config.Alias)
 
 	return &RunningProcessor{
 		Processor: processor,
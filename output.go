@@ -27,3 +27,14 @@ type AggregatingOutput interface {
 	// Reset signals that the aggregator period is completed.
 	Reset()
 }
+
+// OrderedOutput is an optional interface for outputs that require the
+// metrics to be written strictly in order. For those outputs only one batch
+// will be written at a time regardless of the 'max_in_flight' setting.
+type OrderedOutput interface {
+	Output
+
+	// RequiresOrdering returns true if batches must not be written
+	// concurrently.
+	RequiresOrdering() bool
+}
@@ -1,5 +1,7 @@
 package telegraf
 
+import "io"
+
 // Parser is an interface defining functions that a parser plugin must satisfy.
 type Parser interface {
 	// Parse takes a byte buffer separated by newlines
@@ -22,6 +24,40 @@ type Parser interface {
 	SetDefaultTags(tags map[string]string)
 }
 
+// StreamingParser is an optional interface for parsers able to parse data
+// incrementally from a reader instead of requiring the whole payload in
+// memory.
+type StreamingParser interface {
+	// ParseStream reads the data from the given reader and calls the given
+	// function for each parsed metric. Parsing stops when the function
+	// returns an error which is then returned. Metrics parsed before an error
+	// occurred might have been passed to the function already.
+	//
+	// Must be thread-safe.
+	ParseStream(r io.Reader, fn func(Metric) error) error
+}
+
+// ParseAll reads all data from the reader, parses it at once and passes the
+// resulting metrics to the given function. This allows to implement the
+// StreamingParser interface for parsers or parser settings not supporting
+// incremental parsing.
+func ParseAll(parser Parser, r io.Reader, fn func(Metric) error) error {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	metrics, err := parser.Parse(buf)
+	if err != nil {
+		return err
+	}
+	for _, m := range metrics {
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ParserFunc is a function to create a new instance of a parser
 type ParserFunc func() (Parser, error)
 
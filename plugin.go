@@ -37,6 +37,15 @@ type PluginWithID interface {
 	ID() string
 }
 
+// PluginWithAlias allows a plugin to receive the user specified alias of the
+// plugin instance, e.g. to distinguish internal statistics of multiple
+// instances of the same plugin.
+type PluginWithAlias interface {
+	// SetAlias is called with the configured alias, which might be empty,
+	// before the plugin's Init() function if there is any.
+	SetAlias(alias string)
+}
+
 // StatefulPlugin contains the functions that plugins must implement to
 // persist an internal state across Telegraf runs.
 // Note that plugins may define a persister that is not part of the
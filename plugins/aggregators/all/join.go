@@ -0,0 +1,5 @@
+//go:build !custom || aggregators || aggregators.join
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/aggregators/join" // register plugin
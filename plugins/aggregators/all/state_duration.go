@@ -0,0 +1,5 @@
+//go:build !custom || aggregators || aggregators.state_duration
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/aggregators/state_duration" // register plugin
@@ -0,0 +1,167 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package distinct
+
+import (
+	_ "embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/axiomhq/hyperloglog"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+const sketchSuffix = "_sketch"
+
+type Distinct struct {
+	Fields        []string        `toml:"fields"`
+	Precision     uint8           `toml:"precision"`
+	EmitSketch    bool            `toml:"emit_sketch"`
+	MergeSketches bool            `toml:"merge_sketches"`
+	Log           telegraf.Logger `toml:"-"`
+
+	fieldFilter filter.Filter
+	cache       map[uint64]*aggregate
+}
+
+type aggregate struct {
+	name     string
+	tags     map[string]string
+	sketches map[string]*hyperloglog.Sketch
+}
+
+func (*Distinct) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *Distinct) Init() error {
+	if len(d.Fields) == 0 {
+		return errors.New("no fields configured")
+	}
+	f, err := filter.Compile(d.Fields)
+	if err != nil {
+		return fmt.Errorf("creating field filter failed: %w", err)
+	}
+	d.fieldFilter = f
+
+	// Check the precision by creating a sketch
+	if _, err := hyperloglog.NewSketch(d.Precision, true); err != nil {
+		return fmt.Errorf("invalid precision: %w", err)
+	}
+
+	d.Reset()
+
+	return nil
+}
+
+func (d *Distinct) Add(in telegraf.Metric) {
+	id := in.HashID()
+	agg, found := d.cache[id]
+	if !found {
+		agg = &aggregate{
+			name:     in.Name(),
+			tags:     in.Tags(),
+			sketches: make(map[string]*hyperloglog.Sketch),
+		}
+		d.cache[id] = agg
+	}
+
+	for _, field := range in.FieldList() {
+		// Merge sketches of other instances into the sketch of the original
+		// field
+		if d.MergeSketches && strings.HasSuffix(field.Key, sketchSuffix) {
+			key := strings.TrimSuffix(field.Key, sketchSuffix)
+			if !d.fieldFilter.Match(key) {
+				continue
+			}
+			other, err := decodeSketch(field.Value)
+			if err != nil {
+				d.Log.Errorf("Decoding sketch %q of metric %q failed: %v", field.Key, in.Name(), err)
+				continue
+			}
+			if err := d.sketch(agg, key).Merge(other); err != nil {
+				d.Log.Errorf("Merging sketch %q of metric %q failed: %v", field.Key, in.Name(), err)
+			}
+			continue
+		}
+
+		if !d.fieldFilter.Match(field.Key) {
+			continue
+		}
+		value, err := internal.ToString(field.Value)
+		if err != nil {
+			d.Log.Debugf("Converting field %q of metric %q failed: %v", field.Key, in.Name(), err)
+			continue
+		}
+		d.sketch(agg, field.Key).Insert([]byte(value))
+	}
+}
+
+func (d *Distinct) Push(acc telegraf.Accumulator) {
+	for _, agg := range d.cache {
+		if len(agg.sketches) == 0 {
+			continue
+		}
+
+		fields := make(map[string]interface{}, len(agg.sketches))
+		for key, sketch := range agg.sketches {
+			fields[key+"_distinct"] = int64(sketch.Estimate())
+			if d.EmitSketch {
+				buf, err := sketch.MarshalBinary()
+				if err != nil {
+					d.Log.Errorf("Encoding sketch %q of metric %q failed: %v", key, agg.name, err)
+					continue
+				}
+				fields[key+sketchSuffix] = base64.StdEncoding.EncodeToString(buf)
+			}
+		}
+		acc.AddFields(agg.name, fields, agg.tags)
+	}
+}
+
+func (d *Distinct) Reset() {
+	d.cache = make(map[uint64]*aggregate)
+}
+
+func (d *Distinct) sketch(agg *aggregate, key string) *hyperloglog.Sketch {
+	sketch, found := agg.sketches[key]
+	if !found {
+		// The precision was checked on initialization
+		sketch, _ = hyperloglog.NewSketch(d.Precision, true)
+		agg.sketches[key] = sketch
+	}
+	return sketch
+}
+
+func decodeSketch(value interface{}) (*hyperloglog.Sketch, error) {
+	encoded, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid type %T", value)
+	}
+	buf, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var sketch hyperloglog.Sketch
+	if err := sketch.UnmarshalBinary(buf); err != nil {
+		return nil, err
+	}
+	return &sketch, nil
+}
+
+func init() {
+	aggregators.Add("distinct", func() telegraf.Aggregator {
+		return &Distinct{
+			Precision: 14,
+		}
+	})
+}
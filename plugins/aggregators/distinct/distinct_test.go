@@ -0,0 +1,181 @@
+package distinct
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestInitFail(t *testing.T) {
+	plugin := &Distinct{Precision: 14}
+	require.ErrorContains(t, plugin.Init(), "no fields configured")
+
+	plugin = &Distinct{Fields: []string{"user"}, Precision: 3}
+	require.ErrorContains(t, plugin.Init(), "invalid precision")
+
+	plugin = &Distinct{Fields: []string{"user"}, Precision: 19}
+	require.ErrorContains(t, plugin.Init(), "invalid precision")
+}
+
+func TestEstimate(t *testing.T) {
+	plugin := &Distinct{
+		Fields:    []string{"user"},
+		Precision: 14,
+		Log:       testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	// Add each value twice to check duplicates are not counted
+	for range 2 {
+		for i := range 10000 {
+			plugin.Add(metric.New(
+				"requests",
+				map[string]string{"host": "a"},
+				map[string]interface{}{"user": "user" + strconv.Itoa(i), "bytes": int64(i)},
+				time.Unix(0, 0),
+			))
+		}
+	}
+
+	var acc testutil.Accumulator
+	plugin.Push(&acc)
+	require.Len(t, acc.Metrics, 1)
+
+	m := acc.GetTelegrafMetrics()[0]
+	require.Equal(t, "requests", m.Name())
+	require.Equal(t, map[string]string{"host": "a"}, m.Tags())
+	require.Len(t, m.FieldList(), 1)
+	estimate, found := m.GetField("user_distinct")
+	require.True(t, found)
+	require.InDelta(t, 10000, estimate, 200)
+}
+
+func TestSeries(t *testing.T) {
+	plugin := &Distinct{
+		Fields:    []string{"user", "status"},
+		Precision: 14,
+		Log:       testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	input := []telegraf.Metric{
+		metric.New("requests", map[string]string{"host": "a"}, map[string]interface{}{"user": "alice", "status": int64(200)}, time.Unix(0, 0)),
+		metric.New("requests", map[string]string{"host": "a"}, map[string]interface{}{"user": "bob", "status": int64(200)}, time.Unix(1, 0)),
+		metric.New("requests", map[string]string{"host": "a"}, map[string]interface{}{"user": "alice", "status": int64(404)}, time.Unix(2, 0)),
+		metric.New("requests", map[string]string{"host": "b"}, map[string]interface{}{"user": "alice"}, time.Unix(3, 0)),
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 42.0}, time.Unix(4, 0)),
+	}
+	for _, m := range input {
+		plugin.Add(m)
+	}
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"requests",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"user_distinct": int64(2), "status_distinct": int64(2)},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"requests",
+			map[string]string{"host": "b"},
+			map[string]interface{}{"user_distinct": int64(1)},
+			time.Unix(0, 0),
+		),
+	}
+
+	var acc testutil.Accumulator
+	plugin.Push(&acc)
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime(), testutil.SortMetrics())
+
+	// Nothing should be emitted after reset
+	plugin.Reset()
+	acc.ClearMetrics()
+	plugin.Push(&acc)
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
+func TestMergeSketches(t *testing.T) {
+	// Two edge instances seeing overlapping sets of users
+	var edges []telegraf.Metric
+	for n, r := range [][2]int{{0, 6000}, {4000, 10000}} {
+		edge := &Distinct{
+			Fields:     []string{"user"},
+			Precision:  14,
+			EmitSketch: true,
+			Log:        testutil.Logger{},
+		}
+		require.NoError(t, edge.Init())
+		for i := r[0]; i < r[1]; i++ {
+			edge.Add(metric.New(
+				"requests",
+				map[string]string{"region": "eu"},
+				map[string]interface{}{"user": i},
+				time.Unix(0, 0),
+			))
+		}
+
+		var acc testutil.Accumulator
+		edge.Push(&acc)
+		require.Len(t, acc.Metrics, 1, "edge %d", n)
+		m := acc.GetTelegrafMetrics()[0]
+		estimate, found := m.GetField("user_distinct")
+		require.True(t, found)
+		require.InDelta(t, 6000, estimate, 120)
+		require.True(t, m.HasField("user_sketch"))
+		edges = append(edges, m)
+	}
+
+	// Merge the results at a central instance
+	central := &Distinct{
+		Fields:        []string{"user"},
+		Precision:     14,
+		MergeSketches: true,
+		Log:           testutil.Logger{},
+	}
+	require.NoError(t, central.Init())
+	for _, m := range edges {
+		central.Add(m)
+	}
+
+	var acc testutil.Accumulator
+	central.Push(&acc)
+	require.Len(t, acc.Metrics, 1)
+	m := acc.GetTelegrafMetrics()[0]
+	require.Equal(t, map[string]string{"region": "eu"}, m.Tags())
+	require.Len(t, m.FieldList(), 1)
+	estimate, found := m.GetField("user_distinct")
+	require.True(t, found)
+	require.InDelta(t, 10000, estimate, 200)
+}
+
+func TestMergeInvalidSketch(t *testing.T) {
+	plugin := &Distinct{
+		Fields:        []string{"user"},
+		Precision:     14,
+		MergeSketches: true,
+		Log:           testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	plugin.Add(metric.New(
+		"requests",
+		map[string]string{},
+		map[string]interface{}{"user": "alice", "user_sketch": "not a sketch"},
+		time.Unix(0, 0),
+	))
+
+	expected := []telegraf.Metric{
+		metric.New("requests", map[string]string{}, map[string]interface{}{"user_distinct": int64(1)}, time.Unix(0, 0)),
+	}
+
+	var acc testutil.Accumulator
+	plugin.Push(&acc)
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
@@ -0,0 +1,315 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package join
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal/choice"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+	common_cel "github.com/influxdata/telegraf/plugins/common/cel"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+type Join struct {
+	Sources      []*source        `toml:"source"`
+	Tags         []string         `toml:"tags"`
+	TimeBucket   config.Duration  `toml:"time_bucket"`
+	JoinType     string           `toml:"join_type"`
+	Name         string           `toml:"name"`
+	PrefixFields bool             `toml:"prefix_fields"`
+	Unmatched    string           `toml:"unmatched"`
+	Fields       []*computedField `toml:"field"`
+	DropOriginal bool             `toml:"drop_original"`
+	Log          telegraf.Logger  `toml:"-"`
+
+	seed      maphash.Seed
+	groups    map[uint64]*group
+	unmatched []telegraf.Metric
+}
+
+// source defines a side of the join
+type source struct {
+	Measurement string            `toml:"measurement"`
+	TagFilter   map[string]string `toml:"tags"`
+	Prefix      string            `toml:"prefix"`
+
+	tagFilters map[string]filter.Filter
+}
+
+// computedField is a field computed on the joined metric
+type computedField struct {
+	Name       string `toml:"name"`
+	Expression string `toml:"expression"`
+
+	program *common_cel.Program
+}
+
+// group collects the metrics of all sources with the same join key
+type group struct {
+	tags      map[string]string
+	timestamp time.Time
+	fields    []map[string]interface{}
+	metrics   []telegraf.Metric
+}
+
+func (*Join) SampleConfig() string {
+	return sampleConfig
+}
+
+func (j *Join) Init() error {
+	if len(j.Sources) < 2 {
+		return errors.New("at least two sources are required")
+	}
+	for i, s := range j.Sources {
+		if err := s.init(j.PrefixFields); err != nil {
+			return fmt.Errorf("initialization of source %d failed: %w", i+1, err)
+		}
+	}
+
+	if err := choice.Check(j.JoinType, []string{"inner", "left", "outer"}); err != nil {
+		return fmt.Errorf("invalid 'join_type' setting: %w", err)
+	}
+	if err := choice.Check(j.Unmatched, []string{"drop", "forward"}); err != nil {
+		return fmt.Errorf("invalid 'unmatched' setting: %w", err)
+	}
+	// Without dropping the original metrics, those metrics are passed on
+	// anyway and forwarding would duplicate them
+	if j.Unmatched == "forward" && !j.DropOriginal {
+		return errors.New("'unmatched = \"forward\"' requires 'drop_original = true'")
+	}
+	if j.TimeBucket < 0 {
+		return errors.New("'time_bucket' must not be negative")
+	}
+	if j.Name == "" {
+		j.Name = j.Sources[0].Measurement
+	}
+
+	if len(j.Fields) > 0 {
+		env, err := common_cel.NewEnvironment()
+		if err != nil {
+			return err
+		}
+		for i, f := range j.Fields {
+			if err := f.init(env); err != nil {
+				return fmt.Errorf("initialization of field %d failed: %w", i+1, err)
+			}
+		}
+	}
+
+	j.seed = maphash.MakeSeed()
+	j.groups = make(map[uint64]*group)
+
+	return nil
+}
+
+func (j *Join) Add(m telegraf.Metric) {
+	idx := -1
+	for i, s := range j.Sources {
+		if s.matches(m) {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return
+	}
+
+	// Compute the join key from the time bucket and the join tags, metrics
+	// without all join tags cannot be joined
+	var h maphash.Hash
+	h.SetSeed(j.seed)
+	timestamp := m.Time()
+	if j.TimeBucket > 0 {
+		timestamp = timestamp.Truncate(time.Duration(j.TimeBucket))
+		h.WriteString(strconv.FormatInt(timestamp.UnixNano(), 10))
+	}
+	tags := make(map[string]string, len(j.Tags))
+	for _, key := range j.Tags {
+		value, found := m.GetTag(key)
+		if !found {
+			j.unmatched = append(j.unmatched, m)
+			return
+		}
+		h.WriteByte(0)
+		h.WriteString(key)
+		h.WriteByte(0)
+		h.WriteString(value)
+		tags[key] = value
+	}
+
+	id := h.Sum64()
+	g, found := j.groups[id]
+	if !found {
+		g = &group{
+			tags:      tags,
+			timestamp: timestamp,
+			fields:    make([]map[string]interface{}, len(j.Sources)),
+		}
+		j.groups[id] = g
+	}
+	if g.fields[idx] == nil {
+		g.fields[idx] = make(map[string]interface{}, len(m.FieldList()))
+	}
+	for _, field := range m.FieldList() {
+		g.fields[idx][j.Sources[idx].Prefix+field.Key] = field.Value
+	}
+	// Without time buckets use the latest timestamp of the joined metrics
+	if j.TimeBucket == 0 && m.Time().After(g.timestamp) {
+		g.timestamp = m.Time()
+	}
+	g.metrics = append(g.metrics, m)
+}
+
+func (j *Join) Push(acc telegraf.Accumulator) {
+	// Preserve the timestamp of the original metrics
+	acc.SetPrecision(time.Nanosecond)
+
+	// Output the groups in a deterministic order
+	groups := make([]*group, 0, len(j.groups))
+	for _, g := range j.groups {
+		groups = append(groups, g)
+	}
+	sort.SliceStable(groups, func(i, k int) bool { return groups[i].timestamp.Before(groups[k].timestamp) })
+
+	for _, g := range groups {
+		if !j.complete(g) {
+			j.forward(acc, g.metrics...)
+			continue
+		}
+
+		fields := make(map[string]interface{})
+		for _, f := range g.fields {
+			for k, v := range f {
+				fields[k] = v
+			}
+		}
+
+		if len(j.Fields) > 0 {
+			vars := common_cel.Variables(j.Name, g.tags, fields, g.timestamp)
+			for _, f := range j.Fields {
+				value, err := f.evaluate(vars)
+				if err != nil {
+					j.Log.Debugf("Computing field %q failed: %v", f.Name, err)
+					continue
+				}
+				// Make the result available to the following fields
+				fields[f.Name] = value
+			}
+		}
+
+		acc.AddFields(j.Name, fields, g.tags, g.timestamp)
+	}
+	j.forward(acc, j.unmatched...)
+}
+
+func (j *Join) Reset() {
+	j.groups = make(map[uint64]*group)
+	j.unmatched = nil
+}
+
+// complete checks if the group contains the sources required by the join type
+func (j *Join) complete(g *group) bool {
+	switch j.JoinType {
+	case "inner":
+		for _, f := range g.fields {
+			if f == nil {
+				return false
+			}
+		}
+		return true
+	case "left":
+		return g.fields[0] != nil
+	}
+	return true
+}
+
+func (j *Join) forward(acc telegraf.Accumulator, metrics ...telegraf.Metric) {
+	if j.Unmatched != "forward" {
+		return
+	}
+	for _, m := range metrics {
+		acc.AddMetric(m)
+	}
+}
+
+func (s *source) init(prefix bool) error {
+	if s.Measurement == "" {
+		return errors.New("'measurement' must be set")
+	}
+	if prefix && s.Prefix == "" {
+		s.Prefix = s.Measurement + "_"
+	}
+	if !prefix {
+		s.Prefix = ""
+	}
+
+	s.tagFilters = make(map[string]filter.Filter, len(s.TagFilter))
+	for key, pattern := range s.TagFilter {
+		f, err := filter.Compile([]string{pattern})
+		if err != nil {
+			return fmt.Errorf("creating filter for tag %q failed: %w", key, err)
+		}
+		s.tagFilters[key] = f
+	}
+
+	return nil
+}
+
+func (s *source) matches(m telegraf.Metric) bool {
+	if m.Name() != s.Measurement {
+		return false
+	}
+	for key, f := range s.tagFilters {
+		value, found := m.GetTag(key)
+		if !found || !f.Match(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *computedField) init(env *common_cel.Environment) error {
+	if f.Name == "" {
+		return errors.New("'name' must be set")
+	}
+	if f.Expression == "" {
+		return errors.New("'expression' must be set")
+	}
+
+	program, err := env.Compile(f.Expression)
+	if err != nil {
+		return fmt.Errorf("compiling expression failed: %w", err)
+	}
+	f.program = program
+
+	return nil
+}
+
+func (f *computedField) evaluate(vars map[string]interface{}) (interface{}, error) {
+	value, err := f.program.Eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	return common_cel.FieldValue(value)
+}
+
+func init() {
+	aggregators.Add("join", func() telegraf.Aggregator {
+		return &Join{
+			JoinType:     "inner",
+			PrefixFields: true,
+			Unmatched:    "drop",
+		}
+	})
+}
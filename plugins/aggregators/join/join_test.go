@@ -0,0 +1,298 @@
+package join
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   *Join
+		expected string
+	}{
+		{
+			name:     "single source",
+			plugin:   &Join{Sources: []*source{{Measurement: "disk"}}},
+			expected: "at least two sources are required",
+		},
+		{
+			name:     "missing measurement",
+			plugin:   &Join{Sources: []*source{{Measurement: "disk"}, {}}},
+			expected: "initialization of source 2 failed: 'measurement' must be set",
+		},
+		{
+			name: "invalid join type",
+			plugin: &Join{
+				Sources:  []*source{{Measurement: "disk"}, {Measurement: "diskio"}},
+				JoinType: "cross",
+			},
+			expected: "invalid 'join_type' setting",
+		},
+		{
+			name: "invalid expression",
+			plugin: &Join{
+				Sources:   []*source{{Measurement: "disk"}, {Measurement: "diskio"}},
+				JoinType:  "inner",
+				Unmatched: "drop",
+				Fields:    []*computedField{{Name: "ratio", Expression: "fields.a /"}},
+			},
+			expected: "initialization of field 1 failed: compiling expression failed",
+		},
+		{
+			name: "forward without dropping originals",
+			plugin: &Join{
+				Sources:   []*source{{Measurement: "disk"}, {Measurement: "diskio"}},
+				JoinType:  "inner",
+				Unmatched: "forward",
+			},
+			expected: "requires 'drop_original = true'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestJoinTypes(t *testing.T) {
+	input := []telegraf.Metric{
+		metric.New(
+			"disk",
+			map[string]string{"host": "a", "device": "sda1", "fstype": "ext4"},
+			map[string]interface{}{"used": uint64(100), "free": uint64(300)},
+			time.Unix(10, 0),
+		),
+		metric.New(
+			"diskio",
+			map[string]string{"host": "a", "device": "sda1"},
+			map[string]interface{}{"reads": uint64(42)},
+			time.Unix(12, 0),
+		),
+		// Only disk
+		metric.New(
+			"disk",
+			map[string]string{"host": "a", "device": "sdb1"},
+			map[string]interface{}{"used": uint64(1), "free": uint64(3)},
+			time.Unix(10, 0),
+		),
+		// Only diskio
+		metric.New(
+			"diskio",
+			map[string]string{"host": "a", "device": "sdc"},
+			map[string]interface{}{"reads": uint64(7)},
+			time.Unix(10, 0),
+		),
+		// Missing join tag
+		metric.New(
+			"diskio",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"reads": uint64(1)},
+			time.Unix(10, 0),
+		),
+		// Not a source
+		metric.New(
+			"cpu",
+			map[string]string{"host": "a", "device": "sda1"},
+			map[string]interface{}{"usage": 1.0},
+			time.Unix(10, 0),
+		),
+	}
+
+	joined := metric.New(
+		"disk",
+		map[string]string{"host": "a", "device": "sda1"},
+		map[string]interface{}{"disk_used": uint64(100), "disk_free": uint64(300), "io_reads": uint64(42)},
+		time.Unix(12, 0),
+	)
+	diskOnly := metric.New(
+		"disk",
+		map[string]string{"host": "a", "device": "sdb1"},
+		map[string]interface{}{"disk_used": uint64(1), "disk_free": uint64(3)},
+		time.Unix(10, 0),
+	)
+	diskioOnly := metric.New(
+		"disk",
+		map[string]string{"host": "a", "device": "sdc"},
+		map[string]interface{}{"io_reads": uint64(7)},
+		time.Unix(10, 0),
+	)
+
+	tests := []struct {
+		name      string
+		joinType  string
+		unmatched string
+		expected  []telegraf.Metric
+	}{
+		{
+			name:      "inner",
+			joinType:  "inner",
+			unmatched: "drop",
+			expected:  []telegraf.Metric{joined},
+		},
+		{
+			name:      "left",
+			joinType:  "left",
+			unmatched: "drop",
+			expected:  []telegraf.Metric{joined, diskOnly},
+		},
+		{
+			name:      "outer",
+			joinType:  "outer",
+			unmatched: "drop",
+			expected:  []telegraf.Metric{joined, diskOnly, diskioOnly},
+		},
+		{
+			name:      "inner forward",
+			joinType:  "inner",
+			unmatched: "forward",
+			expected:  []telegraf.Metric{joined, input[2], input[3], input[4]},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Join{
+				Sources: []*source{
+					{Measurement: "disk"},
+					{Measurement: "diskio", Prefix: "io_"},
+				},
+				Tags:         []string{"host", "device"},
+				JoinType:     tt.joinType,
+				PrefixFields: true,
+				Unmatched:    tt.unmatched,
+				DropOriginal: true,
+				Log:          testutil.Logger{},
+			}
+			require.NoError(t, plugin.Init())
+
+			for _, m := range input {
+				plugin.Add(m)
+			}
+
+			var acc testutil.Accumulator
+			plugin.Push(&acc)
+			testutil.RequireMetricsEqual(t, tt.expected, acc.GetTelegrafMetrics(), testutil.SortMetrics())
+
+			// Nothing is left after resetting
+			plugin.Reset()
+			acc.ClearMetrics()
+			plugin.Push(&acc)
+			require.Empty(t, acc.GetTelegrafMetrics())
+		})
+	}
+}
+
+func TestDropOriginalSetting(t *testing.T) {
+	cfg := config.NewConfig()
+	require.NoError(t, cfg.LoadConfigData([]byte(`
+[[aggregators.join]]
+  drop_original = true
+  unmatched = "forward"
+
+  [[aggregators.join.source]]
+    measurement = "disk"
+  [[aggregators.join.source]]
+    measurement = "diskio"
+`), config.EmptySourcePath))
+	require.Len(t, cfg.Aggregators, 1)
+	require.True(t, cfg.Aggregators[0].Config.DropOriginal)
+
+	plugin, ok := cfg.Aggregators[0].Aggregator.(*Join)
+	require.True(t, ok)
+	require.True(t, plugin.DropOriginal)
+	require.NoError(t, plugin.Init())
+}
+
+func TestTimeBucketsAndTagFilter(t *testing.T) {
+	plugin := &Join{
+		Sources: []*source{
+			{Measurement: "mem", TagFilter: map[string]string{"source": "procfs"}, Prefix: "proc_"},
+			{Measurement: "mem", TagFilter: map[string]string{"source": "cgroup*"}, Prefix: "cg_"},
+		},
+		Tags:         []string{"host"},
+		TimeBucket:   config.Duration(10 * time.Second),
+		JoinType:     "inner",
+		Name:         "memory",
+		PrefixFields: true,
+		Unmatched:    "drop",
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	input := []telegraf.Metric{
+		metric.New("mem", map[string]string{"host": "a", "source": "procfs"}, map[string]interface{}{"used": int64(10)}, time.Unix(1, 0)),
+		metric.New("mem", map[string]string{"host": "a", "source": "cgroup_v2"}, map[string]interface{}{"used": int64(8)}, time.Unix(9, 0)),
+		metric.New("mem", map[string]string{"host": "a", "source": "procfs"}, map[string]interface{}{"used": int64(11)}, time.Unix(11, 0)),
+		metric.New("mem", map[string]string{"host": "a", "source": "cgroup_v2"}, map[string]interface{}{"used": int64(9)}, time.Unix(15, 0)),
+		// Different bucket, no partner
+		metric.New("mem", map[string]string{"host": "a", "source": "procfs"}, map[string]interface{}{"used": int64(12)}, time.Unix(21, 0)),
+		// Not matching any source
+		metric.New("mem", map[string]string{"host": "a", "source": "other"}, map[string]interface{}{"used": int64(1)}, time.Unix(21, 0)),
+	}
+	for _, m := range input {
+		plugin.Add(m)
+	}
+
+	expected := []telegraf.Metric{
+		metric.New("memory", map[string]string{"host": "a"}, map[string]interface{}{"proc_used": int64(10), "cg_used": int64(8)}, time.Unix(0, 0)),
+		metric.New("memory", map[string]string{"host": "a"}, map[string]interface{}{"proc_used": int64(11), "cg_used": int64(9)}, time.Unix(10, 0)),
+	}
+
+	var acc testutil.Accumulator
+	plugin.Push(&acc)
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestComputedFields(t *testing.T) {
+	plugin := &Join{
+		Sources: []*source{
+			{Measurement: "disk"},
+			{Measurement: "diskio"},
+		},
+		Tags:         []string{"device"},
+		JoinType:     "inner",
+		PrefixFields: false,
+		Unmatched:    "drop",
+		Fields: []*computedField{
+			{Name: "total", Expression: "fields.used + fields.free"},
+			{Name: "used_percent", Expression: "double(fields.used) / double(fields.total) * 100.0"},
+			{Name: "label", Expression: "tags.device + ':' + string(fields.reads)"},
+			{Name: "invalid", Expression: "fields.unknown * 2"},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	plugin.Add(metric.New("disk", map[string]string{"device": "sda"}, map[string]interface{}{"used": int64(25), "free": int64(75)}, time.Unix(0, 0)))
+	plugin.Add(metric.New("diskio", map[string]string{"device": "sda"}, map[string]interface{}{"reads": int64(3)}, time.Unix(0, 0)))
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"disk",
+			map[string]string{"device": "sda"},
+			map[string]interface{}{
+				"used":         int64(25),
+				"free":         int64(75),
+				"reads":        int64(3),
+				"total":        int64(100),
+				"used_percent": 25.0,
+				"label":        "sda:3",
+			},
+			time.Unix(0, 0),
+		),
+	}
+
+	var acc testutil.Accumulator
+	plugin.Push(&acc)
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
@@ -0,0 +1,256 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package state_duration
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+type StateDuration struct {
+	Fields        []string        `toml:"fields"`
+	SeriesTimeout config.Duration `toml:"series_timeout"`
+	Log           telegraf.Logger `toml:"-"`
+
+	fieldFilter filter.Filter
+	cache       map[uint64]*series
+	latest      time.Time
+}
+
+// series holds the state of all tracked fields of a series
+type series struct {
+	Name   string                 `json:"name"`
+	Tags   map[string]string      `json:"tags"`
+	Fields map[string]*fieldState `json:"fields"`
+}
+
+// fieldState tracks the state of a field and the time spent in each state
+// within the current period. All times are metric times, the state is
+// entered at 'Since' and the time up to 'Accounted' is already reported.
+type fieldState struct {
+	State     string    `json:"state"`
+	Type      string    `json:"type"`
+	Since     time.Time `json:"since"`
+	Accounted time.Time `json:"accounted"`
+	Seen      time.Time `json:"seen"`
+
+	durations   map[string]time.Duration
+	transitions int64
+}
+
+func (*StateDuration) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *StateDuration) Init() error {
+	if len(s.Fields) == 0 {
+		return errors.New("no fields configured")
+	}
+	f, err := filter.Compile(s.Fields)
+	if err != nil {
+		return fmt.Errorf("creating field filter failed: %w", err)
+	}
+	s.fieldFilter = f
+
+	if s.SeriesTimeout < 0 {
+		return errors.New("'series_timeout' must not be negative")
+	}
+
+	s.cache = make(map[uint64]*series)
+
+	return nil
+}
+
+func (s *StateDuration) Add(in telegraf.Metric) {
+	id := in.HashID()
+	ts := in.Time()
+	if ts.After(s.latest) {
+		s.latest = ts
+	}
+
+	for _, field := range in.FieldList() {
+		if !s.fieldFilter.Match(field.Key) {
+			continue
+		}
+		state, typ, ok := stateOf(field.Value)
+		if !ok {
+			s.Log.Debugf("Ignoring field %q of metric %q with unsupported type %T", field.Key, in.Name(), field.Value)
+			continue
+		}
+
+		entry, found := s.cache[id]
+		if !found {
+			entry = &series{
+				Name:   in.Name(),
+				Tags:   in.Tags(),
+				Fields: make(map[string]*fieldState),
+			}
+			s.cache[id] = entry
+		}
+
+		fs, found := entry.Fields[field.Key]
+		if !found {
+			entry.Fields[field.Key] = &fieldState{
+				State:     state,
+				Type:      typ,
+				Since:     ts,
+				Seen:      ts,
+				durations: make(map[string]time.Duration),
+			}
+			continue
+		}
+
+		// Ignore samples older than the previous sample of the series
+		if ts.Before(fs.Since) {
+			continue
+		}
+		// Only account the time not yet reported in previous periods. The
+		// start of the state is unknown after restoring the state.
+		if start := fs.start(); !start.IsZero() && ts.After(start) {
+			fs.durations[fs.State] += ts.Sub(start)
+		}
+		fs.Since = ts
+		fs.Seen = ts
+		if state != fs.State {
+			fs.transitions++
+			fs.State = state
+			fs.Type = typ
+		}
+	}
+}
+
+func (s *StateDuration) Push(acc telegraf.Accumulator) {
+	// Use the latest metric time seen as the end of the period to not mix
+	// metric time and wall-clock time
+	end := s.latest
+
+	for id, entry := range s.cache {
+		fields := make(map[string]interface{})
+		for key, fs := range entry.Fields {
+			// Stop tracking fields not updated for a long time
+			if s.SeriesTimeout > 0 && end.Sub(fs.Seen) > time.Duration(s.SeriesTimeout) {
+				delete(entry.Fields, key)
+				continue
+			}
+
+			// Account the time up to the end of the period for the current
+			// state without moving the start of the state, so samples
+			// arriving late can still change the state
+			if start := fs.start(); !start.IsZero() && end.After(start) {
+				fs.durations[fs.State] += end.Sub(start)
+				fs.Accounted = end
+			}
+
+			for state, d := range fs.durations {
+				fields[key+"_"+state+"_seconds"] = d.Seconds()
+			}
+			fields[key+"_transitions"] = fs.transitions
+			fields[key+"_last_state"] = fs.value()
+		}
+		if len(entry.Fields) == 0 {
+			delete(s.cache, id)
+			continue
+		}
+
+		acc.AddFields(entry.Name, fields, entry.Tags, end)
+	}
+}
+
+func (s *StateDuration) Reset() {
+	for _, entry := range s.cache {
+		for _, fs := range entry.Fields {
+			fs.durations = make(map[string]time.Duration)
+			fs.transitions = 0
+		}
+	}
+}
+
+func (s *StateDuration) GetState() interface{} {
+	return s.cache
+}
+
+func (s *StateDuration) SetState(state interface{}) error {
+	restored, ok := state.(map[uint64]*series)
+	if !ok {
+		return fmt.Errorf("state has wrong type %T", state)
+	}
+
+	// The time while Telegraf was not running is not accounted to any state,
+	// so accounting restarts with the next sample of the series
+	for id, entry := range restored {
+		if entry == nil {
+			continue
+		}
+		for _, fs := range entry.Fields {
+			fs.Since = time.Time{}
+			fs.Accounted = time.Time{}
+			fs.durations = make(map[string]time.Duration)
+			if fs.Seen.After(s.latest) {
+				s.latest = fs.Seen
+			}
+		}
+		s.cache[id] = entry
+	}
+	return nil
+}
+
+// start returns the time from which on the current state is not yet
+// accounted or zero if unknown
+func (fs *fieldState) start() time.Time {
+	if fs.Since.IsZero() || fs.Accounted.Before(fs.Since) {
+		return fs.Since
+	}
+	return fs.Accounted
+}
+
+// value returns the state in its original type
+func (fs *fieldState) value() interface{} {
+	switch fs.Type {
+	case "int":
+		if v, err := strconv.ParseInt(fs.State, 10, 64); err == nil {
+			return v
+		}
+	case "uint":
+		if v, err := strconv.ParseUint(fs.State, 10, 64); err == nil {
+			return v
+		}
+	case "bool":
+		if v, err := strconv.ParseBool(fs.State); err == nil {
+			return v
+		}
+	}
+	return fs.State
+}
+
+// stateOf returns the string representation and the type of the state
+func stateOf(value interface{}) (string, string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, "string", true
+	case int64:
+		return strconv.FormatInt(v, 10), "int", true
+	case uint64:
+		return strconv.FormatUint(v, 10), "uint", true
+	case bool:
+		return strconv.FormatBool(v), "bool", true
+	}
+	return "", "", false
+}
+
+func init() {
+	aggregators.Add("state_duration", func() telegraf.Aggregator {
+		return &StateDuration{
+			SeriesTimeout: config.Duration(time.Hour),
+		}
+	})
+}
@@ -0,0 +1,309 @@
+package state_duration
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestInitFail(t *testing.T) {
+	plugin := &StateDuration{}
+	require.ErrorContains(t, plugin.Init(), "no fields configured")
+
+	plugin = &StateDuration{Fields: []string{"state"}, SeriesTimeout: config.Duration(-1)}
+	require.ErrorContains(t, plugin.Init(), "'series_timeout' must not be negative")
+}
+
+func TestPeriods(t *testing.T) {
+	plugin := &StateDuration{
+		Fields:        []string{"active_state", "code"},
+		SeriesTimeout: config.Duration(time.Hour),
+		Log:           testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	tags := map[string]string{"name": "telegraf.service"}
+	add := func(state string, code int64, sec int64) {
+		plugin.Add(metric.New(
+			"systemd_units",
+			tags,
+			map[string]interface{}{"active_state": state, "code": code, "load": 0.5},
+			time.Unix(sec, 0),
+		))
+	}
+
+	// First period: active for 10s, failed for 5s, active again for 15s
+	add("active", 0, 0)
+	add("active", 0, 5)
+	add("failed", 3, 10)
+	add("active", 0, 15)
+	add("active", 0, 30)
+
+	var acc testutil.Accumulator
+	plugin.Push(&acc)
+	plugin.Reset()
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"systemd_units",
+			tags,
+			map[string]interface{}{
+				"active_state_active_seconds": 25.0,
+				"active_state_failed_seconds": 5.0,
+				"active_state_transitions":    int64(2),
+				"active_state_last_state":     "active",
+				"code_0_seconds":              25.0,
+				"code_3_seconds":              5.0,
+				"code_transitions":            int64(2),
+				"code_last_state":             int64(0),
+			},
+			time.Unix(30, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+
+	// Second period: the state is carried over from the previous period
+	acc.ClearMetrics()
+	add("failed", 3, 40)
+	add("failed", 3, 60)
+	plugin.Push(&acc)
+	plugin.Reset()
+
+	expected = []telegraf.Metric{
+		metric.New(
+			"systemd_units",
+			tags,
+			map[string]interface{}{
+				"active_state_active_seconds": 10.0,
+				"active_state_failed_seconds": 20.0,
+				"active_state_transitions":    int64(1),
+				"active_state_last_state":     "failed",
+				"code_0_seconds":              10.0,
+				"code_3_seconds":              20.0,
+				"code_transitions":            int64(1),
+				"code_last_state":             int64(3),
+			},
+			time.Unix(60, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+
+	// Third period with samples of another series only: the time up to the
+	// latest metric time is accounted to the current state
+	acc.ClearMetrics()
+	plugin.Add(metric.New(
+		"systemd_units",
+		map[string]string{"name": "other.service"},
+		map[string]interface{}{"load": 0.5},
+		time.Unix(90, 0),
+	))
+	plugin.Push(&acc)
+	plugin.Reset()
+
+	expected = []telegraf.Metric{
+		metric.New(
+			"systemd_units",
+			tags,
+			map[string]interface{}{
+				"active_state_failed_seconds": 30.0,
+				"active_state_transitions":    int64(0),
+				"active_state_last_state":     "failed",
+				"code_3_seconds":              30.0,
+				"code_transitions":            int64(0),
+				"code_last_state":             int64(3),
+			},
+			time.Unix(90, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestTypesAndOutOfOrder(t *testing.T) {
+	plugin := &StateDuration{
+		Fields: []string{"*"},
+		Log:    testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	plugin.Add(metric.New("modbus", map[string]string{}, map[string]interface{}{"coil": true, "value": 1.5}, time.Unix(0, 0)))
+	plugin.Add(metric.New("modbus", map[string]string{}, map[string]interface{}{"coil": false}, time.Unix(4, 0)))
+	// Out-of-order sample is ignored
+	plugin.Add(metric.New("modbus", map[string]string{}, map[string]interface{}{"coil": true}, time.Unix(2, 0)))
+	plugin.Add(metric.New("modbus", map[string]string{}, map[string]interface{}{"coil": false}, time.Unix(10, 0)))
+
+	var acc testutil.Accumulator
+	plugin.Push(&acc)
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"modbus",
+			map[string]string{},
+			map[string]interface{}{
+				"coil_true_seconds":  4.0,
+				"coil_false_seconds": 6.0,
+				"coil_transitions":   int64(1),
+				"coil_last_state":    false,
+			},
+			time.Unix(10, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestLateTransition(t *testing.T) {
+	plugin := &StateDuration{
+		Fields: []string{"state"},
+		Log:    testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	add := func(name, state string, sec int64) {
+		plugin.Add(metric.New("process", map[string]string{"name": name}, map[string]interface{}{"state": state}, time.Unix(sec, 0)))
+	}
+
+	// First period ends at the latest metric time of all series
+	add("a", "running", 0)
+	add("b", "running", 30)
+
+	var acc testutil.Accumulator
+	plugin.Push(&acc)
+	plugin.Reset()
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"process",
+			map[string]string{"name": "a"},
+			map[string]interface{}{
+				"state_running_seconds": 30.0,
+				"state_transitions":     int64(0),
+				"state_last_state":      "running",
+			},
+			time.Unix(30, 0),
+		),
+		metric.New(
+			"process",
+			map[string]string{"name": "b"},
+			map[string]interface{}{
+				"state_transitions": int64(0),
+				"state_last_state":  "running",
+			},
+			time.Unix(30, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.SortMetrics())
+
+	// A transition timestamped before the end of the previous period arrives
+	// late and must not be lost. The time already reported for the previous
+	// state is not accounted again.
+	acc.ClearMetrics()
+	add("a", "stopped", 20)
+	add("a", "stopped", 40)
+	add("b", "running", 40)
+	plugin.Push(&acc)
+
+	expected = []telegraf.Metric{
+		metric.New(
+			"process",
+			map[string]string{"name": "a"},
+			map[string]interface{}{
+				"state_stopped_seconds": 10.0,
+				"state_transitions":     int64(1),
+				"state_last_state":      "stopped",
+			},
+			time.Unix(40, 0),
+		),
+		metric.New(
+			"process",
+			map[string]string{"name": "b"},
+			map[string]interface{}{
+				"state_running_seconds": 10.0,
+				"state_transitions":     int64(0),
+				"state_last_state":      "running",
+			},
+			time.Unix(40, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.SortMetrics())
+}
+
+func TestSeriesTimeout(t *testing.T) {
+	plugin := &StateDuration{
+		Fields:        []string{"result_code"},
+		SeriesTimeout: config.Duration(time.Minute),
+		Log:           testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	plugin.Add(metric.New("http_response", map[string]string{"server": "a"}, map[string]interface{}{"result_code": uint64(0)}, time.Unix(0, 0)))
+	plugin.Add(metric.New("http_response", map[string]string{"server": "b"}, map[string]interface{}{"result_code": uint64(1)}, time.Unix(50, 0)))
+	plugin.Add(metric.New("http_response", map[string]string{"server": "b"}, map[string]interface{}{"result_code": uint64(1)}, time.Unix(90, 0)))
+
+	var acc testutil.Accumulator
+	plugin.Push(&acc)
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"http_response",
+			map[string]string{"server": "b"},
+			map[string]interface{}{
+				"result_code_1_seconds":   40.0,
+				"result_code_transitions": int64(0),
+				"result_code_last_state":  uint64(1),
+			},
+			time.Unix(90, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+	require.Len(t, plugin.cache, 1)
+}
+
+func TestState(t *testing.T) {
+	plugin := &StateDuration{
+		Fields: []string{"state"},
+		Log:    testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	plugin.Add(metric.New("supervisor", map[string]string{"name": "app"}, map[string]interface{}{"state": int64(20)}, time.Unix(0, 0)))
+	plugin.Add(metric.New("supervisor", map[string]string{"name": "app"}, map[string]interface{}{"state": int64(10)}, time.Unix(10, 0)))
+
+	buf, err := json.Marshal(plugin.GetState())
+	require.NoError(t, err)
+
+	// Restart after some time, the downtime is not accounted
+	restarted := &StateDuration{
+		Fields: []string{"state"},
+		Log:    testutil.Logger{},
+	}
+	require.NoError(t, restarted.Init())
+	var state map[uint64]*series
+	require.NoError(t, json.Unmarshal(buf, &state))
+	require.NoError(t, restarted.SetState(state))
+
+	restarted.Add(metric.New("supervisor", map[string]string{"name": "app"}, map[string]interface{}{"state": int64(20)}, time.Unix(110, 0)))
+	restarted.Add(metric.New("supervisor", map[string]string{"name": "app"}, map[string]interface{}{"state": int64(20)}, time.Unix(120, 0)))
+
+	var acc testutil.Accumulator
+	restarted.Push(&acc)
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"supervisor",
+			map[string]string{"name": "app"},
+			map[string]interface{}{
+				"state_20_seconds":  10.0,
+				"state_transitions": int64(1),
+				"state_last_state":  int64(20),
+			},
+			time.Unix(120, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
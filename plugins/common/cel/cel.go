@@ -0,0 +1,128 @@
+package cel
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/decls"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/ext"
+)
+
+// Environment for compiling expressions operating on a metric. The metric is
+// available as the 'name', 'tags', 'fields' and 'time' variables.
+type Environment struct {
+	env *cel.Env
+}
+
+// Program is a compiled expression
+type Program struct {
+	program    cel.Program
+	outputType *cel.Type
+}
+
+// NewEnvironment declares the metric variables as well as the custom and
+// extension functions available in expressions
+func NewEnvironment() (*Environment, error) {
+	env, err := cel.NewEnv(
+		cel.VariableDecls(
+			decls.NewVariable("name", types.StringType),
+			decls.NewVariable("tags", types.NewMapType(types.StringType, types.StringType)),
+			decls.NewVariable("fields", types.NewMapType(types.StringType, types.DynType)),
+			decls.NewVariable("time", types.TimestampType),
+		),
+		cel.Function(
+			"now",
+			cel.Overload("now", nil, cel.TimestampType),
+			cel.SingletonFunctionBinding(func(_ ...ref.Val) ref.Val { return types.Timestamp{Time: time.Now()} }),
+		),
+		ext.Encoders(),
+		ext.Math(),
+		ext.Strings(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating environment failed: %w", err)
+	}
+	return &Environment{env: env}, nil
+}
+
+// Compile checks and compiles the given expression
+func (e *Environment) Compile(expression string) (*Program, error) {
+	ast, issues := e.env.Compile(expression)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+
+	program, err := e.env.Program(ast, cel.EvalOptions(cel.OptOptimize))
+	if err != nil {
+		return nil, err
+	}
+	return &Program{program: program, outputType: ast.OutputType()}, nil
+}
+
+// Variables returns the variables of a metric used to evaluate programs
+func Variables(name string, tags map[string]string, fields map[string]interface{}, t time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"name":   name,
+		"tags":   tags,
+		"fields": fields,
+		"time":   t,
+	}
+}
+
+// OutputType returns the type name of the expression result
+func (p *Program) OutputType() string {
+	return p.outputType.String()
+}
+
+// IsBool returns true if the expression is guaranteed to return a boolean
+func (p *Program) IsBool() bool {
+	return p.outputType == cel.BoolType
+}
+
+// IsScalar returns false if the expression returns a list or a map
+func (p *Program) IsScalar() bool {
+	switch p.outputType.Kind() {
+	case cel.ListKind, cel.MapKind:
+		return false
+	}
+	return true
+}
+
+// Eval evaluates the program and returns the native Go value of the result
+func (p *Program) Eval(vars map[string]interface{}) (interface{}, error) {
+	result, _, err := p.program.Eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	return result.Value(), nil
+}
+
+// EvalBool evaluates the program expecting a boolean result
+func (p *Program) EvalBool(vars map[string]interface{}) (bool, error) {
+	value, err := p.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	if v, ok := value.(bool); ok {
+		return v, nil
+	}
+	return false, fmt.Errorf("invalid result type %T", value)
+}
+
+// FieldValue converts the result of an expression to a valid field value.
+// Timestamps are converted to nanoseconds since epoch and durations to
+// nanoseconds.
+func FieldValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int64, uint64, float64, bool, string:
+		return v, nil
+	case time.Time:
+		return v.UnixNano(), nil
+	case time.Duration:
+		return int64(v), nil
+	}
+	return nil, fmt.Errorf("unsupported result type %T", value)
+}
@@ -0,0 +1,85 @@
+package cel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	env, err := NewEnvironment()
+	require.NoError(t, err)
+
+	program, err := env.Compile(`name == "cpu" && tags.host == "a"`)
+	require.NoError(t, err)
+	require.True(t, program.IsBool())
+	require.True(t, program.IsScalar())
+
+	program, err = env.Compile(`tags`)
+	require.NoError(t, err)
+	require.False(t, program.IsBool())
+	require.False(t, program.IsScalar())
+
+	_, err = env.Compile(`fields.a /`)
+	require.Error(t, err)
+
+	_, err = env.Compile(`unknown > 1`)
+	require.Error(t, err)
+}
+
+func TestEval(t *testing.T) {
+	env, err := NewEnvironment()
+	require.NoError(t, err)
+
+	vars := Variables(
+		"cpu",
+		map[string]string{"host": "a"},
+		map[string]interface{}{"idle": 75.0, "busy": int64(25)},
+		time.Unix(10, 0),
+	)
+
+	program, err := env.Compile(`double(fields.busy) / (fields.idle + double(fields.busy))`)
+	require.NoError(t, err)
+	value, err := program.Eval(vars)
+	require.NoError(t, err)
+	require.InDelta(t, 0.25, value, 1e-9)
+
+	program, err = env.Compile(`tags.host == "a" && now() > time`)
+	require.NoError(t, err)
+	ok, err := program.EvalBool(vars)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	program, err = env.Compile(`fields.idle`)
+	require.NoError(t, err)
+	_, err = program.EvalBool(vars)
+	require.ErrorContains(t, err, "invalid result type float64")
+}
+
+func TestFieldValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    interface{}
+		expected interface{}
+	}{
+		{name: "int", input: int64(-1), expected: int64(-1)},
+		{name: "uint", input: uint64(1), expected: uint64(1)},
+		{name: "float", input: 1.5, expected: 1.5},
+		{name: "bool", input: true, expected: true},
+		{name: "string", input: "a", expected: "a"},
+		{name: "timestamp", input: time.Unix(1, 5), expected: int64(1000000005)},
+		{name: "duration", input: time.Second, expected: int64(1000000000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := FieldValue(tt.input)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+
+	_, err := FieldValue([]interface{}{1})
+	require.ErrorContains(t, err, "unsupported result type")
+}
@@ -0,0 +1,429 @@
+// Package columnar contains the conversion of metrics to Apache Arrow records
+// shared by the columnar serializers and outputs.
+package columnar
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/choice"
+)
+
+// Builder converts metrics to Arrow records with schemas inferred from the
+// metrics of each measurement.
+type Builder struct {
+	// TimestampColumn is the name of the column holding the metric time
+	TimestampColumn string
+	// TimestampType is the type of the timestamp column, either a nanosecond
+	// timestamp (default) or a 64-bit integer holding nanoseconds since epoch
+	TimestampType arrow.DataType
+	// TimestampLast puts the timestamp column after all other columns
+	TimestampLast bool
+	// OmitTimestamp skips the timestamp column
+	OmitTimestamp bool
+	// MeasurementColumn is the name of the column holding the metric name,
+	// if set all measurements are put into a single record
+	MeasurementColumn string
+	// TypeWidening determines how fields with conflicting types are handled,
+	// one of "none", "numeric" or "string"
+	TypeWidening string
+}
+
+// Init checks the settings and applies the defaults
+func (b *Builder) Init() error {
+	if b.TimestampColumn == "" {
+		b.TimestampColumn = "timestamp"
+	}
+	if b.TimestampType == nil {
+		b.TimestampType = arrow.FixedWidthTypes.Timestamp_ns
+	}
+	switch b.TimestampType.ID() {
+	case arrow.TIMESTAMP, arrow.INT64:
+	default:
+		return fmt.Errorf("invalid timestamp type %s", b.TimestampType)
+	}
+	if b.TypeWidening == "" {
+		b.TypeWidening = "numeric"
+	}
+	if err := choice.Check(b.TypeWidening, []string{"none", "numeric", "string"}); err != nil {
+		return fmt.Errorf("invalid type widening: %w", err)
+	}
+	if !b.OmitTimestamp && b.MeasurementColumn == b.TimestampColumn {
+		return errors.New("measurement and timestamp column must differ")
+	}
+	return nil
+}
+
+// Build converts the metrics to records, one per measurement in the order of
+// their first occurrence or a single one if a measurement column is set. The
+// caller is responsible for releasing the records.
+func (b *Builder) Build(metrics []telegraf.Metric) ([]arrow.Record, error) {
+	groups := make(map[string][]telegraf.Metric)
+	order := make([]string, 0)
+	for _, m := range metrics {
+		var key string
+		if b.MeasurementColumn == "" {
+			key = m.Name()
+		}
+		if _, found := groups[key]; !found {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], m)
+	}
+
+	records := make([]arrow.Record, 0, len(order))
+	for _, key := range order {
+		schema, err := b.Schema(key, groups[key])
+		if err != nil {
+			releaseAll(records)
+			return nil, err
+		}
+		record, err := b.Record(schema, groups[key])
+		if err != nil {
+			releaseAll(records)
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Record converts the metrics to a record of the given schema. Values of
+// tags and fields not contained in the schema are ignored, missing ones are
+// set to null. The caller is responsible for releasing the record.
+func (b *Builder) Record(schema *arrow.Schema, metrics []telegraf.Metric) (arrow.Record, error) {
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+
+	for i, col := range schema.Fields() {
+		fb := builder.Field(i)
+		for _, m := range metrics {
+			if b.isReserved(col.Name) {
+				if err := b.appendReserved(fb, col.Name, m); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			value, found := lookup(m, col.Name)
+			if !found {
+				fb.AppendNull()
+				continue
+			}
+			if err := appendValue(fb, value); err != nil {
+				return nil, fmt.Errorf("column %q: %w", col.Name, err)
+			}
+		}
+	}
+
+	return builder.NewRecord(), nil
+}
+
+// Check returns an error if a tag or field value of the metric cannot be
+// stored in the corresponding column of the given schema
+func (b *Builder) Check(schema *arrow.Schema, m telegraf.Metric) error {
+	for _, col := range schema.Fields() {
+		if b.isReserved(col.Name) {
+			continue
+		}
+		value, found := lookup(m, col.Name)
+		if !found {
+			continue
+		}
+		if !convertible(col.Type, value) {
+			return fmt.Errorf("cannot store %T in %s column %q", value, col.Type, col.Name)
+		}
+	}
+	return nil
+}
+
+// Schema determines the columns of the given metrics with the timestamp
+// first, unless configured otherwise, followed by the measurement, tags and
+// fields each sorted by name.
+// If a tag and a field share the same key, the field takes precedence.
+func (b *Builder) Schema(name string, metrics []telegraf.Metric) (*arrow.Schema, error) {
+	tags := make(map[string]bool)
+	fields := make(map[string]arrow.DataType)
+	negative := make(map[string]bool)
+	for _, m := range metrics {
+		for _, tag := range m.TagList() {
+			tags[tag.Key] = true
+		}
+		for _, field := range m.FieldList() {
+			dt := arrowType(field.Value)
+			if dt == nil {
+				return nil, fmt.Errorf("unsupported type %T of field %q", field.Value, field.Key)
+			}
+			if v, ok := field.Value.(int64); ok && v < 0 {
+				negative[field.Key] = true
+			}
+			current, found := fields[field.Key]
+			if !found {
+				fields[field.Key] = dt
+				continue
+			}
+			widened, err := b.widen(current, dt)
+			if err != nil {
+				return nil, fmt.Errorf("field %q of measurement %q: %w", field.Key, m.Name(), err)
+			}
+			fields[field.Key] = widened
+		}
+	}
+
+	// Mixing signed and unsigned integers results in unsigned integers
+	// unless negative values are present which can only be held by floats
+	for k, dt := range fields {
+		if dt.ID() == arrow.UINT64 && negative[k] {
+			fields[k] = arrow.PrimitiveTypes.Float64
+		}
+	}
+
+	columns := make([]arrow.Field, 0, len(tags)+len(fields)+2)
+	reserved := make(map[string]bool)
+	timestamp := arrow.Field{Name: b.TimestampColumn, Type: b.TimestampType}
+	if !b.OmitTimestamp {
+		if !b.TimestampLast {
+			columns = append(columns, timestamp)
+		}
+		reserved[b.TimestampColumn] = true
+	}
+	if b.MeasurementColumn != "" {
+		columns = append(columns, arrow.Field{Name: b.MeasurementColumn, Type: arrow.BinaryTypes.String})
+		reserved[b.MeasurementColumn] = true
+	}
+
+	tagKeys := make([]string, 0, len(tags))
+	for k := range tags {
+		if reserved[k] {
+			return nil, fmt.Errorf("tag %q conflicts with timestamp or measurement column", k)
+		}
+		if _, found := fields[k]; found {
+			continue
+		}
+		tagKeys = append(tagKeys, k)
+	}
+	sort.Strings(tagKeys)
+	for _, k := range tagKeys {
+		columns = append(columns, arrow.Field{Name: k, Type: arrow.BinaryTypes.String, Nullable: true})
+	}
+
+	fieldKeys := make([]string, 0, len(fields))
+	for k := range fields {
+		if reserved[k] {
+			return nil, fmt.Errorf("field %q conflicts with timestamp or measurement column", k)
+		}
+		fieldKeys = append(fieldKeys, k)
+	}
+	sort.Strings(fieldKeys)
+	for _, k := range fieldKeys {
+		columns = append(columns, arrow.Field{Name: k, Type: fields[k], Nullable: true})
+	}
+	if !b.OmitTimestamp && b.TimestampLast {
+		columns = append(columns, timestamp)
+	}
+
+	var metadata *arrow.Metadata
+	if b.MeasurementColumn == "" {
+		md := arrow.NewMetadata([]string{"measurement"}, []string{name})
+		metadata = &md
+	}
+	return arrow.NewSchema(columns, metadata), nil
+}
+
+func (b *Builder) isReserved(name string) bool {
+	return !b.OmitTimestamp && name == b.TimestampColumn || b.MeasurementColumn != "" && name == b.MeasurementColumn
+}
+
+func (b *Builder) appendReserved(builder array.Builder, name string, m telegraf.Metric) error {
+	switch fb := builder.(type) {
+	case *array.TimestampBuilder:
+		if name == b.TimestampColumn {
+			fb.Append(arrow.Timestamp(m.Time().UnixNano()))
+			return nil
+		}
+	case *array.Int64Builder:
+		if name == b.TimestampColumn {
+			fb.Append(m.Time().UnixNano())
+			return nil
+		}
+	case *array.StringBuilder:
+		if name == b.MeasurementColumn {
+			fb.Append(m.Name())
+			return nil
+		}
+	}
+	return fmt.Errorf("column %q has invalid type %s", name, builder.Type())
+}
+
+// lookup returns the value of the field with the given key or the tag if no
+// such field exists
+func lookup(m telegraf.Metric, key string) (interface{}, bool) {
+	if value, found := m.GetField(key); found {
+		return value, true
+	}
+	if tag, found := m.GetTag(key); found {
+		return tag, true
+	}
+	return nil, false
+}
+
+func releaseAll(records []arrow.Record) {
+	for _, r := range records {
+		r.Release()
+	}
+}
+
+// widen returns the type able to hold values of both given types according
+// to the type-widening setting
+func (b *Builder) widen(a, c arrow.DataType) (arrow.DataType, error) {
+	if arrow.TypeEqual(a, c) {
+		return a, nil
+	}
+
+	if b.TypeWidening != "none" && isNumeric(a) && isNumeric(c) {
+		// Everything involving floats results in floats while mixing signed
+		// and unsigned integers results in unsigned integers, see Schema for
+		// the handling of negative values
+		if a.ID() == arrow.FLOAT64 || c.ID() == arrow.FLOAT64 {
+			return arrow.PrimitiveTypes.Float64, nil
+		}
+		return arrow.PrimitiveTypes.Uint64, nil
+	}
+
+	if b.TypeWidening == "string" {
+		return arrow.BinaryTypes.String, nil
+	}
+	return nil, fmt.Errorf("conflicting types %s and %s", a, c)
+}
+
+func isNumeric(dt arrow.DataType) bool {
+	switch dt.ID() {
+	case arrow.INT64, arrow.UINT64, arrow.FLOAT64:
+		return true
+	}
+	return false
+}
+
+func arrowType(value interface{}) arrow.DataType {
+	switch value.(type) {
+	case int64:
+		return arrow.PrimitiveTypes.Int64
+	case uint64:
+		return arrow.PrimitiveTypes.Uint64
+	case float64:
+		return arrow.PrimitiveTypes.Float64
+	case bool:
+		return arrow.FixedWidthTypes.Boolean
+	case string:
+		return arrow.BinaryTypes.String
+	}
+	return nil
+}
+
+// appendValue adds the value to the column builder converting it to the
+// column type if necessary
+func appendValue(builder array.Builder, value interface{}) error {
+	switch fb := builder.(type) {
+	case *array.Int64Builder:
+		switch v := value.(type) {
+		case int64:
+			fb.Append(v)
+			return nil
+		case uint64:
+			if v > math.MaxInt64 {
+				return fmt.Errorf("value %d overflows int64", v)
+			}
+			fb.Append(int64(v))
+			return nil
+		}
+	case *array.Uint64Builder:
+		switch v := value.(type) {
+		case uint64:
+			fb.Append(v)
+			return nil
+		case int64:
+			if v < 0 {
+				return fmt.Errorf("negative value %d cannot be stored as uint64", v)
+			}
+			fb.Append(uint64(v))
+			return nil
+		}
+	case *array.Float64Builder:
+		switch v := value.(type) {
+		case float64:
+			fb.Append(v)
+			return nil
+		case int64:
+			fb.Append(float64(v))
+			return nil
+		case uint64:
+			fb.Append(float64(v))
+			return nil
+		}
+	case *array.BooleanBuilder:
+		if v, ok := value.(bool); ok {
+			fb.Append(v)
+			return nil
+		}
+	case *array.StringBuilder:
+		switch v := value.(type) {
+		case string:
+			fb.Append(v)
+		case int64:
+			fb.Append(strconv.FormatInt(v, 10))
+		case uint64:
+			fb.Append(strconv.FormatUint(v, 10))
+		case float64:
+			fb.Append(strconv.FormatFloat(v, 'g', -1, 64))
+		case bool:
+			fb.Append(strconv.FormatBool(v))
+		default:
+			return fmt.Errorf("cannot convert %T to string", value)
+		}
+		return nil
+	}
+	return fmt.Errorf("cannot append %T to %s column", value, builder.Type())
+}
+
+// convertible checks if appendValue accepts the value for a column of the
+// given type
+func convertible(dt arrow.DataType, value interface{}) bool {
+	switch dt.ID() {
+	case arrow.INT64:
+		switch v := value.(type) {
+		case int64:
+			return true
+		case uint64:
+			return v <= math.MaxInt64
+		}
+	case arrow.UINT64:
+		switch v := value.(type) {
+		case uint64:
+			return true
+		case int64:
+			return v >= 0
+		}
+	case arrow.FLOAT64:
+		switch value.(type) {
+		case float64, int64, uint64:
+			return true
+		}
+	case arrow.BOOL:
+		_, ok := value.(bool)
+		return ok
+	case arrow.STRING:
+		switch value.(type) {
+		case string, int64, uint64, float64, bool:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,240 @@
+package columnar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func TestBuild(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 1.5, "ok": true}, time.Unix(1, 0)),
+		metric.New("mem", map[string]string{"host": "a"}, map[string]interface{}{"free": uint64(10)}, time.Unix(2, 0)),
+		metric.New("cpu", map[string]string{"cpu": "cpu0"}, map[string]interface{}{"usage": 2.5, "state": "idle"}, time.Unix(3, 0)),
+	}
+
+	b := &Builder{}
+	require.NoError(t, b.Init())
+
+	records, err := b.Build(metrics)
+	require.NoError(t, err)
+	defer func() {
+		for _, r := range records {
+			r.Release()
+		}
+	}()
+	require.Len(t, records, 2)
+
+	cpu := records[0]
+	expected := arrow.NewSchema(
+		[]arrow.Field{
+			{Name: "timestamp", Type: arrow.FixedWidthTypes.Timestamp_ns},
+			{Name: "cpu", Type: arrow.BinaryTypes.String, Nullable: true},
+			{Name: "host", Type: arrow.BinaryTypes.String, Nullable: true},
+			{Name: "ok", Type: arrow.FixedWidthTypes.Boolean, Nullable: true},
+			{Name: "state", Type: arrow.BinaryTypes.String, Nullable: true},
+			{Name: "usage", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+		},
+		&arrow.Metadata{},
+	)
+	require.Truef(t, expected.Equal(cpu.Schema()), "expected %s but got %s", expected, cpu.Schema())
+	measurement, found := cpu.Schema().Metadata().GetValue("measurement")
+	require.True(t, found)
+	require.Equal(t, "cpu", measurement)
+	require.Equal(t, int64(2), cpu.NumRows())
+	require.Equal(t, `[1000000000 3000000000]`, cpu.Column(0).String())
+	require.Equal(t, `[(null) "cpu0"]`, cpu.Column(1).String())
+	require.Equal(t, `["a" (null)]`, cpu.Column(2).String())
+	require.Equal(t, `[true (null)]`, cpu.Column(3).String())
+	require.Equal(t, `[(null) "idle"]`, cpu.Column(4).String())
+	require.Equal(t, `[1.5 2.5]`, cpu.Column(5).String())
+
+	mem := records[1]
+	require.Equal(t, int64(1), mem.NumRows())
+	require.Equal(t, "free", mem.Schema().Field(2).Name)
+	require.Equal(t, arrow.PrimitiveTypes.Uint64, mem.Schema().Field(2).Type)
+}
+
+func TestBuildMeasurementColumn(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"usage": 1.5}, time.Unix(1, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"free": int64(10)}, time.Unix(2, 0)),
+	}
+
+	b := &Builder{MeasurementColumn: "measurement"}
+	require.NoError(t, b.Init())
+
+	records, err := b.Build(metrics)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	defer records[0].Release()
+
+	record := records[0]
+	require.False(t, record.Schema().HasMetadata())
+	require.Equal(t, []string{"timestamp", "measurement", "free", "usage"}, columnNames(record))
+	require.Equal(t, `["cpu" "mem"]`, record.Column(1).String())
+	require.Equal(t, `[(null) 10]`, record.Column(2).String())
+	require.Equal(t, `[1.5 (null)]`, record.Column(3).String())
+}
+
+func TestBuildTypeWidening(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New("m", map[string]string{}, map[string]interface{}{"a": int64(1), "b": int64(-1), "c": true}, time.Unix(1, 0)),
+		metric.New("m", map[string]string{}, map[string]interface{}{"a": 2.5, "b": uint64(2), "c": "x"}, time.Unix(2, 0)),
+	}
+
+	tests := []struct {
+		widening string
+		expected []string
+		types    []arrow.DataType
+		err      string
+	}{
+		{
+			widening: "none",
+			err:      "conflicting types",
+		},
+		{
+			widening: "numeric",
+			err:      `field "c" of measurement "m": conflicting types bool and utf8`,
+		},
+		{
+			widening: "string",
+			expected: []string{`[1 2.5]`, `[-1 2]`, `["true" "x"]`},
+			types:    []arrow.DataType{arrow.PrimitiveTypes.Float64, arrow.PrimitiveTypes.Float64, arrow.BinaryTypes.String},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.widening, func(t *testing.T) {
+			b := &Builder{TypeWidening: tt.widening}
+			require.NoError(t, b.Init())
+
+			records, err := b.Build(metrics)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, records, 1)
+			defer records[0].Release()
+
+			for i, expected := range tt.expected {
+				require.Equal(t, tt.types[i], records[0].Schema().Field(i+1).Type)
+				require.Equal(t, expected, records[0].Column(i+1).String())
+			}
+		})
+	}
+}
+
+func TestBuildIntegerWidening(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New("m", map[string]string{}, map[string]interface{}{"a": uint64(1 << 63), "b": uint64(1 << 63)}, time.Unix(1, 0)),
+		metric.New("m", map[string]string{}, map[string]interface{}{"a": int64(1), "b": int64(-1)}, time.Unix(2, 0)),
+	}
+
+	b := &Builder{}
+	require.NoError(t, b.Init())
+
+	records, err := b.Build(metrics)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	defer records[0].Release()
+
+	// Unsigned values beyond the signed range must be kept, negative values
+	// require floats
+	record := records[0]
+	require.Equal(t, arrow.PrimitiveTypes.Uint64, record.Schema().Field(1).Type)
+	require.Equal(t, `[9223372036854775808 1]`, record.Column(1).String())
+	require.Equal(t, arrow.PrimitiveTypes.Float64, record.Schema().Field(2).Type)
+	require.Equal(t, `[9.223372036854776e+18 -1]`, record.Column(2).String())
+}
+
+func TestBuildTagFieldConflict(t *testing.T) {
+	b := &Builder{}
+	require.NoError(t, b.Init())
+
+	records, err := b.Build([]telegraf.Metric{
+		metric.New("m", map[string]string{"x": "a", "host": "b"}, map[string]interface{}{"x": 1.0}, time.Unix(0, 0)),
+	})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	defer records[0].Release()
+
+	// The field takes precedence over the tag
+	require.Equal(t, []string{"timestamp", "host", "x"}, columnNames(records[0]))
+	require.Equal(t, arrow.PrimitiveTypes.Float64, records[0].Schema().Field(2).Type)
+	require.Equal(t, `[1]`, records[0].Column(2).String())
+}
+
+func TestRecordWithSchema(t *testing.T) {
+	b := &Builder{OmitTimestamp: true}
+	require.NoError(t, b.Init())
+
+	schema, err := b.Schema("m", []telegraf.Metric{
+		metric.New("m", map[string]string{"host": "a"}, map[string]interface{}{"count": int64(1), "value": 1.5}, time.Unix(0, 0)),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"host", "count", "value"}, []string{schema.Field(0).Name, schema.Field(1).Name, schema.Field(2).Name})
+
+	// Metrics not fitting the schema must be detected before building
+	m := metric.New("m", map[string]string{}, map[string]interface{}{"count": 1.5}, time.Unix(0, 0))
+	require.ErrorContains(t, b.Check(schema, m), `cannot store float64 in int64 column "count"`)
+
+	// Unknown keys are ignored and missing ones are null
+	m = metric.New("m", map[string]string{"other": "x"}, map[string]interface{}{"count": uint64(2), "new": true}, time.Unix(0, 0))
+	require.NoError(t, b.Check(schema, m))
+	record, err := b.Record(schema, []telegraf.Metric{m})
+	require.NoError(t, err)
+	defer record.Release()
+	require.Equal(t, []string{"host", "count", "value"}, columnNames(record))
+	require.Equal(t, `[(null)]`, record.Column(0).String())
+	require.Equal(t, `[2]`, record.Column(1).String())
+	require.Equal(t, `[(null)]`, record.Column(2).String())
+}
+
+func TestBuildTimestampInt64Last(t *testing.T) {
+	b := &Builder{
+		TimestampColumn: "time",
+		TimestampType:   arrow.PrimitiveTypes.Int64,
+		TimestampLast:   true,
+	}
+	require.NoError(t, b.Init())
+
+	records, err := b.Build([]telegraf.Metric{
+		metric.New("m", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.5}, time.Unix(1, 0)),
+	})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	defer records[0].Release()
+
+	require.Equal(t, []string{"host", "value", "time"}, columnNames(records[0]))
+	require.Equal(t, arrow.PrimitiveTypes.Int64, records[0].Schema().Field(2).Type)
+	require.Equal(t, `[1000000000]`, records[0].Column(2).String())
+}
+
+func TestBuildErrors(t *testing.T) {
+	b := &Builder{}
+	require.NoError(t, b.Init())
+
+	_, err := b.Build([]telegraf.Metric{
+		metric.New("m", map[string]string{}, map[string]interface{}{"timestamp": 1.0}, time.Unix(0, 0)),
+	})
+	require.ErrorContains(t, err, `field "timestamp" conflicts with timestamp or measurement column`)
+
+	require.ErrorContains(t, (&Builder{TypeWidening: "all"}).Init(), "invalid type widening")
+	require.ErrorContains(t, (&Builder{TimestampType: arrow.BinaryTypes.String}).Init(), "invalid timestamp type utf8")
+	require.ErrorContains(t, (&Builder{MeasurementColumn: "timestamp"}).Init(), "must differ")
+}
+
+func columnNames(record arrow.Record) []string {
+	names := make([]string, 0, record.NumCols())
+	for _, f := range record.Schema().Fields() {
+		names = append(names, f.Name)
+	}
+	return names
+}
@@ -0,0 +1,55 @@
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Container IDs of docker, containerd and cri-o are 64 hex characters and
+// appear as the last path element of the cgroup, possibly wrapped by a
+// systemd scope like 'docker-<id>.scope' or 'cri-containerd-<id>.scope'.
+var containerIDPattern = regexp.MustCompile(`(?:^|[/\-:])([0-9a-f]{64})(?:\.scope)?$`)
+
+// ContainerIDFromCgroup extracts the ID of the container from the content of
+// a '/proc/<pid>/cgroup' file for both cgroup v1 and v2. An empty string is
+// returned if the process does not run in a container.
+func ContainerIDFromCgroup(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		// Each line has the format 'hierarchy-ID:controller-list:cgroup-path'
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if match := containerIDPattern.FindStringSubmatch(parts[2]); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// ContainerIDFromPID returns the ID of the container running the process by
+// reading the cgroup file in the given proc filesystem
+func ContainerIDFromPID(procRoot string, pid int64) (string, error) {
+	fn := filepath.Join(procRoot, strconv.FormatInt(pid, 10), "cgroup")
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		return "", fmt.Errorf("reading cgroup of process %d failed: %w", pid, err)
+	}
+	return ContainerIDFromCgroup(data), nil
+}
+
+// TrimContainerRuntime removes the runtime prefix like 'containerd://' or
+// 'docker://' from container IDs reported by Kubernetes
+func TrimContainerRuntime(id string) string {
+	if _, after, found := strings.Cut(id, "://"); found {
+		return after
+	}
+	return id
+}
@@ -0,0 +1,80 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerIDFromCgroup(t *testing.T) {
+	id := "3f4b2c1d9e8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f4a3b2c"
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "cgroup v1 docker",
+			content:  "12:memory:/docker/" + id + "\n11:cpu,cpuacct:/docker/" + id + "\n",
+			expected: id,
+		},
+		{
+			name:     "cgroup v1 kubernetes",
+			content:  "11:devices:/kubepods/burstable/pod0b8f6f0e-5c1d-4b8f-9d6a-1a2b3c4d5e6f/" + id + "\n",
+			expected: id,
+		},
+		{
+			name:     "cgroup v2 docker",
+			content:  "0::/system.slice/docker-" + id + ".scope\n",
+			expected: id,
+		},
+		{
+			name: "cgroup v2 containerd",
+			content: "0::/kubepods.slice/kubepods-besteffort.slice/" +
+				"kubepods-besteffort-pod0b8f6f0e_5c1d_4b8f_9d6a_1a2b3c4d5e6f.slice/cri-containerd-" + id + ".scope\n",
+			expected: id,
+		},
+		{
+			name:     "cgroup v2 cri-o",
+			content:  "0::/kubepods.slice/crio-" + id + ".scope\n",
+			expected: id,
+		},
+		{
+			name:    "host process",
+			content: "0::/user.slice/user-1000.slice/session-2.scope\n",
+		},
+		{
+			name: "invalid content",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, ContainerIDFromCgroup([]byte(tt.content)))
+		})
+	}
+}
+
+func TestContainerIDFromPID(t *testing.T) {
+	id := "3f4b2c1d9e8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f4a3b2c"
+
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "42"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "42", "cgroup"), []byte("0::/docker/"+id+"\n"), 0600))
+
+	actual, err := ContainerIDFromPID(root, 42)
+	require.NoError(t, err)
+	require.Equal(t, id, actual)
+
+	_, err = ContainerIDFromPID(root, 43)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestTrimContainerRuntime(t *testing.T) {
+	require.Equal(t, "abc", TrimContainerRuntime("containerd://abc"))
+	require.Equal(t, "abc", TrimContainerRuntime("docker://abc"))
+	require.Equal(t, "abc", TrimContainerRuntime("abc"))
+}
@@ -0,0 +1,79 @@
+package docker
+
+import (
+	"sync"
+	"time"
+)
+
+// PIDCache caches the container IDs of processes to avoid reading the cgroup
+// file for every metric. Entries expire after the TTL as process IDs are
+// reused by the system after a process terminated.
+type PIDCache struct {
+	procRoot  string
+	ttl       time.Duration
+	entries   map[int64]pidEntry
+	nextPurge time.Time
+	sync.Mutex
+}
+
+type pidEntry struct {
+	id      string
+	expires time.Time
+}
+
+// NewPIDCache creates a cache resolving the processes in the given proc
+// filesystem
+func NewPIDCache(procRoot string, ttl time.Duration) *PIDCache {
+	return &PIDCache{
+		procRoot: procRoot,
+		ttl:      ttl,
+		entries:  make(map[int64]pidEntry),
+	}
+}
+
+// ContainerID returns the ID of the container running the process, or an
+// empty string if the process does not run in a container. Failures to read
+// the cgroup of the process are not cached.
+func (c *PIDCache) ContainerID(pid int64) (string, error) {
+	now := time.Now()
+
+	c.Lock()
+	defer c.Unlock()
+
+	if entry, found := c.entries[pid]; found && now.Before(entry.expires) {
+		return entry.id, nil
+	}
+
+	id, err := ContainerIDFromPID(c.procRoot, pid)
+	if err != nil {
+		delete(c.entries, pid)
+		return "", err
+	}
+	c.entries[pid] = pidEntry{id: id, expires: now.Add(c.ttl)}
+
+	// Remove the entries of processes not seen anymore from time to time to
+	// not grow indefinitely
+	if now.After(c.nextPurge) {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.nextPurge = now.Add(c.ttl)
+	}
+
+	return id, nil
+}
+
+// Evict removes all processes of the given container, e.g. after the
+// container was destroyed
+func (c *PIDCache) Evict(id string) {
+	c.Lock()
+	defer c.Unlock()
+
+	for k, entry := range c.entries {
+		if entry.id == id {
+			delete(c.entries, k)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPIDCache(t *testing.T) {
+	id := "3f4b2c1d9e8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f4a3b2c"
+
+	root := t.TempDir()
+	fn := filepath.Join(root, "42", "cgroup")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "42"), 0750))
+	require.NoError(t, os.WriteFile(fn, []byte("0::/docker/"+id+"\n"), 0600))
+
+	cache := NewPIDCache(root, time.Hour)
+	actual, err := cache.ContainerID(42)
+	require.NoError(t, err)
+	require.Equal(t, id, actual)
+
+	// The cached ID must be used without reading the cgroup again
+	require.NoError(t, os.WriteFile(fn, []byte("0::/user.slice\n"), 0600))
+	actual, err = cache.ContainerID(42)
+	require.NoError(t, err)
+	require.Equal(t, id, actual)
+
+	// Evicting the container must read the cgroup again
+	cache.Evict(id)
+	actual, err = cache.ContainerID(42)
+	require.NoError(t, err)
+	require.Empty(t, actual)
+
+	_, err = cache.ContainerID(43)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestPIDCacheExpiry(t *testing.T) {
+	id := "3f4b2c1d9e8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f4a3b2c"
+
+	root := t.TempDir()
+	fn := filepath.Join(root, "42", "cgroup")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "42"), 0750))
+	require.NoError(t, os.WriteFile(fn, []byte("0::/docker/"+id+"\n"), 0600))
+
+	cache := NewPIDCache(root, 0)
+	actual, err := cache.ContainerID(42)
+	require.NoError(t, err)
+	require.Equal(t, id, actual)
+
+	// Expired entries must be resolved again as the process ID might have
+	// been reused
+	require.NoError(t, os.WriteFile(fn, []byte("0::/user.slice\n"), 0600))
+	actual, err = cache.ContainerID(42)
+	require.NoError(t, err)
+	require.Empty(t, actual)
+}
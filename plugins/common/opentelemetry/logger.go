@@ -0,0 +1,19 @@
+package opentelemetry
+
+import (
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Logger adapts a Telegraf logger to the logger interface of the
+// influxdb-observability converters, printing the key-value pairs as part of
+// the debug message
+type Logger struct {
+	telegraf.Logger
+}
+
+func (l Logger) Debug(msg string, kv ...interface{}) {
+	format := msg + strings.Repeat(" %s=%q", len(kv)/2)
+	l.Logger.Debugf(format, kv...)
+}
@@ -0,0 +1,20 @@
+package opentelemetry
+
+import (
+	"testing"
+
+	"github.com/influxdata/influxdb-observability/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestDebug(t *testing.T) {
+	log := &testutil.CaptureLogger{}
+
+	var logger common.Logger = &Logger{log}
+	logger.Debug("skipping metric", "name", "cpu", "reason", "no fields")
+
+	expected := []testutil.Entry{{Level: testutil.LevelDebug, Text: `skipping metric name="cpu" reason="no fields"`}}
+	require.Equal(t, expected, log.Messages())
+}
@@ -0,0 +1,15 @@
+package s2cell
+
+import (
+	"github.com/golang/geo/s2"
+)
+
+// Token returns the token of the S2 cell at the given level containing the
+// location or false if the location is invalid
+func Token(lat, lon float64, level int) (string, bool) {
+	cellID := s2.CellIDFromLatLng(s2.LatLngFromDegrees(lat, lon))
+	if !cellID.IsValid() {
+		return "", false
+	}
+	return cellID.Parent(level).ToToken(), true
+}
@@ -0,0 +1,17 @@
+package s2cell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestToken(t *testing.T) {
+	token, ok := Token(40.7128, -74.006, 9)
+	require.True(t, ok)
+	require.Equal(t, "89c25c", token)
+
+	token, ok = Token(40.7128, -74.006, 0)
+	require.True(t, ok)
+	require.Equal(t, "9", token)
+}
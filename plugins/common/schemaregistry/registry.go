@@ -0,0 +1,144 @@
+package schemaregistry
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const (
+	schemaByID     = "%s/schemas/ids/%d"
+	registerSchema = "%s/subjects/%s/versions"
+)
+
+// Client for a Confluent compatible schema registry
+type Client struct {
+	url      string
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewClient creates a client for the registry at the given address.
+// Credentials can be provided as part of the address, the CA certificate is
+// used to verify the registry if given.
+func NewClient(addr, caCertPath string) (*Client, error) {
+	var tlsCfg *tls.Config
+	if caCertPath != "" {
+		caCert, err := os.ReadFile(caCertPath)
+		if err != nil {
+			return nil, err
+		}
+		caCertPool := x509.NewCertPool()
+		caCertPool.AppendCertsFromPEM(caCert)
+		tlsCfg = &tls.Config{
+			RootCAs: caCertPool,
+		}
+	}
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+			MaxIdleConns:    10,
+			IdleConnTimeout: 90 * time.Second,
+		},
+		Timeout: 10 * time.Second,
+	}
+
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing registry URL failed: %w", err)
+	}
+
+	var username, password string
+	if u.User != nil {
+		username = u.User.Username()
+		password, _ = u.User.Password()
+		u.User = nil
+	}
+
+	return &Client{
+		url:      u.String(),
+		username: username,
+		password: password,
+		client:   client,
+	}, nil
+}
+
+// Schema returns the schema registered with the given ID
+func (c *Client) Schema(id int) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(schemaByID, c.url, id), nil)
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		Schema *string `json:"schema"`
+	}
+	if err := c.do(req, &response); err != nil {
+		return "", fmt.Errorf("getting schema %d failed: %w", id, err)
+	}
+	if response.Schema == nil {
+		return "", errors.New("malformed response from schema registry: no 'schema' key")
+	}
+
+	return *response.Schema, nil
+}
+
+// Register adds the schema to the given subject and returns the ID assigned
+// by the registry. Registering an already known schema returns its existing
+// ID.
+func (c *Client) Register(subject, schema string) (int, error) {
+	body, err := json.Marshal(map[string]string{"schema": schema})
+	if err != nil {
+		return 0, err
+	}
+
+	addr := fmt.Sprintf(registerSchema, c.url, url.PathEscape(subject))
+	req, err := http.NewRequest(http.MethodPost, addr, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+
+	var response struct {
+		ID *int `json:"id"`
+	}
+	if err := c.do(req, &response); err != nil {
+		return 0, fmt.Errorf("registering schema for subject %q failed: %w", subject, err)
+	}
+	if response.ID == nil {
+		return 0, errors.New("malformed response from schema registry: no 'id' key")
+	}
+
+	return *response.ID, nil
+}
+
+func (c *Client) do(req *http.Request, response interface{}) error {
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, string(msg))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("decoding response from schema registry failed: %w", err)
+	}
+	return nil
+}
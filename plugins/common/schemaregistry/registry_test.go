@@ -0,0 +1,60 @@
+package schemaregistry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterAndSchema(t *testing.T) {
+	var schemas []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/subjects/cpu-value/versions":
+			var body struct {
+				Schema string `json:"schema"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			schemas = append(schemas, body.Schema)
+			fmt.Fprintf(w, `{"id":%d}`, len(schemas))
+		case r.Method == http.MethodGet && r.URL.Path == "/schemas/ids/1":
+			if err := json.NewEncoder(w).Encode(map[string]string{"schema": schemas[0]}); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error_code":40403,"message":"Schema not found"}`)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient("http://user:secret@"+server.Listener.Addr().String(), "")
+	require.NoError(t, err)
+
+	id, err := client.Register("cpu-value", `"string"`)
+	require.NoError(t, err)
+	require.Equal(t, 1, id)
+
+	schema, err := client.Schema(1)
+	require.NoError(t, err)
+	require.Equal(t, `"string"`, schema)
+
+	_, err = client.Schema(2)
+	require.ErrorContains(t, err, "getting schema 2 failed: status 404")
+
+	_, err = client.Register("mem-value", `"string"`)
+	require.ErrorContains(t, err, `registering schema for subject "mem-value" failed: status 404`)
+}
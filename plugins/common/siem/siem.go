@@ -0,0 +1,94 @@
+// Package siem contains the handling shared by the parsers of security event
+// formats like the ArcSight Common Event Format (CEF) and the IBM QRadar Log
+// Event Extended Format (LEEF).
+package siem
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrNoMetric = errors.New("no metric in line")
+
+// TimeLayouts are the layouts of timestamps commonly used by the formats in
+// addition to milliseconds since epoch
+var TimeLayouts = []string{
+	"Jan 2 2006 15:04:05.000 MST",
+	"Jan 2 2006 15:04:05.000",
+	"Jan 2 2006 15:04:05 MST",
+	"Jan 2 2006 15:04:05",
+	"Jan 2 15:04:05.000 MST",
+	"Jan 2 15:04:05.000",
+	"Jan 2 15:04:05 MST",
+	"Jan 2 15:04:05",
+}
+
+// SplitHeader splits the header at unescaped pipe characters into at most n
+// parts with the escaping of pipes and backslashes removed from all but the
+// last part.
+func SplitHeader(s string, n int) []string {
+	parts := make([]string, 0, n)
+	var current strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			if i+1 < len(s) && (s[i+1] == '|' || s[i+1] == '\\') {
+				i++
+				current.WriteByte(s[i])
+			} else {
+				current.WriteByte(c)
+			}
+		case '|':
+			parts = append(parts, current.String())
+			current.Reset()
+			if len(parts) == n-1 {
+				return append(parts, s[i+1:])
+			}
+		default:
+			current.WriteByte(c)
+		}
+	}
+	return append(parts, current.String())
+}
+
+// Convert returns the value as integer or float if possible and as string
+// otherwise
+func Convert(value string) interface{} {
+	if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return v
+	}
+	if v, err := strconv.ParseFloat(value, 64); err == nil {
+		return v
+	}
+	return value
+}
+
+// ParseTime parses the value as milliseconds since epoch or using one of the
+// common time layouts
+func ParseTime(value string, loc *time.Location) (time.Time, error) {
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+
+	for _, layout := range TimeLayouts {
+		if t, err := ParseTimeLayout(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("unknown time format")
+}
+
+// ParseTimeLayout parses the value using the given layout. Timestamps
+// without year refer to the current one.
+func ParseTimeLayout(layout, value string, loc *time.Location) (time.Time, error) {
+	t, err := time.ParseInLocation(layout, value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if t.Year() == 0 {
+		t = t.AddDate(time.Now().In(loc).Year(), 0, 0)
+	}
+	return t, nil
+}
@@ -0,0 +1,40 @@
+package siem
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitHeader(t *testing.T) {
+	parts := SplitHeader(`0|Vendor\|Inc|Product\\|1.0|ext=a\|b`, 5)
+	require.Equal(t, []string{"0", "Vendor|Inc", `Product\`, "1.0", `ext=a\|b`}, parts)
+
+	parts = SplitHeader("0|Vendor", 5)
+	require.Equal(t, []string{"0", "Vendor"}, parts)
+}
+
+func TestConvert(t *testing.T) {
+	require.Equal(t, int64(42), Convert("42"))
+	require.Equal(t, 4.2, Convert("4.2"))
+	require.Equal(t, "text", Convert("text"))
+}
+
+func TestParseTime(t *testing.T) {
+	actual, err := ParseTime("1700000000123", time.UTC)
+	require.NoError(t, err)
+	require.Equal(t, time.UnixMilli(1700000000123), actual)
+
+	actual, err = ParseTime("Nov 14 2023 22:13:20.123", time.UTC)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2023, 11, 14, 22, 13, 20, 123000000, time.UTC), actual)
+
+	// Timestamps without year refer to the current one
+	actual, err = ParseTime("Nov 14 22:13:20", time.UTC)
+	require.NoError(t, err)
+	require.Equal(t, time.Now().UTC().Year(), actual.Year())
+
+	_, err = ParseTime("yesterday", time.UTC)
+	require.ErrorContains(t, err, "unknown time format")
+}
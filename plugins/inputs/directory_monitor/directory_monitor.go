@@ -354,17 +354,26 @@ func (monitor *DirectoryMonitor) parseFile(parser telegraf.Parser, reader io.Rea
 }
 
 func (monitor *DirectoryMonitor) parseAtOnce(parser telegraf.Parser, reader io.Reader, fileName string) error {
-	bytes, err := io.ReadAll(reader)
-	if err != nil {
+	// Parse the file incrementally if supported by the parser to avoid
+	// loading the whole file into memory
+	var count int
+	err := parsers.ParseStream(parser, reader, func(m telegraf.Metric) error {
+		count++
+		if monitor.FileTag != "" {
+			m.AddTag(monitor.FileTag, filepath.Base(fileName))
+		}
+		return monitor.sendMetrics([]telegraf.Metric{m})
+	})
+	if err != nil && !errors.Is(err, parsers.ErrEOF) {
 		return err
 	}
 
-	metrics, err := monitor.parseMetrics(parser, bytes, fileName)
-	if err != nil {
-		return err
+	if count == 0 {
+		once.Do(func() {
+			monitor.Log.Debug(internal.NoMetricsCreatedMsg)
+		})
 	}
-
-	return monitor.sendMetrics(metrics)
+	return nil
 }
 
 func (monitor *DirectoryMonitor) parseMetrics(parser telegraf.Parser, line []byte, fileName string) (metrics []telegraf.Metric, err error) {
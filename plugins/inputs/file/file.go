@@ -4,7 +4,6 @@ package file
 import (
 	_ "embed"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"sync"
@@ -16,6 +15,7 @@ import (
 	"github.com/influxdata/telegraf/internal/globpath"
 	"github.com/influxdata/telegraf/plugins/common/encoding"
 	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
 //go:embed sample.conf
@@ -55,12 +55,7 @@ func (f *File) Gather(acc telegraf.Accumulator) error {
 		return err
 	}
 	for _, k := range f.filenames {
-		metrics, err := f.readMetric(k)
-		if err != nil {
-			return err
-		}
-
-		for _, m := range metrics {
+		err := f.readMetrics(k, func(m telegraf.Metric) error {
 			if f.FileTag != "" {
 				m.AddTag(f.FileTag, filepath.Base(k))
 			}
@@ -70,6 +65,10 @@ func (f *File) Gather(acc telegraf.Accumulator) error {
 				}
 			}
 			acc.AddMetric(m)
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 	}
 	return nil
@@ -93,33 +92,37 @@ func (f *File) refreshFilePaths() error {
 	return nil
 }
 
-func (f *File) readMetric(filename string) ([]telegraf.Metric, error) {
+// readMetrics parses the given file and passes the metrics to the given
+// function. Streaming parsers parse the file incrementally to avoid loading
+// the whole file into memory, so metrics parsed before an error are passed on.
+func (f *File) readMetrics(filename string, fn func(telegraf.Metric) error) error {
 	file, err := os.Open(filename)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer file.Close()
 
 	r, _ := utfbom.Skip(f.decoder.Reader(file))
-	fileContents, err := io.ReadAll(r)
-	if err != nil {
-		return nil, fmt.Errorf("could not read %q: %w", filename, err)
-	}
 	parser, err := f.parserFunc()
 	if err != nil {
-		return nil, fmt.Errorf("could not instantiate parser: %w", err)
+		return fmt.Errorf("could not instantiate parser: %w", err)
 	}
-	metrics, err := parser.Parse(fileContents)
-	if err != nil {
-		return metrics, fmt.Errorf("could not parse %q: %w", filename, err)
+
+	var count int
+	add := func(m telegraf.Metric) error {
+		count++
+		return fn(m)
+	}
+	if err := parsers.ParseStream(parser, r, add); err != nil {
+		return fmt.Errorf("could not parse %q: %w", filename, err)
 	}
 
-	if len(metrics) == 0 {
+	if count == 0 {
 		once.Do(func() {
 			f.Log.Debug(internal.NoMetricsCreatedMsg)
 		})
 	}
-	return metrics, err
+	return nil
 }
 
 func init() {
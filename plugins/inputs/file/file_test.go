@@ -16,8 +16,10 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/models"
 	"github.com/influxdata/telegraf/plugins/parsers/csv"
 	"github.com/influxdata/telegraf/plugins/parsers/grok"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -464,3 +466,55 @@ func TestCSVBehavior(t *testing.T) {
 	actual := acc.GetTelegrafMetrics()
 	testutil.RequireMetricsEqual(t, expected, actual, options...)
 }
+
+func TestStreamingParser(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "metrics.influx")
+	require.NoError(t, os.WriteFile(fn, []byte("cpu value=1 1\ncpu value=2 2\ncpu value=3 3\n"), 0600))
+
+	plugin := &File{
+		Files: []string{fn},
+		Log:   testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	parser := models.NewRunningParser(&influx.Parser{}, &models.ParserConfig{DataFormat: "influx", Alias: "file-streaming"})
+	require.NoError(t, parser.Init())
+	plugin.SetParserFunc(func() (telegraf.Parser, error) { return parser, nil })
+
+	expected := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(0, 1)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 2.0}, time.Unix(0, 2)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 3.0}, time.Unix(0, 3)),
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Gather(&acc))
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+	require.Equal(t, int64(3), parser.MetricsParsed.Get())
+}
+
+func TestStreamingParserPartialError(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "metrics.influx")
+	require.NoError(t, os.WriteFile(fn, []byte("cpu value=1 1\ncpu value=2 2\ncpu value=\ncpu value=4 4\n"), 0600))
+
+	plugin := &File{
+		Files: []string{fn},
+		Log:   testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	parser := models.NewRunningParser(&influx.Parser{}, &models.ParserConfig{DataFormat: "influx", Alias: "file-partial"})
+	require.NoError(t, parser.Init())
+	plugin.SetParserFunc(func() (telegraf.Parser, error) { return parser, nil })
+
+	// Metrics parsed before the error are kept as they were passed on
+	// already while streaming
+	expected := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(0, 1)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 2.0}, time.Unix(0, 2)),
+	}
+
+	var acc testutil.Accumulator
+	require.ErrorContains(t, plugin.Gather(&acc), "could not parse")
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
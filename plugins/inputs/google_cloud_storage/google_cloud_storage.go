@@ -18,6 +18,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
 const (
@@ -128,26 +129,14 @@ func (gcs *GCS) processMeasurementsInObject(name string, bucket *storage.BucketH
 		return err
 	}
 
-	metrics, err := gcs.fetchedMetrics(r)
-
-	if err != nil {
-		return err
-	}
-
-	for _, metric := range metrics {
+	// Parse the object incrementally if supported by the parser to avoid
+	// loading large objects into memory. Metrics parsed before an error are
+	// kept.
+	add := func(metric telegraf.Metric) error {
 		acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
+		return nil
 	}
-
-	return nil
-}
-
-func (gcs *GCS) fetchedMetrics(r *storage.Reader) ([]telegraf.Metric, error) {
-	buf := new(bytes.Buffer)
-	if _, err := buf.ReadFrom(r); err != nil {
-		return nil, err
-	}
-
-	return gcs.parser.Parse(buf.Bytes())
+	return parsers.ParseStream(gcs.parser, r, add)
 }
 
 func (gcs *GCS) reachedThreshlod(processed int) bool {
@@ -10,10 +10,14 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	parsers_json "github.com/influxdata/telegraf/plugins/parsers/json"
 	"github.com/influxdata/telegraf/testutil"
 )
@@ -96,6 +100,48 @@ func TestRunGatherOneItem(t *testing.T) {
 	require.InEpsilon(t, -1.0975806427415925e-12, metric.Fields["fields_sine"], testutil.DefaultEpsilon)
 }
 
+func TestRunGatherStreamingPartialError(t *testing.T) {
+	singleFileList := readJSON(t, "testdata/single_file_list.json")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/b/test-iteration-bucket/o":
+			serveJSONText(w, singleFileList)
+		case "/test-iteration-bucket/1604148850990":
+			serveJSONText(w, []byte("cpu value=1 1\ncpu value=2 2\ncpu value=\ncpu value=4 4\n"))
+		default:
+			serveBlobs(t, w, r.URL.Path, "")
+		}
+	}))
+	defer srv.Close()
+
+	emulatorSetEnv(t, srv)
+
+	parser := models.NewRunningParser(&influx.Parser{}, &models.ParserConfig{DataFormat: "influx", Alias: "gcs-partial"})
+	require.NoError(t, parser.Init())
+
+	gcs := &GCS{
+		Bucket: "test-iteration-bucket",
+		Prefix: "prefix/",
+		Log:    testutil.Logger{},
+		parser: parser,
+	}
+	require.NoError(t, gcs.Init())
+
+	// Metrics parsed before the error are kept as they were passed on
+	// already while streaming the object
+	acc := &testutil.Accumulator{}
+	require.NoError(t, gcs.Gather(acc))
+	require.Len(t, acc.Errors, 1)
+	require.ErrorContains(t, acc.Errors[0], `could not process object "1604148850990"`)
+
+	expected := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(0, 1)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 2.0}, time.Unix(0, 2)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+	require.Equal(t, int64(2), parser.MetricsParsed.Get())
+}
+
 func TestRunGatherOneIteration(t *testing.T) {
 	srv := startMultipleItemGCSServer(t)
 	defer srv.Close()
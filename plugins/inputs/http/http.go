@@ -18,6 +18,7 @@ import (
 	"github.com/influxdata/telegraf/internal"
 	common_http "github.com/influxdata/telegraf/plugins/common/http"
 	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
 )
 
 //go:embed sample.conf
@@ -191,34 +192,34 @@ func (h *HTTP) gatherURL(acc telegraf.Accumulator, url string) error {
 			h.SuccessStatusCodes)
 	}
 
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("reading body failed: %w", err)
-	}
-
 	// Instantiate a new parser for the new data to avoid trouble with stateful parsers
 	parser, err := h.parserFunc()
 	if err != nil {
 		return fmt.Errorf("instantiating parser failed: %w", err)
 	}
-	metrics, err := parser.Parse(b)
-	if err != nil {
+
+	// Parse the body incrementally if supported by the parser to avoid
+	// loading large responses into memory. Metrics parsed before an error
+	// are kept.
+	var count int
+	add := func(metric telegraf.Metric) error {
+		count++
+		if !metric.HasTag("url") {
+			metric.AddTag("url", url)
+		}
+		acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
+		return nil
+	}
+	if err := parsers.ParseStream(parser, resp.Body, add); err != nil {
 		return fmt.Errorf("parsing metrics failed: %w", err)
 	}
 
-	if len(metrics) == 0 {
+	if count == 0 {
 		once.Do(func() {
 			h.Log.Debug(internal.NoMetricsCreatedMsg)
 		})
 	}
 
-	for _, metric := range metrics {
-		if !metric.HasTag("url") {
-			metric.AddTag("url", url)
-		}
-		acc.AddFields(metric.Name(), metric.Fields(), metric.Tags(), metric.Time())
-	}
-
 	return nil
 }
 
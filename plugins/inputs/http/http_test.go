@@ -19,6 +19,8 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/models"
 	common_http "github.com/influxdata/telegraf/plugins/common/http"
 	"github.com/influxdata/telegraf/plugins/common/oauth"
 	httpplugin "github.com/influxdata/telegraf/plugins/inputs/http"
@@ -169,6 +171,39 @@ func TestInvalidStatusCode(t *testing.T) {
 	require.Error(t, acc.GatherError(plugin.Gather))
 }
 
+func TestStreamingParserPartialError(t *testing.T) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if _, err := w.Write([]byte("cpu value=1 1\ncpu value=2 2\ncpu value=\ncpu value=4 4\n")); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			t.Error(err)
+		}
+	}))
+	defer fakeServer.Close()
+
+	address := fakeServer.URL + "/endpoint"
+	plugin := &httpplugin.HTTP{
+		URLs: []string{address},
+		Log:  testutil.Logger{},
+	}
+
+	parser := models.NewRunningParser(&influx.Parser{}, &models.ParserConfig{DataFormat: "influx", Alias: "http-partial"})
+	require.NoError(t, parser.Init())
+	plugin.SetParserFunc(func() (telegraf.Parser, error) { return parser, nil })
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Init())
+	require.ErrorContains(t, acc.GatherError(plugin.Gather), "parsing metrics failed")
+
+	// Metrics parsed before the error are kept as they were passed on
+	// already while streaming the response body
+	expected := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"url": address}, map[string]interface{}{"value": 1.0}, time.Unix(0, 1)),
+		metric.New("cpu", map[string]string{"url": address}, map[string]interface{}{"value": 2.0}, time.Unix(0, 2)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+	require.Equal(t, int64(2), parser.MetricsParsed.Get())
+}
+
 func TestSuccessStatusCodes(t *testing.T) {
 	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusAccepted)
@@ -18,6 +18,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	common_otel "github.com/influxdata/telegraf/plugins/common/opentelemetry"
 	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -75,7 +76,7 @@ func (o *OpenTelemetry) Start(acc telegraf.Accumulator) error {
 		grpcOptions = append(grpcOptions, grpc.MaxRecvMsgSize(int(o.MaxMsgSize)))
 	}
 
-	logger := &otelLogger{o.Log}
+	logger := &common_otel.Logger{Logger: o.Log}
 	influxWriter := &writeToAccumulator{acc}
 	o.grpcServer = grpc.NewServer(grpcOptions...)
 
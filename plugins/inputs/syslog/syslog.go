@@ -11,16 +11,14 @@ import (
 	"strings"
 	"sync"
 	"time"
-	"unicode"
 
 	"github.com/leodido/go-syslog/v4"
 	"github.com/leodido/go-syslog/v4/nontransparent"
 	"github.com/leodido/go-syslog/v4/octetcounting"
-	"github.com/leodido/go-syslog/v4/rfc3164"
-	"github.com/leodido/go-syslog/v4/rfc5424"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/common/socket"
+	common_syslog "github.com/influxdata/telegraf/plugins/common/syslog"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -132,7 +130,10 @@ func (s *Syslog) Start(acc telegraf.Accumulator) error {
 		onConnection := s.createStreamDataHandler(acc)
 		s.socket.ListenConnection(onConnection, onError)
 	case "udp", "udp4", "udp6", "ip", "ip4", "ip6", "unixgram":
-		onData := s.createDatagramDataHandler(acc)
+		onData, err := s.createDatagramDataHandler(acc)
+		if err != nil {
+			return err
+		}
 		s.socket.Listen(onData, onError)
 	default:
 		return fmt.Errorf("unknown protocol %q in %q", s.url.Scheme, s.Address)
@@ -191,23 +192,17 @@ func (s *Syslog) createStreamDataHandler(acc telegraf.Accumulator) socket.Callba
 			}
 
 			// Extract message information
-			acc.AddFields("syslog", fields(r.Message, s.Separator), tags(r.Message, addr))
+			acc.AddFields("syslog", common_syslog.Fields(r.Message, s.Separator), common_syslog.Tags(r.Message, addr))
 		})
 		parser.Parse(reader)
 	}
 }
 
-func (s *Syslog) createDatagramDataHandler(acc telegraf.Accumulator) socket.CallbackData {
+func (s *Syslog) createDatagramDataHandler(acc telegraf.Accumulator) (socket.CallbackData, error) {
 	// Create the parser depending on syslog standard and other settings
-	var parser syslog.Machine
-	switch s.SyslogStandard {
-	case "RFC3164":
-		parser = rfc3164.NewParser(rfc3164.WithYear(rfc3164.CurrentYear{}))
-	case "RFC5424":
-		parser = rfc5424.NewParser()
-	}
-	if s.BestEffort {
-		parser.WithBestEffort()
+	parser, err := common_syslog.NewMachine(s.SyslogStandard, s.BestEffort)
+	if err != nil {
+		return nil, err
 	}
 
 	// Return the OnData function
@@ -230,98 +225,8 @@ func (s *Syslog) createDatagramDataHandler(acc telegraf.Accumulator) socket.Call
 				addr = src.String()
 			}
 		}
-		acc.AddFields("syslog", fields(message, s.Separator), tags(message, addr))
-	}
-}
-
-func tags(msg syslog.Message, src string) map[string]string {
-	// Extract message information
-	tags := map[string]string{
-		"severity": *msg.SeverityShortLevel(),
-		"facility": *msg.FacilityLevel(),
-	}
-
-	if src != "" {
-		tags["source"] = src
-	}
-
-	switch msg := msg.(type) {
-	case *rfc5424.SyslogMessage:
-		if msg.Hostname != nil {
-			tags["hostname"] = *msg.Hostname
-		}
-		if msg.Appname != nil {
-			tags["appname"] = *msg.Appname
-		}
-	case *rfc3164.SyslogMessage:
-		if msg.Hostname != nil {
-			tags["hostname"] = *msg.Hostname
-		}
-		if msg.Appname != nil {
-			tags["appname"] = *msg.Appname
-		}
-	}
-
-	return tags
-}
-
-func fields(msg syslog.Message, separator string) map[string]interface{} {
-	var fields map[string]interface{}
-	switch msg := msg.(type) {
-	case *rfc5424.SyslogMessage:
-		fields = map[string]interface{}{
-			"facility_code": int(*msg.Facility),
-			"severity_code": int(*msg.Severity),
-			"version":       msg.Version,
-		}
-		if msg.Timestamp != nil {
-			fields["timestamp"] = (*msg.Timestamp).UnixNano()
-		}
-		if msg.ProcID != nil {
-			fields["procid"] = *msg.ProcID
-		}
-		if msg.MsgID != nil {
-			fields["msgid"] = *msg.MsgID
-		}
-		if msg.Message != nil {
-			fields["message"] = strings.TrimRightFunc(*msg.Message, func(r rune) bool {
-				return unicode.IsSpace(r)
-			})
-		}
-		if msg.StructuredData != nil {
-			for sdid, sdparams := range *msg.StructuredData {
-				if len(sdparams) == 0 {
-					// When SD-ID does not have params we indicate its presence with a bool
-					fields[sdid] = true
-					continue
-				}
-				for k, v := range sdparams {
-					fields[sdid+separator+k] = v
-				}
-			}
-		}
-	case *rfc3164.SyslogMessage:
-		fields = map[string]interface{}{
-			"facility_code": int(*msg.Facility),
-			"severity_code": int(*msg.Severity),
-		}
-		if msg.Timestamp != nil {
-			fields["timestamp"] = (*msg.Timestamp).UnixNano()
-		}
-		if msg.ProcID != nil {
-			fields["procid"] = *msg.ProcID
-		}
-		if msg.MsgID != nil {
-			fields["msgid"] = *msg.MsgID
-		}
-		if msg.Message != nil {
-			fields["message"] = strings.TrimRightFunc(*msg.Message, func(r rune) bool {
-				return unicode.IsSpace(r)
-			})
-		}
-	}
-
-	return fields
+		acc.AddFields("syslog", common_syslog.Fields(message, s.Separator), common_syslog.Tags(message, addr))
+	}, nil
 }
 
 func init() {
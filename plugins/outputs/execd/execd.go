@@ -78,6 +78,12 @@ func (e *Execd) Close() error {
 	return nil
 }
 
+// RequiresOrdering disables concurrent writes as all batches are written to the
+// stdin of the same process
+func (*Execd) RequiresOrdering() bool {
+	return true
+}
+
 func (e *Execd) Write(metrics []telegraf.Metric) error {
 	if e.UseBatchFormat {
 		b, err := e.serializer.SerializeBatch(metrics)
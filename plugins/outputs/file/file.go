@@ -93,6 +93,12 @@ func (f *File) Close() error {
 	return err
 }
 
+// RequiresOrdering disables concurrent writes as the writers are shared and
+// batches must be appended in order
+func (*File) RequiresOrdering() bool {
+	return true
+}
+
 func (f *File) Write(metrics []telegraf.Metric) error {
 	var writeErr error
 
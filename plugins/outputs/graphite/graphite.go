@@ -212,6 +212,12 @@ func (g *Graphite) checkEOF(conn net.Conn) error {
 
 // Choose a random server in the cluster to write to until a successful write
 // occurs, logging each unsuccessful. If all servers fail, return error.
+// RequiresOrdering disables concurrent writes as the connections are shared and
+// re-established on write
+func (*Graphite) RequiresOrdering() bool {
+	return true
+}
+
 func (g *Graphite) Write(metrics []telegraf.Metric) error {
 	// Prepare data
 	var batch []byte
@@ -20,6 +20,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
+	common_otel "github.com/influxdata/telegraf/plugins/common/opentelemetry"
 	"github.com/influxdata/telegraf/plugins/common/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
@@ -58,7 +59,7 @@ func (*OpenTelemetry) SampleConfig() string {
 }
 
 func (o *OpenTelemetry) Connect() error {
-	logger := &otelLogger{o.Log}
+	logger := &common_otel.Logger{Logger: o.Log}
 
 	if o.ServiceAddress == "" {
 		o.ServiceAddress = defaultServiceAddress
@@ -10,13 +10,12 @@ import (
 	"time"
 
 	"github.com/apache/arrow-go/v18/arrow"
-	"github.com/apache/arrow-go/v18/arrow/array"
-	"github.com/apache/arrow-go/v18/arrow/memory"
 	"github.com/apache/arrow-go/v18/parquet"
 	"github.com/apache/arrow-go/v18/parquet/pqarrow"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/plugins/common/columnar"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
 
@@ -27,7 +26,6 @@ var defaultTimestampFieldName = "timestamp"
 
 type metricGroup struct {
 	filename string
-	builder  *array.RecordBuilder
 	schema   *arrow.Schema
 	writer   *pqarrow.FileWriter
 }
@@ -38,6 +36,7 @@ type Parquet struct {
 	TimestampFieldName string          `toml:"timestamp_field_name"`
 	Log                telegraf.Logger `toml:"-"`
 
+	builder      *columnar.Builder
 	metricGroups map[string]*metricGroup
 }
 
@@ -59,6 +58,18 @@ func (p *Parquet) Init() error {
 		return fmt.Errorf("provided directory %q is not a directory", p.Directory)
 	}
 
+	// Keep the file layout of previous versions with the timestamp stored as
+	// integer in the last column
+	p.builder = &columnar.Builder{
+		TimestampColumn: p.TimestampFieldName,
+		TimestampType:   arrow.PrimitiveTypes.Int64,
+		TimestampLast:   true,
+		OmitTimestamp:   p.TimestampFieldName == "",
+	}
+	if err := p.builder.Init(); err != nil {
+		return err
+	}
+
 	p.metricGroups = make(map[string]*metricGroup)
 
 	return nil
@@ -85,6 +96,12 @@ func (p *Parquet) Close() error {
 	return nil
 }
 
+// RequiresOrdering disables concurrent writes as the file writers are shared
+// and rotated on write
+func (*Parquet) RequiresOrdering() bool {
+	return true
+}
+
 func (p *Parquet) Write(metrics []telegraf.Metric) error {
 	groupedMetrics := make(map[string][]telegraf.Metric)
 	for _, metric := range metrics {
@@ -95,7 +112,7 @@ func (p *Parquet) Write(metrics []telegraf.Metric) error {
 	for name, metrics := range groupedMetrics {
 		if _, ok := p.metricGroups[name]; !ok {
 			filename := fmt.Sprintf("%s/%s-%s-%s.parquet", p.Directory, name, now.Format("2006-01-02"), strconv.FormatInt(now.Unix(), 10))
-			schema, err := p.createSchema(metrics)
+			schema, err := p.builder.Schema(name, metrics)
 			if err != nil {
 				return fmt.Errorf("failed to create schema for file %q: %w", name, err)
 			}
@@ -104,7 +121,6 @@ func (p *Parquet) Write(metrics []telegraf.Metric) error {
 				return fmt.Errorf("failed to create writer for file %q: %w", name, err)
 			}
 			p.metricGroups[name] = &metricGroup{
-				builder:  array.NewRecordBuilder(memory.DefaultAllocator, schema),
 				filename: filename,
 				schema:   schema,
 				writer:   writer,
@@ -117,7 +133,17 @@ func (p *Parquet) Write(metrics []telegraf.Metric) error {
 			}
 		}
 
-		record, err := p.createRecord(metrics, p.metricGroups[name].builder, p.metricGroups[name].schema)
+		// Drop metrics not matching the schema of the file
+		accepted := make([]telegraf.Metric, 0, len(metrics))
+		for _, m := range metrics {
+			if err := p.builder.Check(p.metricGroups[name].schema, m); err != nil {
+				p.Log.Errorf("Dropping metric for file %q: %v", p.metricGroups[name].filename, err)
+				continue
+			}
+			accepted = append(accepted, m)
+		}
+
+		record, err := p.builder.Record(p.metricGroups[name].schema, accepted)
 		if err != nil {
 			return fmt.Errorf("failed to create record for file %q: %w", p.metricGroups[name].filename, err)
 		}
@@ -154,128 +180,6 @@ func (p *Parquet) rotateIfNeeded(name string) error {
 	return nil
 }
 
-func (p *Parquet) createRecord(metrics []telegraf.Metric, builder *array.RecordBuilder, schema *arrow.Schema) (arrow.Record, error) {
-	for index, col := range schema.Fields() {
-		for _, m := range metrics {
-			if p.TimestampFieldName != "" && col.Name == p.TimestampFieldName {
-				builder.Field(index).(*array.Int64Builder).Append(m.Time().UnixNano())
-				continue
-			}
-
-			// Try to get the value from a field first, then from a tag.
-			var value any
-			var ok bool
-			value, ok = m.GetField(col.Name)
-			if !ok {
-				value, ok = m.GetTag(col.Name)
-			}
-
-			// if neither field nor tag exists, append a null value
-			if !ok {
-				switch col.Type {
-				case arrow.PrimitiveTypes.Int8:
-					builder.Field(index).(*array.Int8Builder).AppendNull()
-				case arrow.PrimitiveTypes.Int16:
-					builder.Field(index).(*array.Int16Builder).AppendNull()
-				case arrow.PrimitiveTypes.Int32:
-					builder.Field(index).(*array.Int32Builder).AppendNull()
-				case arrow.PrimitiveTypes.Int64:
-					builder.Field(index).(*array.Int64Builder).AppendNull()
-				case arrow.PrimitiveTypes.Uint8:
-					builder.Field(index).(*array.Uint8Builder).AppendNull()
-				case arrow.PrimitiveTypes.Uint16:
-					builder.Field(index).(*array.Uint16Builder).AppendNull()
-				case arrow.PrimitiveTypes.Uint32:
-					builder.Field(index).(*array.Uint32Builder).AppendNull()
-				case arrow.PrimitiveTypes.Uint64:
-					builder.Field(index).(*array.Uint64Builder).AppendNull()
-				case arrow.PrimitiveTypes.Float32:
-					builder.Field(index).(*array.Float32Builder).AppendNull()
-				case arrow.PrimitiveTypes.Float64:
-					builder.Field(index).(*array.Float64Builder).AppendNull()
-				case arrow.BinaryTypes.String:
-					builder.Field(index).(*array.StringBuilder).AppendNull()
-				case arrow.FixedWidthTypes.Boolean:
-					builder.Field(index).(*array.BooleanBuilder).AppendNull()
-				default:
-					return nil, fmt.Errorf("unsupported type: %T", value)
-				}
-
-				continue
-			}
-
-			switch col.Type {
-			case arrow.PrimitiveTypes.Int8:
-				builder.Field(index).(*array.Int8Builder).Append(value.(int8))
-			case arrow.PrimitiveTypes.Int16:
-				builder.Field(index).(*array.Int16Builder).Append(value.(int16))
-			case arrow.PrimitiveTypes.Int32:
-				builder.Field(index).(*array.Int32Builder).Append(value.(int32))
-			case arrow.PrimitiveTypes.Int64:
-				builder.Field(index).(*array.Int64Builder).Append(value.(int64))
-			case arrow.PrimitiveTypes.Uint8:
-				builder.Field(index).(*array.Uint8Builder).Append(value.(uint8))
-			case arrow.PrimitiveTypes.Uint16:
-				builder.Field(index).(*array.Uint16Builder).Append(value.(uint16))
-			case arrow.PrimitiveTypes.Uint32:
-				builder.Field(index).(*array.Uint32Builder).Append(value.(uint32))
-			case arrow.PrimitiveTypes.Uint64:
-				builder.Field(index).(*array.Uint64Builder).Append(value.(uint64))
-			case arrow.PrimitiveTypes.Float32:
-				builder.Field(index).(*array.Float32Builder).Append(value.(float32))
-			case arrow.PrimitiveTypes.Float64:
-				builder.Field(index).(*array.Float64Builder).Append(value.(float64))
-			case arrow.BinaryTypes.String:
-				builder.Field(index).(*array.StringBuilder).Append(value.(string))
-			case arrow.FixedWidthTypes.Boolean:
-				builder.Field(index).(*array.BooleanBuilder).Append(value.(bool))
-			default:
-				return nil, fmt.Errorf("unsupported type: %T", value)
-			}
-		}
-	}
-
-	record := builder.NewRecord()
-	return record, nil
-}
-
-func (p *Parquet) createSchema(metrics []telegraf.Metric) (*arrow.Schema, error) {
-	rawFields := make(map[string]arrow.DataType, 0)
-	for _, metric := range metrics {
-		for _, field := range metric.FieldList() {
-			if _, ok := rawFields[field.Key]; !ok {
-				arrowType, err := goToArrowType(field.Value)
-				if err != nil {
-					return nil, fmt.Errorf("error converting '%s=%s' field to arrow type: %w", field.Key, field.Value, err)
-				}
-				rawFields[field.Key] = arrowType
-			}
-		}
-		for _, tag := range metric.TagList() {
-			if _, ok := rawFields[tag.Key]; !ok {
-				rawFields[tag.Key] = arrow.BinaryTypes.String
-			}
-		}
-	}
-
-	fields := make([]arrow.Field, 0)
-	for key, value := range rawFields {
-		fields = append(fields, arrow.Field{
-			Name: key,
-			Type: value,
-		})
-	}
-
-	if p.TimestampFieldName != "" {
-		fields = append(fields, arrow.Field{
-			Name: p.TimestampFieldName,
-			Type: arrow.PrimitiveTypes.Int64,
-		})
-	}
-
-	return arrow.NewSchema(fields, nil), nil
-}
-
 func (p *Parquet) createWriter(name, filename string, schema *arrow.Schema) (*pqarrow.FileWriter, error) {
 	if _, err := os.Stat(filename); err == nil {
 		now := time.Now()
@@ -297,37 +201,6 @@ func (p *Parquet) createWriter(name, filename string, schema *arrow.Schema) (*pq
 	return writer, nil
 }
 
-func goToArrowType(value interface{}) (arrow.DataType, error) {
-	switch value.(type) {
-	case int8:
-		return arrow.PrimitiveTypes.Int8, nil
-	case int16:
-		return arrow.PrimitiveTypes.Int16, nil
-	case int32:
-		return arrow.PrimitiveTypes.Int32, nil
-	case int64, int:
-		return arrow.PrimitiveTypes.Int64, nil
-	case uint8:
-		return arrow.PrimitiveTypes.Uint8, nil
-	case uint16:
-		return arrow.PrimitiveTypes.Uint16, nil
-	case uint32:
-		return arrow.PrimitiveTypes.Uint32, nil
-	case uint64, uint:
-		return arrow.PrimitiveTypes.Uint64, nil
-	case float32:
-		return arrow.PrimitiveTypes.Float32, nil
-	case float64:
-		return arrow.PrimitiveTypes.Float64, nil
-	case string:
-		return arrow.BinaryTypes.String, nil
-	case bool:
-		return arrow.FixedWidthTypes.Boolean, nil
-	default:
-		return nil, fmt.Errorf("unsupported type: %T", value)
-	}
-}
-
 func init() {
 	outputs.Add("parquet", func() telegraf.Output {
 		return &Parquet{
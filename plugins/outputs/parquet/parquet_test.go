@@ -6,7 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/apache/arrow-go/v18/arrow"
 	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
 	"github.com/stretchr/testify/require"
 
 	"github.com/influxdata/telegraf"
@@ -245,3 +247,81 @@ func TestTimestampDifferentName(t *testing.T) {
 	require.Equal(t, 1, int(metadata.NumRows))
 	require.Equal(t, 2, metadata.Schema.NumColumns())
 }
+
+func TestSchemaMismatch(t *testing.T) {
+	testDir := t.TempDir()
+	plugin := &Parquet{
+		Directory:          testDir,
+		TimestampFieldName: defaultTimestampFieldName,
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+
+	// Metrics with conflicting types must be dropped, additional fields are
+	// omitted
+	require.NoError(t, plugin.Write([]telegraf.Metric{
+		testutil.MustMetric("test", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Now()),
+	}))
+	require.NoError(t, plugin.Write([]telegraf.Metric{
+		testutil.MustMetric("test", map[string]string{}, map[string]interface{}{"value": "on"}, time.Now()),
+		testutil.MustMetric("test", map[string]string{}, map[string]interface{}{"value": int64(2), "other": 3.0}, time.Now()),
+	}))
+	require.NoError(t, plugin.Close())
+
+	files, err := os.ReadDir(testDir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	reader, err := file.OpenParquetFile(filepath.Join(testDir, files[0].Name()), false)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	metadata := reader.MetaData()
+	require.Equal(t, 2, int(metadata.NumRows))
+	require.Equal(t, 2, metadata.Schema.NumColumns())
+}
+
+func TestSchema(t *testing.T) {
+	metrics := []telegraf.Metric{
+		testutil.MustMetric(
+			"test",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"value": 1.0, "count": int64(2), "state": "ok"},
+			time.Unix(1, 0),
+		),
+	}
+
+	testDir := t.TempDir()
+	plugin := &Parquet{
+		Directory:          testDir,
+		TimestampFieldName: defaultTimestampFieldName,
+	}
+	require.NoError(t, plugin.Init())
+	require.NoError(t, plugin.Connect())
+	require.NoError(t, plugin.Write(metrics))
+	require.NoError(t, plugin.Close())
+
+	files, err := os.ReadDir(testDir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	reader, err := file.OpenParquetFile(filepath.Join(testDir, files[0].Name()), false)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	// The timestamp must be stored as integer in the last column
+	schema, err := pqarrow.FromParquet(reader.MetaData().Schema, nil, nil)
+	require.NoError(t, err)
+	expected := []arrow.Field{
+		{Name: "host", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "count", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+		{Name: "state", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "value", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+		{Name: "timestamp", Type: arrow.PrimitiveTypes.Int64},
+	}
+	require.Len(t, schema.Fields(), len(expected))
+	for i, field := range schema.Fields() {
+		require.Equal(t, expected[i].Name, field.Name)
+		require.Truef(t, arrow.TypeEqual(expected[i].Type, field.Type), "column %q: expected %s but got %s", field.Name, expected[i].Type, field.Type)
+		require.Equal(t, expected[i].Nullable, field.Nullable, field.Name)
+	}
+}
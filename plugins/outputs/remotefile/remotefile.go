@@ -172,6 +172,12 @@ func (f *File) Close() error {
 	return nil
 }
 
+// RequiresOrdering disables concurrent writes as the files are shared and
+// batches must be appended in order
+func (*File) RequiresOrdering() bool {
+	return true
+}
+
 func (f *File) Write(metrics []telegraf.Metric) error {
 	var buf bytes.Buffer
 
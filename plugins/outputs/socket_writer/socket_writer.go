@@ -131,6 +131,12 @@ func (sw *SocketWriter) setKeepAlive(c net.Conn) error {
 // Write writes the given metrics to the destination.
 // If an error is encountered, it is up to the caller to retry the same write again later.
 // Not parallel safe.
+// RequiresOrdering disables concurrent writes as the connection is shared and
+// re-established on write
+func (*SocketWriter) RequiresOrdering() bool {
+	return true
+}
+
 func (sw *SocketWriter) Write(metrics []telegraf.Metric) error {
 	if sw.Conn == nil {
 		// previous write failed with permanent error and socket was closed.
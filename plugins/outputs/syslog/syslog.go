@@ -113,6 +113,12 @@ func (s *Syslog) Close() error {
 	return err
 }
 
+// RequiresOrdering disables concurrent writes as the connection is shared and
+// re-established on write
+func (*Syslog) RequiresOrdering() bool {
+	return true
+}
+
 func (s *Syslog) Write(metrics []telegraf.Metric) (err error) {
 	if s.Conn == nil {
 		// previous write failed with permanent error and socket was closed.
@@ -0,0 +1,232 @@
+package cef
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/siem"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+var ErrNoMetric = siem.ErrNoMetric
+
+// Parser decodes ArcSight Common Event Format (CEF) messages into metrics.
+type Parser struct {
+	TagKeys     []string          `toml:"cef_tag_keys"`
+	FieldKeys   []string          `toml:"cef_field_keys"`
+	Timezone    string            `toml:"cef_timezone"`
+	DefaultTags map[string]string `toml:"-"`
+
+	metricName  string
+	tagFilter   filter.Filter
+	fieldFilter filter.Filter
+	location    *time.Location
+}
+
+func (p *Parser) Init() error {
+	var err error
+
+	if p.tagFilter, err = filter.Compile(p.TagKeys); err != nil {
+		return fmt.Errorf("error compiling tag pattern: %w", err)
+	}
+	if p.fieldFilter, err = filter.Compile(p.FieldKeys); err != nil {
+		return fmt.Errorf("error compiling field pattern: %w", err)
+	}
+
+	p.location = time.UTC
+	if p.Timezone != "" {
+		loc, err := time.LoadLocation(p.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone: %w", err)
+		}
+		p.location = loc
+	}
+
+	return nil
+}
+
+// Parse converts a slice of bytes containing one CEF message per line to
+// metrics.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		m, err := p.parseMessage(line)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+// ParseLine converts a single CEF message to a metric.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil, ErrNoMetric
+	}
+	return p.parseMessage(line)
+}
+
+// SetDefaultTags adds tags to the metrics outputs of Parse and ParseLine.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) parseMessage(line string) (telegraf.Metric, error) {
+	// Skip any prefix like a syslog header in front of the message
+	start := strings.Index(line, "CEF:")
+	if start < 0 {
+		return nil, errors.New("no CEF header found")
+	}
+
+	// The header consists of seven pipe-separated fields followed by the
+	// extension
+	header := siem.SplitHeader(line[start+len("CEF:"):], 8)
+	if len(header) < 8 {
+		return nil, fmt.Errorf("invalid CEF header, expected 7 fields but got %d", len(header)-1)
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(header[0]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CEF version %q: %w", header[0], err)
+	}
+
+	tags := map[string]string{
+		"device_vendor":         header[1],
+		"device_product":        header[2],
+		"device_version":        header[3],
+		"device_event_class_id": header[4],
+		"severity":              header[6],
+	}
+	fields := map[string]interface{}{
+		"version": version,
+		"name":    header[5],
+	}
+
+	timestamp := time.Now()
+	for _, kv := range parseExtension(header[7]) {
+		if kv.key == "rt" {
+			t, err := siem.ParseTime(kv.value, p.location)
+			if err != nil {
+				return nil, fmt.Errorf("parsing receipt time %q failed: %w", kv.value, err)
+			}
+			timestamp = t
+			continue
+		}
+
+		if p.tagFilter != nil && p.tagFilter.Match(kv.key) {
+			tags[kv.key] = kv.value
+			continue
+		}
+		if p.fieldFilter != nil && !p.fieldFilter.Match(kv.key) {
+			continue
+		}
+		fields[kv.key] = siem.Convert(kv.value)
+	}
+
+	for k, v := range p.DefaultTags {
+		if _, found := tags[k]; !found {
+			tags[k] = v
+		}
+	}
+
+	return metric.New(p.metricName, tags, fields, timestamp), nil
+}
+
+type keyValue struct {
+	key   string
+	value string
+}
+
+// parseExtension splits the space-separated key-value pairs of the
+// extension. Values may contain spaces so a value only ends where the next
+// key starts, i.e. at a space followed by a key and an unescaped equal sign.
+func parseExtension(s string) []keyValue {
+	type span struct{ start, end int }
+	keys := make([]span, 0)
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] != '=' {
+			continue
+		}
+		start := i
+		for start > 0 && isKeyChar(s[start-1]) {
+			start--
+		}
+		if start == i || (start > 0 && s[start-1] != ' ') {
+			continue
+		}
+		keys = append(keys, span{start, i})
+	}
+
+	result := make([]keyValue, 0, len(keys))
+	for j, k := range keys {
+		end := len(s)
+		if j+1 < len(keys) {
+			end = keys[j+1].start
+		}
+		value := strings.TrimRight(s[k.end+1:end], " ")
+		result = append(result, keyValue{key: s[k.start:k.end], value: unescapeValue(value)})
+	}
+	return result
+}
+
+func isKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '-'
+}
+
+func unescapeValue(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case '=', '\\':
+			b.WriteByte(s[i])
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func init() {
+	parsers.Add("cef",
+		func(defaultMetricName string) telegraf.Parser {
+			return &Parser{metricName: defaultMetricName}
+		},
+	)
+}
@@ -0,0 +1,283 @@
+package cef
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		tagKeys  []string
+		fieldKey []string
+		input    string
+		expected []telegraf.Metric
+	}{
+		{
+			name:  "header only",
+			input: "CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|",
+			expected: []telegraf.Metric{
+				metric.New(
+					"cef",
+					map[string]string{
+						"device_vendor":         "Security",
+						"device_product":        "threatmanager",
+						"device_version":        "1.0",
+						"device_event_class_id": "100",
+						"severity":              "10",
+					},
+					map[string]interface{}{
+						"version": 0,
+						"name":    "worm successfully stopped",
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name:  "extension",
+			input: "CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1 dst=2.1.2.2 spt=1232 msg=Detected a threat. No action needed.",
+			expected: []telegraf.Metric{
+				metric.New(
+					"cef",
+					map[string]string{
+						"device_vendor":         "Security",
+						"device_product":        "threatmanager",
+						"device_version":        "1.0",
+						"device_event_class_id": "100",
+						"severity":              "10",
+					},
+					map[string]interface{}{
+						"version": 0,
+						"name":    "worm successfully stopped",
+						"src":     "10.0.0.1",
+						"dst":     "2.1.2.2",
+						"spt":     int64(1232),
+						"msg":     "Detected a threat. No action needed.",
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "escaping",
+			input: `CEF:0|security|threat\|manager|1.0|100|detected a \\ in packet|High|` +
+				`act=blocked a \= and a \\ request=http://example.com/?a=b msg=line1\nline2`,
+			expected: []telegraf.Metric{
+				metric.New(
+					"cef",
+					map[string]string{
+						"device_vendor":         "security",
+						"device_product":        "threat|manager",
+						"device_version":        "1.0",
+						"device_event_class_id": "100",
+						"severity":              "High",
+					},
+					map[string]interface{}{
+						"version": 0,
+						"name":    `detected a \ in packet`,
+						"act":     `blocked a = and a \`,
+						"request": "http://example.com/?a=b",
+						"msg":     "line1\nline2",
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name:  "syslog prefix",
+			input: "<134>Feb 14 19:04:54 www.example.com CEF:1|Vendor|Product|2.0|login|User login|3|suser=admin",
+			expected: []telegraf.Metric{
+				metric.New(
+					"cef",
+					map[string]string{
+						"device_vendor":         "Vendor",
+						"device_product":        "Product",
+						"device_version":        "2.0",
+						"device_event_class_id": "login",
+						"severity":              "3",
+					},
+					map[string]interface{}{
+						"version": 1,
+						"name":    "User login",
+						"suser":   "admin",
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name:     "tag and field keys",
+			tagKeys:  []string{"src", "d*"},
+			fieldKey: []string{"spt"},
+			input:    "CEF:0|Vendor|Product|1.0|100|name|5|src=10.0.0.1 dst=2.1.2.2 spt=1232 dpt=80 msg=ignored",
+			expected: []telegraf.Metric{
+				metric.New(
+					"cef",
+					map[string]string{
+						"device_vendor":         "Vendor",
+						"device_product":        "Product",
+						"device_version":        "1.0",
+						"device_event_class_id": "100",
+						"severity":              "5",
+						"src":                   "10.0.0.1",
+						"dst":                   "2.1.2.2",
+						"dpt":                   "80",
+					},
+					map[string]interface{}{
+						"version": 0,
+						"name":    "name",
+						"spt":     int64(1232),
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "multiple lines",
+			input: "CEF:0|Vendor|Product|1.0|100|first|1|cnt=1\r\n\n" +
+				"CEF:0|Vendor|Product|1.0|101|second|2|cnt=2.5\n",
+			expected: []telegraf.Metric{
+				metric.New(
+					"cef",
+					map[string]string{
+						"device_vendor":         "Vendor",
+						"device_product":        "Product",
+						"device_version":        "1.0",
+						"device_event_class_id": "100",
+						"severity":              "1",
+					},
+					map[string]interface{}{
+						"version": 0,
+						"name":    "first",
+						"cnt":     int64(1),
+					},
+					time.Unix(0, 0),
+				),
+				metric.New(
+					"cef",
+					map[string]string{
+						"device_vendor":         "Vendor",
+						"device_product":        "Product",
+						"device_version":        "1.0",
+						"device_event_class_id": "101",
+						"severity":              "2",
+					},
+					map[string]interface{}{
+						"version": 0,
+						"name":    "second",
+						"cnt":     2.5,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{
+				TagKeys:    tt.tagKeys,
+				FieldKeys:  tt.fieldKey,
+				metricName: "cef",
+			}
+			require.NoError(t, parser.Init())
+
+			actual, err := parser.Parse([]byte(tt.input))
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.expected, actual, testutil.IgnoreTime())
+		})
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		name     string
+		timezone string
+		rt       string
+		expected time.Time
+	}{
+		{
+			name:     "epoch milliseconds",
+			rt:       "1234567890123",
+			expected: time.UnixMilli(1234567890123),
+		},
+		{
+			name:     "date with milliseconds and zone",
+			rt:       "Feb 13 2009 23:31:30.123 UTC",
+			expected: time.UnixMilli(1234567890123),
+		},
+		{
+			name:     "date in UTC",
+			rt:       "Feb 13 2009 23:31:30",
+			expected: time.Unix(1234567890, 0),
+		},
+		{
+			name:     "date with timezone setting",
+			timezone: "Europe/Berlin",
+			rt:       "Feb 14 2009 00:31:30",
+			expected: time.Unix(1234567890, 0),
+		},
+		{
+			name:     "date without year",
+			rt:       "Jan 2 15:04:05",
+			expected: time.Date(time.Now().UTC().Year(), time.January, 2, 15, 4, 5, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{
+				Timezone:   tt.timezone,
+				metricName: "cef",
+			}
+			require.NoError(t, parser.Init())
+
+			m, err := parser.ParseLine("CEF:0|Vendor|Product|1.0|100|name|5|rt=" + tt.rt + " cnt=1")
+			require.NoError(t, err)
+			require.Equal(t, tt.expected.UnixNano(), m.Time().UnixNano())
+			require.NotContains(t, m.Fields(), "rt")
+			require.Contains(t, m.Fields(), "cnt")
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	parser := &Parser{metricName: "cef"}
+	require.NoError(t, parser.Init())
+
+	_, err := parser.ParseLine("LEEF:1.0|Vendor|Product|1.0|100|")
+	require.ErrorContains(t, err, "no CEF header found")
+
+	_, err = parser.ParseLine("CEF:0|Vendor|Product|1.0|100")
+	require.ErrorContains(t, err, "invalid CEF header")
+
+	_, err = parser.ParseLine("CEF:x|Vendor|Product|1.0|100|name|5|")
+	require.ErrorContains(t, err, "invalid CEF version")
+
+	_, err = parser.ParseLine("CEF:0|Vendor|Product|1.0|100|name|5|rt=yesterday")
+	require.ErrorContains(t, err, "parsing receipt time")
+
+	_, err = parser.ParseLine("")
+	require.ErrorIs(t, err, ErrNoMetric)
+}
+
+func TestParseDefaultTags(t *testing.T) {
+	parser := &Parser{metricName: "cef"}
+	require.NoError(t, parser.Init())
+	parser.SetDefaultTags(map[string]string{
+		"host":     "localhost",
+		"severity": "ignored",
+	})
+
+	m, err := parser.ParseLine("CEF:0|Vendor|Product|1.0|100|name|5|")
+	require.NoError(t, err)
+	require.Equal(t, "localhost", m.Tags()["host"])
+	require.Equal(t, "5", m.Tags()["severity"])
+}
@@ -264,7 +264,77 @@ func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 	return nil, nil
 }
 
+// ParseStream parses the records incrementally from the reader. Data with
+// an invalid delimiter is read at once.
+func (p *Parser) ParseStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	if p.invalidDelimiter {
+		return telegraf.ParseAll(p, r, fn)
+	}
+
+	// Reset the parser according to the specified mode
+	if p.ResetMode == "always" {
+		p.Reset()
+	}
+
+	csvReader, err := p.readHeader(r)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return parsers.ErrEOF
+		}
+		return err
+	}
+
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		m, err := p.parseRecord(record)
+		if err != nil {
+			if p.SkipErrors {
+				p.Log.Debugf("Parsing error: %v", err)
+				continue
+			}
+			return err
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+}
+
 func parseCSV(p *Parser, r io.Reader) ([]telegraf.Metric, error) {
+	csvReader, err := p.readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := make([]telegraf.Metric, 0)
+	for _, record := range table {
+		m, err := p.parseRecord(record)
+		if err != nil {
+			if p.SkipErrors {
+				p.Log.Debugf("Parsing error: %v", err)
+				continue
+			}
+			return metrics, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// readHeader skips the configured rows and reads the metadata and header
+// rows returning a reader for the remaining records
+func (p *Parser) readHeader(r io.Reader) (*csv.Reader, error) {
 	lineReader := bufio.NewReader(r)
 	// skip first rows
 	for p.remainingSkipRows > 0 {
@@ -320,24 +390,7 @@ func parseCSV(p *Parser, r io.Reader) ([]telegraf.Metric, error) {
 		p.gotColumnNames = true
 	}
 
-	table, err := csvReader.ReadAll()
-	if err != nil {
-		return nil, err
-	}
-
-	metrics := make([]telegraf.Metric, 0)
-	for _, record := range table {
-		m, err := p.parseRecord(record)
-		if err != nil {
-			if p.SkipErrors {
-				p.Log.Debugf("Parsing error: %v", err)
-				continue
-			}
-			return metrics, err
-		}
-		metrics = append(metrics, m)
-	}
-	return metrics, nil
+	return csvReader, nil
 }
 
 func (p *Parser) parseRecord(record []string) (telegraf.Metric, error) {
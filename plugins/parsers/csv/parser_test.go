@@ -1031,6 +1031,87 @@ timestamp,type,name,status
 	require.Equal(t, expectedTags[1], m.Tags())
 }
 
+func TestParseStreamReader(t *testing.T) {
+	testCSV := `garbage nonsense that needs be skipped
+
+# version= 1.0
+
+    invalid meta data that can be ignored.
+file created: 2021-10-08T12:34:18+10:00
+timestamp,type,name,status
+2020-11-23T08:19:27+10:00,Reader,R002,1
+#2020-11-04T13:23:04+10:00,Reader,R031,0
+2020-11-04T13:29:47+10:00,Coordinator,C001,0
+2020-11-04T13:31:12+10:00,Coordinator,C002,1`
+
+	newParser := func() *Parser {
+		p := &Parser{
+			HeaderRowCount:     1,
+			SkipRows:           2,
+			MetadataRows:       4,
+			Comment:            "#",
+			TagColumns:         []string{"type"},
+			MetadataSeparators: []string{":", "="},
+			MetadataTrimSet:    " #",
+			TimeFunc:           DefaultTime,
+		}
+		require.NoError(t, p.Init())
+		p.SetDefaultTags(map[string]string{"test": "tag"})
+		return p
+	}
+
+	// Use the result of parsing the data at once as reference
+	expected, err := newParser().Parse([]byte(testCSV))
+	require.NoError(t, err)
+	require.Len(t, expected, 3)
+
+	var actual []telegraf.Metric
+	err = newParser().ParseStream(strings.NewReader(testCSV), func(m telegraf.Metric) error {
+		actual = append(actual, m)
+		return nil
+	})
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestParseStreamReaderErrors(t *testing.T) {
+	p := &Parser{
+		HeaderRowCount: 1,
+		ColumnTypes:    []string{"string", "int"},
+		TimeFunc:       DefaultTime,
+	}
+	require.NoError(t, p.Init())
+
+	// Empty data
+	err := p.ParseStream(strings.NewReader(""), func(telegraf.Metric) error { return nil })
+	require.ErrorIs(t, err, parsers.ErrEOF)
+
+	// Metrics before the invalid record are passed on
+	p.Reset()
+	var actual []telegraf.Metric
+	err = p.ParseStream(strings.NewReader("name,value\na,1\nb,foo\nc,3"), func(m telegraf.Metric) error {
+		actual = append(actual, m)
+		return nil
+	})
+	require.ErrorContains(t, err, "invalid syntax")
+	expected := []telegraf.Metric{
+		metric.New("", map[string]string{}, map[string]interface{}{"name": "a", "value": int64(1)}, DefaultTime()),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+
+	// Invalid records are skipped if configured
+	p.Reset()
+	p.SkipErrors = true
+	p.Log = testutil.Logger{}
+	actual = nil
+	err = p.ParseStream(strings.NewReader("name,value\na,1\nb,foo\nc,3"), func(m telegraf.Metric) error {
+		actual = append(actual, m)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, actual, 2)
+}
+
 func TestOverwriteDefaultTagsAndMetaDataTags(t *testing.T) {
 	csv := []byte(`second=orange
 fourth=plain
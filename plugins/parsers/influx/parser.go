@@ -114,6 +114,35 @@ func (p *Parser) Parse(input []byte) ([]telegraf.Metric, error) {
 	return metrics, nil
 }
 
+// ParseStream parses the line protocol incrementally from the reader. Series
+// parsers read all data at once.
+func (p *Parser) ParseStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	if p.Type == "series" {
+		return telegraf.ParseAll(p, r, fn)
+	}
+
+	sp := NewStreamParser(r)
+	sp.SetTimeFunc(p.handler.timeFunc)
+	sp.SetTimePrecision(p.handler.timePrecision)
+	for {
+		m, err := sp.Next()
+		if errors.Is(err, EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			continue
+		}
+
+		p.applyDefaultTagsSingle(m)
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+}
+
 func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 	metrics, err := p.Parse([]byte(line))
 	if err != nil {
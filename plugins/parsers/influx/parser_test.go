@@ -752,6 +752,56 @@ func TestStreamParser(t *testing.T) {
 	}
 }
 
+func TestParseStream(t *testing.T) {
+	for _, tt := range ptests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := Parser{}
+			require.NoError(t, parser.Init())
+			parser.SetTimeFunc(DefaultTime)
+			if tt.timeFunc != nil {
+				parser.SetTimeFunc(tt.timeFunc)
+			}
+
+			var actual []telegraf.Metric
+			err := parser.ParseStream(bytes.NewReader(tt.input), func(m telegraf.Metric) error {
+				actual = append(actual, m)
+				return nil
+			})
+			if tt.err != nil {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.metrics, actual)
+		})
+	}
+}
+
+func TestParseStreamDefaultTags(t *testing.T) {
+	parser := Parser{}
+	require.NoError(t, parser.Init())
+	parser.SetDefaultTags(map[string]string{"host": "localhost", "region": "eu"})
+
+	input := "cpu,host=server01 value=42 0\nmem value=23 0\ndisk value=1 0\n"
+	expected := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "server01", "region": "eu"}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0)),
+		metric.New("mem", map[string]string{"host": "localhost", "region": "eu"}, map[string]interface{}{"value": 23.0}, time.Unix(0, 0)),
+	}
+
+	// Stop parsing after the second metric
+	errStop := errors.New("stop")
+	var actual []telegraf.Metric
+	err := parser.ParseStream(strings.NewReader(input), func(m telegraf.Metric) error {
+		actual = append(actual, m)
+		if len(actual) == 2 {
+			return errStop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, errStop)
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
 func TestSeriesParser(t *testing.T) {
 	var tests = []struct {
 		name     string
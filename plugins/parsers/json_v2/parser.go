@@ -1,6 +1,7 @@
 package json_v2
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -131,6 +132,35 @@ func (p *Parser) Parse(input []byte) ([]telegraf.Metric, error) {
 	return p.parseCriticalPath(input)
 }
 
+// ParseStream parses the top-level JSON values of the reader one by one,
+// e.g. the documents of a newline delimited JSON stream, as the queries may
+// reference any part of a document. Each document is read into memory
+// completely, so only streams of multiple documents are processed with
+// bounded memory but not a single large array or object.
+func (p *Parser) ParseStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	body, _ := utfbom.Skip(r)
+	decoder := json.NewDecoder(body)
+	for {
+		var doc json.RawMessage
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("invalid JSON provided, unable to parse: %w", err)
+		}
+
+		metrics, err := p.Parse(doc)
+		if err != nil {
+			return err
+		}
+		for _, m := range metrics {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 func (p *Parser) parseCriticalPath(input []byte) ([]telegraf.Metric, error) {
 	p.parseMutex.Lock()
 	defer p.parseMutex.Unlock()
@@ -12,6 +12,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/inputs/file"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
@@ -105,6 +106,54 @@ func TestParserEmptyConfig(t *testing.T) {
 	require.ErrorContains(t, plugin.Init(), "no configuration provided")
 }
 
+func TestParseStream(t *testing.T) {
+	plugin := &json_v2.Parser{
+		Configs: []json_v2.Config{
+			{
+				MeasurementName: "sensors",
+				TimestampPath:   "time",
+				TimestampFormat: "unix",
+				JSONObjects: []json_v2.Object{
+					{
+						Path: "readings",
+						Tags: []string{"name"},
+					},
+				},
+			},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	plugin.SetDefaultTags(map[string]string{"host": "localhost"})
+
+	// Newline delimited JSON documents are parsed one by one
+	input := `{"time": 1700000000, "readings": [{"name": "a", "value": 1}, {"name": "b", "value": 2}]}
+{"time": 1700000010, "readings": [{"name": "a", "value": 3}]}
+`
+	expected := []telegraf.Metric{
+		metric.New("sensors", map[string]string{"host": "localhost", "name": "a"}, map[string]interface{}{"value": 1.0}, time.Unix(1700000000, 0)),
+		metric.New("sensors", map[string]string{"host": "localhost", "name": "b"}, map[string]interface{}{"value": 2.0}, time.Unix(1700000000, 0)),
+		metric.New("sensors", map[string]string{"host": "localhost", "name": "a"}, map[string]interface{}{"value": 3.0}, time.Unix(1700000010, 0)),
+	}
+
+	var actual []telegraf.Metric
+	err := plugin.ParseStream(strings.NewReader(input), func(m telegraf.Metric) error {
+		actual = append(actual, m)
+		return nil
+	})
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, actual)
+
+	// Invalid documents stop the parsing
+	actual = nil
+	err = plugin.ParseStream(strings.NewReader(input+`{"time": `), func(m telegraf.Metric) error {
+		actual = append(actual, m)
+		return nil
+	})
+	require.ErrorContains(t, err, "invalid JSON provided")
+	require.Len(t, actual, 3)
+}
+
 func BenchmarkParsingSequential(b *testing.B) {
 	inputFilename := filepath.Join("testdata", "benchmark", "input.json")
 
@@ -0,0 +1,323 @@
+package leef
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/siem"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+var ErrNoMetric = siem.ErrNoMetric
+
+// Parser decodes IBM QRadar Log Event Extended Format (LEEF) messages into
+// metrics.
+type Parser struct {
+	TagKeys     []string          `toml:"leef_tag_keys"`
+	FieldKeys   []string          `toml:"leef_field_keys"`
+	Timezone    string            `toml:"leef_timezone"`
+	DefaultTags map[string]string `toml:"-"`
+
+	metricName  string
+	tagFilter   filter.Filter
+	fieldFilter filter.Filter
+	location    *time.Location
+}
+
+func (p *Parser) Init() error {
+	var err error
+
+	if p.tagFilter, err = filter.Compile(p.TagKeys); err != nil {
+		return fmt.Errorf("error compiling tag pattern: %w", err)
+	}
+	if p.fieldFilter, err = filter.Compile(p.FieldKeys); err != nil {
+		return fmt.Errorf("error compiling field pattern: %w", err)
+	}
+
+	p.location = time.UTC
+	if p.Timezone != "" {
+		loc, err := time.LoadLocation(p.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone: %w", err)
+		}
+		p.location = loc
+	}
+
+	return nil
+}
+
+// Parse converts a slice of bytes containing one LEEF message per line to
+// metrics.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		// Do not trim tabs as those are the default attribute delimiter
+		line := strings.Trim(scanner.Text(), " \r")
+		if line == "" {
+			continue
+		}
+		m, err := p.parseMessage(line)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+// ParseLine converts a single LEEF message to a metric.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	line = strings.Trim(line, " \r\n")
+	if line == "" {
+		return nil, ErrNoMetric
+	}
+	return p.parseMessage(line)
+}
+
+// SetDefaultTags adds tags to the metrics outputs of Parse and ParseLine.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) parseMessage(line string) (telegraf.Metric, error) {
+	// Skip any prefix like a syslog header in front of the message
+	start := strings.Index(line, "LEEF:")
+	if start < 0 {
+		return nil, errors.New("no LEEF header found")
+	}
+	line = line[start+len("LEEF:"):]
+
+	// Version 1.0 has five header fields followed by the tab-separated
+	// attributes while version 2.0 adds the attribute delimiter as sixth
+	// header field.
+	version, _, _ := strings.Cut(line, "|")
+	version = strings.TrimSpace(version)
+	var header []string
+	delimiter := "\t"
+	switch {
+	case strings.HasPrefix(version, "1."):
+		header = siem.SplitHeader(line, 6)
+		if len(header) < 6 {
+			return nil, fmt.Errorf("invalid LEEF header, expected 5 fields but got %d", len(header)-1)
+		}
+	case strings.HasPrefix(version, "2."):
+		header = siem.SplitHeader(line, 7)
+		if len(header) < 7 {
+			return nil, fmt.Errorf("invalid LEEF header, expected 6 fields but got %d", len(header)-1)
+		}
+		d, err := parseDelimiter(header[5])
+		if err != nil {
+			return nil, err
+		}
+		delimiter = d
+	default:
+		return nil, fmt.Errorf("unsupported LEEF version %q", version)
+	}
+
+	tags := map[string]string{
+		"device_vendor":  header[1],
+		"device_product": header[2],
+		"device_version": header[3],
+		"event_id":       header[4],
+	}
+	fields := map[string]interface{}{
+		"version": version,
+	}
+
+	attributes := make(map[string]string)
+	for _, attr := range strings.Split(header[len(header)-1], delimiter) {
+		k, v, found := strings.Cut(attr, "=")
+		k = strings.TrimSpace(k)
+		if !found || k == "" {
+			continue
+		}
+		attributes[k] = v
+	}
+
+	timestamp := time.Now()
+	if v, found := attributes["devTime"]; found {
+		t, err := parseTime(v, attributes["devTimeFormat"], p.location)
+		if err != nil {
+			return nil, fmt.Errorf("parsing device time %q failed: %w", v, err)
+		}
+		timestamp = t
+		delete(attributes, "devTime")
+		delete(attributes, "devTimeFormat")
+	}
+
+	for k, v := range attributes {
+		if p.tagFilter != nil && p.tagFilter.Match(k) {
+			tags[k] = v
+			continue
+		}
+		if p.fieldFilter != nil && !p.fieldFilter.Match(k) {
+			continue
+		}
+		fields[k] = siem.Convert(v)
+	}
+
+	for k, v := range p.DefaultTags {
+		if _, found := tags[k]; !found {
+			tags[k] = v
+		}
+	}
+
+	return metric.New(p.metricName, tags, fields, timestamp), nil
+}
+
+// parseDelimiter decodes the attribute delimiter of LEEF 2.0 which is either
+// a single character or its hex representation like "x09" or "0x09".
+func parseDelimiter(s string) (string, error) {
+	switch {
+	case s == "":
+		return "\t", nil
+	case len(s) == 1:
+		return s, nil
+	}
+
+	hex := strings.ToLower(s)
+	switch {
+	case strings.HasPrefix(hex, "0x"):
+		hex = hex[2:]
+	case strings.HasPrefix(hex, "x"):
+		hex = hex[1:]
+	default:
+		return "", fmt.Errorf("invalid attribute delimiter %q", s)
+	}
+	c, err := strconv.ParseUint(hex, 16, 8)
+	if err != nil {
+		return "", fmt.Errorf("invalid attribute delimiter %q: %w", s, err)
+	}
+	return string(rune(c)), nil
+}
+
+func parseTime(value, format string, loc *time.Location) (time.Time, error) {
+	if format == "" {
+		return siem.ParseTime(value, loc)
+	}
+	return siem.ParseTimeLayout(convertJavaLayout(format), value, loc)
+}
+
+// convertJavaLayout converts the Java SimpleDateFormat pattern used in the
+// "devTimeFormat" attribute to the corresponding Go time layout. Unknown
+// pattern letters are kept as they are.
+func convertJavaLayout(format string) string {
+	var b strings.Builder
+	for i := 0; i < len(format); {
+		c := format[i]
+
+		// Quoted literal text
+		if c == '\'' {
+			end := strings.IndexByte(format[i+1:], '\'')
+			if end < 0 {
+				b.WriteString(format[i+1:])
+				break
+			}
+			b.WriteString(format[i+1 : i+1+end])
+			i += end + 2
+			continue
+		}
+
+		// Determine the run of the same pattern letter
+		n := 1
+		for i+n < len(format) && format[i+n] == c {
+			n++
+		}
+		token := format[i : i+n]
+		i += n
+
+		switch c {
+		case 'y':
+			if n == 2 {
+				b.WriteString("06")
+			} else {
+				b.WriteString("2006")
+			}
+		case 'M':
+			switch n {
+			case 1:
+				b.WriteString("1")
+			case 2:
+				b.WriteString("01")
+			case 3:
+				b.WriteString("Jan")
+			default:
+				b.WriteString("January")
+			}
+		case 'd':
+			if n == 1 {
+				b.WriteString("2")
+			} else {
+				b.WriteString("02")
+			}
+		case 'E':
+			if n <= 3 {
+				b.WriteString("Mon")
+			} else {
+				b.WriteString("Monday")
+			}
+		case 'H':
+			b.WriteString("15")
+		case 'h':
+			if n == 1 {
+				b.WriteString("3")
+			} else {
+				b.WriteString("03")
+			}
+		case 'm':
+			if n == 1 {
+				b.WriteString("4")
+			} else {
+				b.WriteString("04")
+			}
+		case 's':
+			if n == 1 {
+				b.WriteString("5")
+			} else {
+				b.WriteString("05")
+			}
+		case 'S':
+			b.WriteString(strings.Repeat("0", n))
+		case 'a':
+			b.WriteString("PM")
+		case 'z':
+			b.WriteString("MST")
+		case 'Z':
+			b.WriteString("-0700")
+		case 'X':
+			switch n {
+			case 1:
+				b.WriteString("Z07")
+			case 2:
+				b.WriteString("Z0700")
+			default:
+				b.WriteString("Z07:00")
+			}
+		default:
+			b.WriteString(token)
+		}
+	}
+	return b.String()
+}
+
+func init() {
+	parsers.Add("leef",
+		func(defaultMetricName string) telegraf.Parser {
+			return &Parser{metricName: defaultMetricName}
+		},
+	)
+}
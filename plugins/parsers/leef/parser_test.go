@@ -0,0 +1,280 @@
+package leef
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		tagKeys   []string
+		fieldKeys []string
+		input     string
+		expected  []telegraf.Metric
+	}{
+		{
+			name:  "version 1.0",
+			input: "LEEF:1.0|Microsoft|MSExchange|4.0 SP1|15345|src=192.0.2.0\tdst=172.50.123.1\tsev=5\tcat=anomaly\tmsg=this is a message",
+			expected: []telegraf.Metric{
+				metric.New(
+					"leef",
+					map[string]string{
+						"device_vendor":  "Microsoft",
+						"device_product": "MSExchange",
+						"device_version": "4.0 SP1",
+						"event_id":       "15345",
+					},
+					map[string]interface{}{
+						"version": "1.0",
+						"src":     "192.0.2.0",
+						"dst":     "172.50.123.1",
+						"sev":     int64(5),
+						"cat":     "anomaly",
+						"msg":     "this is a message",
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name:  "version 2.0 with character delimiter",
+			input: "LEEF:2.0|Lancope|StealthWatch|1.0|41|^|src=10.0.1.8^dst=10.0.0.5^sev=5^srcPort=81^dstPort=21",
+			expected: []telegraf.Metric{
+				metric.New(
+					"leef",
+					map[string]string{
+						"device_vendor":  "Lancope",
+						"device_product": "StealthWatch",
+						"device_version": "1.0",
+						"event_id":       "41",
+					},
+					map[string]interface{}{
+						"version": "2.0",
+						"src":     "10.0.1.8",
+						"dst":     "10.0.0.5",
+						"sev":     int64(5),
+						"srcPort": int64(81),
+						"dstPort": int64(21),
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name:  "version 2.0 with hex delimiter",
+			input: "LEEF:2.0|Vendor|Product|1.0|login|0x7c|usrName=admin|role=a=b",
+			expected: []telegraf.Metric{
+				metric.New(
+					"leef",
+					map[string]string{
+						"device_vendor":  "Vendor",
+						"device_product": "Product",
+						"device_version": "1.0",
+						"event_id":       "login",
+					},
+					map[string]interface{}{
+						"version": "2.0",
+						"usrName": "admin",
+						"role":    "a=b",
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name:  "syslog prefix and escaped header",
+			input: "<13>Jan 18 11:07:53 192.168.1.1 LEEF:1.0|Vendor|Pro\\|duct|1.0|100|cat=test",
+			expected: []telegraf.Metric{
+				metric.New(
+					"leef",
+					map[string]string{
+						"device_vendor":  "Vendor",
+						"device_product": "Pro|duct",
+						"device_version": "1.0",
+						"event_id":       "100",
+					},
+					map[string]interface{}{
+						"version": "1.0",
+						"cat":     "test",
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name:      "tag and field keys",
+			tagKeys:   []string{"cat", "src*"},
+			fieldKeys: []string{"sev"},
+			input:     "LEEF:1.0|Vendor|Product|1.0|100|cat=anomaly\tsrc=10.0.0.1\tsrcPort=81\tsev=5\tmsg=ignored",
+			expected: []telegraf.Metric{
+				metric.New(
+					"leef",
+					map[string]string{
+						"device_vendor":  "Vendor",
+						"device_product": "Product",
+						"device_version": "1.0",
+						"event_id":       "100",
+						"cat":            "anomaly",
+						"src":            "10.0.0.1",
+						"srcPort":        "81",
+					},
+					map[string]interface{}{
+						"version": "1.0",
+						"sev":     int64(5),
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "multiple lines",
+			input: "LEEF:1.0|Vendor|Product|1.0|100|cnt=1\r\n\n" +
+				"LEEF:1.0|Vendor|Product|1.0|101|cnt=2.5\n",
+			expected: []telegraf.Metric{
+				metric.New(
+					"leef",
+					map[string]string{
+						"device_vendor":  "Vendor",
+						"device_product": "Product",
+						"device_version": "1.0",
+						"event_id":       "100",
+					},
+					map[string]interface{}{
+						"version": "1.0",
+						"cnt":     int64(1),
+					},
+					time.Unix(0, 0),
+				),
+				metric.New(
+					"leef",
+					map[string]string{
+						"device_vendor":  "Vendor",
+						"device_product": "Product",
+						"device_version": "1.0",
+						"event_id":       "101",
+					},
+					map[string]interface{}{
+						"version": "1.0",
+						"cnt":     2.5,
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{
+				TagKeys:    tt.tagKeys,
+				FieldKeys:  tt.fieldKeys,
+				metricName: "leef",
+			}
+			require.NoError(t, parser.Init())
+
+			actual, err := parser.Parse([]byte(tt.input))
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.expected, actual, testutil.IgnoreTime())
+		})
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		name       string
+		timezone   string
+		attributes string
+		expected   time.Time
+	}{
+		{
+			name:       "epoch milliseconds",
+			attributes: "devTime=1234567890123",
+			expected:   time.UnixMilli(1234567890123),
+		},
+		{
+			name:       "default format",
+			attributes: "devTime=Feb 13 2009 23:31:30.123 UTC",
+			expected:   time.UnixMilli(1234567890123),
+		},
+		{
+			name:       "default format with timezone setting",
+			timezone:   "Europe/Berlin",
+			attributes: "devTime=Feb 14 2009 00:31:30",
+			expected:   time.Unix(1234567890, 0),
+		},
+		{
+			name:       "custom format",
+			attributes: "devTimeFormat=yyyy-MM-dd'T'HH:mm:ss.SSSZ\tdevTime=2009-02-14T00:31:30.123+0100",
+			expected:   time.UnixMilli(1234567890123),
+		},
+		{
+			name:       "custom format with month name",
+			attributes: "devTimeFormat=dd/MMM/yyyy:HH:mm:ss\tdevTime=13/Feb/2009:23:31:30",
+			expected:   time.Unix(1234567890, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{
+				Timezone:   tt.timezone,
+				metricName: "leef",
+			}
+			require.NoError(t, parser.Init())
+
+			m, err := parser.ParseLine("LEEF:1.0|Vendor|Product|1.0|100|" + tt.attributes + "\tcnt=1")
+			require.NoError(t, err)
+			require.Equal(t, tt.expected.UnixNano(), m.Time().UnixNano())
+			require.NotContains(t, m.Fields(), "devTime")
+			require.NotContains(t, m.Fields(), "devTimeFormat")
+			require.Contains(t, m.Fields(), "cnt")
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	parser := &Parser{metricName: "leef"}
+	require.NoError(t, parser.Init())
+
+	_, err := parser.ParseLine("CEF:0|Vendor|Product|1.0|100|name|5|")
+	require.ErrorContains(t, err, "no LEEF header found")
+
+	_, err = parser.ParseLine("LEEF:1.0|Vendor|Product|1.0")
+	require.ErrorContains(t, err, "invalid LEEF header")
+
+	_, err = parser.ParseLine("LEEF:2.0|Vendor|Product|1.0|100|")
+	require.ErrorContains(t, err, "invalid LEEF header")
+
+	_, err = parser.ParseLine("LEEF:3.0|Vendor|Product|1.0|100|")
+	require.ErrorContains(t, err, "unsupported LEEF version")
+
+	_, err = parser.ParseLine("LEEF:2.0|Vendor|Product|1.0|100|tab|src=10.0.0.1")
+	require.ErrorContains(t, err, "invalid attribute delimiter")
+
+	_, err = parser.ParseLine("LEEF:1.0|Vendor|Product|1.0|100|devTime=yesterday")
+	require.ErrorContains(t, err, "parsing device time")
+
+	_, err = parser.ParseLine("")
+	require.ErrorIs(t, err, ErrNoMetric)
+}
+
+func TestParseDefaultTags(t *testing.T) {
+	parser := &Parser{metricName: "leef"}
+	require.NoError(t, parser.Init())
+	parser.SetDefaultTags(map[string]string{
+		"host":     "localhost",
+		"event_id": "ignored",
+	})
+
+	m, err := parser.ParseLine("LEEF:1.0|Vendor|Product|1.0|100|")
+	require.NoError(t, err)
+	require.Equal(t, "localhost", m.Tags()["host"])
+	require.Equal(t, "100", m.Tags()["event_id"])
+}
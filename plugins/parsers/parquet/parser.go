@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"slices"
 	"time"
 
+	"github.com/apache/arrow-go/v18/parquet"
 	"github.com/apache/arrow-go/v18/parquet/file"
 
 	"github.com/influxdata/telegraf"
@@ -55,69 +58,121 @@ func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
 	now := time.Now()
 	metrics := make([]telegraf.Metric, 0, metadata.NumRows)
 	for i := 0; i < parquetReader.NumRowGroups(); i++ {
-		rowGroup := parquetReader.RowGroup(i)
-		scanners := make([]*columnParser, metadata.Schema.NumColumns())
-		for colIndex := range metadata.Schema.NumColumns() {
-			col, err := rowGroup.Column(colIndex)
-			if err != nil {
-				return nil, fmt.Errorf("unable to fetch column %q: %w", colIndex, err)
-			}
+		rowGroupMetrics, err := p.parseRowGroup(parquetReader.RowGroup(i), metadata.Schema.NumColumns(), now)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, rowGroupMetrics...)
+	}
+
+	return metrics, nil
+}
 
-			scanners[colIndex] = newColumnParser(col)
+// ParseStream parses the file row group by row group so only a single row
+// group is held in memory at a time. As the parquet format requires random
+// access, data not provided by a seekable reader, such as a file, is
+// buffered in a temporary file.
+func (p *Parser) ParseStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	reader, ok := r.(parquet.ReaderAtSeeker)
+	if !ok {
+		tmpfile, err := os.CreateTemp("", "telegraf-parquet-*")
+		if err != nil {
+			return fmt.Errorf("unable to create temporary file: %w", err)
 		}
+		defer os.Remove(tmpfile.Name())
+		defer tmpfile.Close()
 
-		rowIndex := 0
-		rowGroupMetrics := make([]telegraf.Metric, rowGroup.NumRows())
-		for _, s := range scanners {
-			for s.HasNext() {
-				if rowIndex%int(rowGroup.NumRows()) == 0 {
-					rowIndex = 0
-				}
+		if _, err := io.Copy(tmpfile, r); err != nil {
+			return fmt.Errorf("unable to buffer data: %w", err)
+		}
+		reader = tmpfile
+	}
 
-				val, ok := s.Next()
-				if !ok || val == nil {
-					rowIndex++
-					continue
-				}
+	parquetReader, err := file.NewParquetReader(reader)
+	if err != nil {
+		return fmt.Errorf("unable to create parquet reader: %w", err)
+	}
+	metadata := parquetReader.MetaData()
 
-				if rowGroupMetrics[rowIndex] == nil {
-					rowGroupMetrics[rowIndex] = metric.New(p.metricName, p.defaultTags, nil, now)
-				}
+	now := time.Now()
+	for i := 0; i < parquetReader.NumRowGroups(); i++ {
+		rowGroupMetrics, err := p.parseRowGroup(parquetReader.RowGroup(i), metadata.Schema.NumColumns(), now)
+		if err != nil {
+			return err
+		}
+		for _, m := range rowGroupMetrics {
+			if m == nil {
+				continue
+			}
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+	}
 
-				if p.MeasurementColumn != "" && s.name == p.MeasurementColumn {
-					valStr, err := internal.ToString(val)
-					if err != nil {
-						return nil, fmt.Errorf("could not convert value to string: %w", err)
-					}
-					rowGroupMetrics[rowIndex].SetName(valStr)
-				} else if p.TagColumns != nil && slices.Contains(p.TagColumns, s.name) {
-					valStr, err := internal.ToString(val)
-					if err != nil {
-						return nil, fmt.Errorf("could not convert value to string: %w", err)
-					}
-					rowGroupMetrics[rowIndex].AddTag(s.name, valStr)
-				} else if p.TimestampColumn != "" && s.name == p.TimestampColumn {
-					valStr, err := internal.ToString(val)
-					if err != nil {
-						return nil, fmt.Errorf("could not convert value to string: %w", err)
-					}
-					timestamp, err := internal.ParseTimestamp(p.TimestampFormat, valStr, p.location)
-					if err != nil {
-						return nil, fmt.Errorf("could not parse '%s' to '%s'", valStr, p.TimestampFormat)
-					}
-					rowGroupMetrics[rowIndex].SetTime(timestamp)
-				} else {
-					rowGroupMetrics[rowIndex].AddField(s.name, val)
-				}
+	return nil
+}
+
+func (p *Parser) parseRowGroup(rowGroup *file.RowGroupReader, numColumns int, now time.Time) ([]telegraf.Metric, error) {
+	scanners := make([]*columnParser, numColumns)
+	for colIndex := range numColumns {
+		col, err := rowGroup.Column(colIndex)
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch column %q: %w", colIndex, err)
+		}
+
+		scanners[colIndex] = newColumnParser(col)
+	}
+
+	rowIndex := 0
+	rowGroupMetrics := make([]telegraf.Metric, rowGroup.NumRows())
+	for _, s := range scanners {
+		for s.HasNext() {
+			if rowIndex%int(rowGroup.NumRows()) == 0 {
+				rowIndex = 0
+			}
 
+			val, ok := s.Next()
+			if !ok || val == nil {
 				rowIndex++
+				continue
 			}
-		}
 
-		metrics = append(metrics, rowGroupMetrics...)
+			if rowGroupMetrics[rowIndex] == nil {
+				rowGroupMetrics[rowIndex] = metric.New(p.metricName, p.defaultTags, nil, now)
+			}
+
+			if p.MeasurementColumn != "" && s.name == p.MeasurementColumn {
+				valStr, err := internal.ToString(val)
+				if err != nil {
+					return nil, fmt.Errorf("could not convert value to string: %w", err)
+				}
+				rowGroupMetrics[rowIndex].SetName(valStr)
+			} else if p.TagColumns != nil && slices.Contains(p.TagColumns, s.name) {
+				valStr, err := internal.ToString(val)
+				if err != nil {
+					return nil, fmt.Errorf("could not convert value to string: %w", err)
+				}
+				rowGroupMetrics[rowIndex].AddTag(s.name, valStr)
+			} else if p.TimestampColumn != "" && s.name == p.TimestampColumn {
+				valStr, err := internal.ToString(val)
+				if err != nil {
+					return nil, fmt.Errorf("could not convert value to string: %w", err)
+				}
+				timestamp, err := internal.ParseTimestamp(p.TimestampFormat, valStr, p.location)
+				if err != nil {
+					return nil, fmt.Errorf("could not parse '%s' to '%s'", valStr, p.TimestampFormat)
+				}
+				rowGroupMetrics[rowIndex].SetTime(timestamp)
+			} else {
+				rowGroupMetrics[rowIndex].AddField(s.name, val)
+			}
+
+			rowIndex++
+		}
 	}
 
-	return metrics, nil
+	return rowGroupMetrics, nil
 }
 
 func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
@@ -1,6 +1,8 @@
 package parquet
 
 import (
+	"bytes"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -9,6 +11,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/stretchr/testify/require"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/testutil"
 	test "github.com/influxdata/telegraf/testutil/plugin_input"
@@ -62,6 +65,54 @@ func TestCases(t *testing.T) {
 	}
 }
 
+func TestParseStream(t *testing.T) {
+	folders, err := os.ReadDir("testcases")
+	require.NoError(t, err)
+	require.NotEmpty(t, folders)
+
+	for _, f := range folders {
+		filename := filepath.Join("testcases", f.Name(), "input.parquet")
+		t.Run(f.Name(), func(t *testing.T) {
+			plugin := &Parser{metricName: "test"}
+			require.NoError(t, plugin.Init())
+
+			// Use the result of parsing the data at once as reference
+			buf, err := os.ReadFile(filename)
+			require.NoError(t, err)
+			metrics, err := plugin.Parse(buf)
+			require.NoError(t, err)
+			expected := make([]telegraf.Metric, 0, len(metrics))
+			for _, m := range metrics {
+				if m != nil {
+					expected = append(expected, m)
+				}
+			}
+
+			// Files are read directly
+			file, err := os.Open(filename)
+			require.NoError(t, err)
+			defer file.Close()
+
+			var actual []telegraf.Metric
+			err = plugin.ParseStream(file, func(m telegraf.Metric) error {
+				actual = append(actual, m)
+				return nil
+			})
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime())
+
+			// Other readers are buffered
+			actual = nil
+			err = plugin.ParseStream(struct{ io.Reader }{bytes.NewReader(buf)}, func(m telegraf.Metric) error {
+				actual = append(actual, m)
+				return nil
+			})
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime())
+		})
+	}
+}
+
 func BenchmarkParsing(b *testing.B) {
 	plugin := &Parser{}
 
@@ -0,0 +1,20 @@
+package parsers
+
+import (
+	"io"
+
+	"github.com/influxdata/telegraf"
+)
+
+// ParseStream passes the data of the reader to the parser and calls the given
+// function for each parsed metric. Parsers created by Telegraf for a plugin
+// always implement the telegraf.StreamingParser interface and parse the data
+// incrementally if supported by the data format, all other parsers read the
+// data at once. Metrics parsed before an error occurred might have been passed
+// to the function already.
+func ParseStream(parser telegraf.Parser, r io.Reader, fn func(telegraf.Metric) error) error {
+	if sp, ok := parser.(telegraf.StreamingParser); ok {
+		return sp.ParseStream(r, fn)
+	}
+	return telegraf.ParseAll(parser, r, fn)
+}
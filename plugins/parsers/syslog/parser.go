@@ -0,0 +1,151 @@
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/leodido/go-syslog/v4"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	common_syslog "github.com/influxdata/telegraf/plugins/common/syslog"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+var ErrNoMetric = errors.New("no metric in line")
+
+// Parser decodes syslog messages, one per line, into metrics.
+type Parser struct {
+	SyslogStandard string            `toml:"syslog_standard"`
+	BestEffort     bool              `toml:"best_effort"`
+	Separator      string            `toml:"sdparam_separator"`
+	DefaultTags    map[string]string `toml:"-"`
+
+	metricName string
+	machine    syslog.Machine
+	// machineMutex guards the machine as it keeps the parsing state and is
+	// shared by all concurrent calls, e.g. of different kafka partitions
+	machineMutex sync.Mutex
+}
+
+func (p *Parser) Init() error {
+	if p.SyslogStandard == "" {
+		p.SyslogStandard = "RFC5424"
+	}
+	if p.Separator == "" {
+		p.Separator = "_"
+	}
+
+	machine, err := common_syslog.NewMachine(p.SyslogStandard, p.BestEffort)
+	if err != nil {
+		return err
+	}
+	p.machine = machine
+
+	return nil
+}
+
+// Parse converts a slice of bytes containing one syslog message per line to
+// metrics.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metrics := make([]telegraf.Metric, 0)
+	err := p.ParseStream(bytes.NewReader(buf), func(m telegraf.Metric) error {
+		metrics = append(metrics, m)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// ParseLine converts a single syslog message to a metric.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	m, err := p.parseMessage([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrNoMetric
+	}
+	return m, nil
+}
+
+// ParseStream reads syslog messages line by line from the reader and hands
+// the resulting metrics to the given function.
+func (p *Parser) ParseStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		m, err := p.parseMessage(scanner.Bytes())
+		if err != nil {
+			return err
+		}
+		if m == nil {
+			continue
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// SetDefaultTags adds tags to the metrics outputs of Parse and ParseLine.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+func (p *Parser) parseMessage(line []byte) (telegraf.Metric, error) {
+	line = bytes.TrimRight(line, "\r\n")
+	if len(bytes.TrimSpace(line)) == 0 {
+		return nil, nil
+	}
+
+	tags, fields, err := p.parseWithMachine(line)
+	if err != nil {
+		return nil, err
+	}
+
+	m := metric.New(p.metricName, tags, fields, time.Now())
+	for k, v := range p.DefaultTags {
+		if !m.HasTag(k) {
+			m.AddTag(k, v)
+		}
+	}
+	return m, nil
+}
+
+// parseWithMachine parses the message and extracts the tags and fields while
+// holding the machine exclusively
+func (p *Parser) parseWithMachine(line []byte) (map[string]string, map[string]interface{}, error) {
+	p.machineMutex.Lock()
+	defer p.machineMutex.Unlock()
+
+	// In best-effort mode the machine returns partial messages alongside
+	// the error, so only fail if nothing could be extracted at all.
+	msg, err := p.machine.Parse(line)
+	if msg == nil {
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing message failed: %w", err)
+		}
+		return nil, nil, fmt.Errorf("unable to parse message: %s", string(line))
+	}
+	if err != nil && !p.BestEffort {
+		return nil, nil, fmt.Errorf("parsing message failed: %w", err)
+	}
+
+	return common_syslog.Tags(msg, ""), common_syslog.Fields(msg, p.Separator), nil
+}
+
+func init() {
+	parsers.Add("syslog",
+		func(defaultMetricName string) telegraf.Parser {
+			return &Parser{metricName: defaultMetricName}
+		},
+	)
+}
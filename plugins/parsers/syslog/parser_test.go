@@ -0,0 +1,311 @@
+package syslog
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestParseRFC5424(t *testing.T) {
+	tests := []struct {
+		name      string
+		separator string
+		input     string
+		expected  []telegraf.Metric
+	}{
+		{
+			name:  "minimal",
+			input: "<34>1 - - - - - -",
+			expected: []telegraf.Metric{
+				metric.New(
+					"syslog",
+					map[string]string{
+						"severity": "crit",
+						"facility": "auth",
+					},
+					map[string]interface{}{
+						"facility_code": 4,
+						"severity_code": 2,
+						"version":       uint16(1),
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "structured data",
+			input: `<29>1 2016-02-21T04:32:57+00:00 web1 someservice 2341 2 ` +
+				`[origin][meta sequence="14125553" service="someservice"] "GET /v1/ok HTTP/1.1" 200 145 "-" "hacheck 0.9.0" 24306 127.0.0.1:40124 575`,
+			expected: []telegraf.Metric{
+				metric.New(
+					"syslog",
+					map[string]string{
+						"severity": "notice",
+						"facility": "daemon",
+						"hostname": "web1",
+						"appname":  "someservice",
+					},
+					map[string]interface{}{
+						"facility_code": 3,
+						"severity_code": 5,
+						"version":       uint16(1),
+						"timestamp":     time.Unix(1456029177, 0).UnixNano(),
+						"procid":        "2341",
+						"msgid":         "2",
+						"message":       `"GET /v1/ok HTTP/1.1" 200 145 "-" "hacheck 0.9.0" 24306 127.0.0.1:40124 575`,
+						"origin":        true,
+						"meta_sequence": "14125553",
+						"meta_service":  "someservice",
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name:      "custom separator",
+			separator: "::",
+			input:     `<13>1 2016-02-21T04:32:57Z host app - - [id k="v"] hello`,
+			expected: []telegraf.Metric{
+				metric.New(
+					"syslog",
+					map[string]string{
+						"severity": "notice",
+						"facility": "user",
+						"hostname": "host",
+						"appname":  "app",
+					},
+					map[string]interface{}{
+						"facility_code": 1,
+						"severity_code": 5,
+						"version":       uint16(1),
+						"timestamp":     time.Unix(1456029177, 0).UnixNano(),
+						"message":       "hello",
+						"id::k":         "v",
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+		{
+			name: "multiple lines",
+			input: "<34>1 - - - - - -\r\n\n" +
+				"<13>1 - host - - - - hello\n",
+			expected: []telegraf.Metric{
+				metric.New(
+					"syslog",
+					map[string]string{
+						"severity": "crit",
+						"facility": "auth",
+					},
+					map[string]interface{}{
+						"facility_code": 4,
+						"severity_code": 2,
+						"version":       uint16(1),
+					},
+					time.Unix(0, 0),
+				),
+				metric.New(
+					"syslog",
+					map[string]string{
+						"severity": "notice",
+						"facility": "user",
+						"hostname": "host",
+					},
+					map[string]interface{}{
+						"facility_code": 1,
+						"severity_code": 5,
+						"version":       uint16(1),
+						"message":       "hello",
+					},
+					time.Unix(0, 0),
+				),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{
+				Separator:  tt.separator,
+				metricName: "syslog",
+			}
+			require.NoError(t, parser.Init())
+
+			actual, err := parser.Parse([]byte(tt.input))
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.expected, actual, testutil.IgnoreTime())
+		})
+	}
+}
+
+func TestParseRFC3164(t *testing.T) {
+	parser := &Parser{
+		SyslogStandard: "RFC3164",
+		metricName:     "syslog",
+	}
+	require.NoError(t, parser.Init())
+
+	actual, err := parser.ParseLine("<34>Oct 11 22:14:15 mymachine su: 'su root' failed for lucas on /dev/pts/8")
+	require.NoError(t, err)
+
+	expected := metric.New(
+		"syslog",
+		map[string]string{
+			"severity": "crit",
+			"facility": "auth",
+			"hostname": "mymachine",
+			"appname":  "su",
+		},
+		map[string]interface{}{
+			"facility_code": 4,
+			"severity_code": 2,
+			"message":       "'su root' failed for lucas on /dev/pts/8",
+		},
+		time.Unix(0, 0),
+	)
+	// The timestamp depends on the current year so only check its presence
+	require.Contains(t, actual.Fields(), "timestamp")
+	actual.RemoveField("timestamp")
+	testutil.RequireMetricEqual(t, expected, actual, testutil.IgnoreTime())
+}
+
+func TestParseBestEffort(t *testing.T) {
+	input := "<1>1 2016-02-21T04:32:57+00:00 web1 someservice - - [id param="
+
+	strict := &Parser{metricName: "syslog"}
+	require.NoError(t, strict.Init())
+	_, err := strict.Parse([]byte(input))
+	require.ErrorContains(t, err, "parsing message failed")
+
+	parser := &Parser{
+		BestEffort: true,
+		metricName: "syslog",
+	}
+	require.NoError(t, parser.Init())
+
+	actual, err := parser.Parse([]byte(input))
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"syslog",
+			map[string]string{
+				"severity": "alert",
+				"facility": "kern",
+				"hostname": "web1",
+				"appname":  "someservice",
+			},
+			map[string]interface{}{
+				"facility_code": 0,
+				"severity_code": 1,
+				"version":       uint16(1),
+				"timestamp":     time.Unix(1456029177, 0).UnixNano(),
+				"id":            true,
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime())
+}
+
+func TestParseStream(t *testing.T) {
+	parser := &Parser{metricName: "syslog"}
+	require.NoError(t, parser.Init())
+	parser.SetDefaultTags(map[string]string{
+		"source":   "file",
+		"severity": "ignored",
+	})
+
+	input := "<34>1 - - - - - -\n<13>1 - host - - - - hello\n"
+	var actual []telegraf.Metric
+	require.NoError(t, parser.ParseStream(strings.NewReader(input), func(m telegraf.Metric) error {
+		actual = append(actual, m)
+		return nil
+	}))
+
+	expected := []telegraf.Metric{
+		metric.New(
+			"syslog",
+			map[string]string{
+				"severity": "crit",
+				"facility": "auth",
+				"source":   "file",
+			},
+			map[string]interface{}{
+				"facility_code": 4,
+				"severity_code": 2,
+				"version":       uint16(1),
+			},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"syslog",
+			map[string]string{
+				"severity": "notice",
+				"facility": "user",
+				"hostname": "host",
+				"source":   "file",
+			},
+			map[string]interface{}{
+				"facility_code": 1,
+				"severity_code": 5,
+				"version":       uint16(1),
+				"message":       "hello",
+			},
+			time.Unix(0, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime())
+}
+
+func TestParseConcurrent(t *testing.T) {
+	parser := &Parser{metricName: "syslog"}
+	require.NoError(t, parser.Init())
+
+	// The parser is shared between concurrent consumers, e.g. the partitions
+	// in kafka_consumer, so messages must not be mixed up
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			host := fmt.Sprintf("host%d", i)
+			input := fmt.Sprintf("<13>1 - %s app%d - - - message %d", host, i, i)
+			for range 100 {
+				m, err := parser.ParseLine(input)
+				if !assert.NoError(t, err) {
+					return
+				}
+				assert.Equal(t, host, m.Tags()["hostname"])
+				assert.Equal(t, fmt.Sprintf("app%d", i), m.Tags()["appname"])
+				assert.Equal(t, fmt.Sprintf("message %d", i), m.Fields()["message"])
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := &Parser{metricName: "syslog"}
+	require.NoError(t, parser.Init())
+
+	_, err := parser.Parse([]byte("<34>1 - - - - - -\nnot a syslog message\n"))
+	require.ErrorContains(t, err, "parsing message failed")
+
+	_, err = parser.ParseLine("")
+	require.ErrorIs(t, err, ErrNoMetric)
+}
+
+func TestInvalidStandard(t *testing.T) {
+	parser := &Parser{SyslogStandard: "RFC1234"}
+	require.ErrorContains(t, parser.Init(), `invalid syslog standard "RFC1234"`)
+}
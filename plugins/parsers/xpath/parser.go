@@ -3,8 +3,10 @@ package xpath
 import (
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"slices"
 	"strconv"
@@ -12,6 +14,7 @@ import (
 	"time"
 
 	"github.com/antchfx/jsonquery"
+	"github.com/antchfx/xmlquery"
 	path "github.com/antchfx/xpath"
 	"github.com/srebhan/cborquery"
 	"github.com/srebhan/protobufquery"
@@ -45,6 +48,7 @@ type Parser struct {
 	PrintDocument        bool              `toml:"xpath_print_document"`
 	AllowEmptySelection  bool              `toml:"xpath_allow_empty_selection"`
 	NativeTypes          bool              `toml:"xpath_native_types"`
+	Streaming            bool              `toml:"xpath_streaming"`
 	Trace                bool              `toml:"xpath_trace" deprecated:"1.35.0;use 'log_level' 'trace' instead"`
 	Configs              []Config          `toml:"xpath"`
 	DefaultMetricName    string            `toml:"-"`
@@ -193,6 +197,27 @@ func (p *Parser) Init() error {
 		p.Configs[i] = cfg
 	}
 
+	if p.Streaming {
+		if p.Format != "" && p.Format != "xml" {
+			return fmt.Errorf("streaming is not supported for data-format %q", p.Format)
+		}
+		if len(p.Configs) == 0 {
+			return errors.New("streaming requires at least one parsing section")
+		}
+		selection := p.Configs[0].Selection
+		if selection == "/" {
+			return errors.New("streaming requires a 'metric_selection'")
+		}
+		for _, cfg := range p.Configs[1:] {
+			if cfg.Selection != selection {
+				return errors.New("streaming requires the same 'metric_selection' for all parsing sections")
+			}
+		}
+		if _, err := path.Compile(selection); err != nil {
+			return fmt.Errorf("invalid 'metric_selection' %q: %w", selection, err)
+		}
+	}
+
 	return nil
 }
 
@@ -235,6 +260,127 @@ func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
 	return metrics, nil
 }
 
+// ParseStream parses XML documents incrementally if streaming is enabled and
+// JSON data value by value, e.g. newline delimited JSON. All other formats are
+// read at once.
+func (p *Parser) ParseStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	switch {
+	case p.Streaming:
+		return p.parseXMLStream(r, fn)
+	case p.Format == "xpath_json":
+		return p.parseJSONStream(r, fn)
+	}
+	return telegraf.ParseAll(p, r, fn)
+}
+
+// parseXMLStream only keeps the currently selected node and its ancestors in
+// memory. Nodes preceding the selected node on the same level are removed
+// and all following nodes are not yet read, so queries can only reference
+// the selected node, its ancestors and their preceding nodes.
+func (p *Parser) parseXMLStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	t := time.Now()
+
+	// Conditions are evaluated when reading the start of an element where the
+	// children are not known yet, so use the selection without conditions to
+	// find the candidates and filter them after reading the whole element.
+	selection := p.Configs[0].Selection
+	var filters []string
+	if elements := stripConditions(selection); elements != selection {
+		selection = elements
+		filters = append(filters, p.Configs[0].Selection)
+	}
+	sp, err := xmlquery.CreateStreamParser(r, selection, filters...)
+	if err != nil {
+		return err
+	}
+
+	var selected bool
+	for {
+		node, err := sp.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		selected = true
+
+		doc := node
+		for doc.Parent != nil {
+			doc = doc.Parent
+		}
+		for _, cfg := range p.Configs {
+			m, err := p.parseQuery(t, doc, node, cfg)
+			if err != nil {
+				return err
+			}
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !selected && !p.AllowEmptySelection {
+		return errors.New("cannot parse with empty selection node")
+	}
+	return nil
+}
+
+// parseJSONStream parses the top-level JSON values of the reader one by one
+// as separate documents. Each document is read into memory completely as the
+// queries may reference any part of the document.
+func (p *Parser) parseJSONStream(r io.Reader, fn func(telegraf.Metric) error) error {
+	decoder := json.NewDecoder(r)
+	for {
+		var doc json.RawMessage
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		metrics, err := p.Parse(doc)
+		if err != nil {
+			return err
+		}
+		for _, m := range metrics {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// stripConditions removes all conditions in square brackets from the query
+func stripConditions(query string) string {
+	var stripped strings.Builder
+	var depth int
+	var quote rune
+	for _, c := range query {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			continue
+		case depth > 0 && (c == '"' || c == '\''):
+			quote = c
+			continue
+		case c == '[':
+			depth++
+			continue
+		case c == ']':
+			depth--
+			continue
+		case depth > 0:
+			continue
+		}
+		stripped.WriteRune(c)
+	}
+	return stripped.String()
+}
+
 func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
 	metrics, err := p.Parse([]byte(line))
 	if err != nil {
@@ -1190,6 +1190,216 @@ func TestEmptySelection(t *testing.T) {
 	}
 }
 
+const streamingXML = `<?xml version="1.0"?>
+<Export>
+	<Header>
+		<Site>plant1</Site>
+	</Header>
+	<Rows>
+		<Row enabled="true"><Name>a</Name><Value>1</Value></Row>
+		<Row enabled="false"><Name>b</Name><Value>2</Value></Row>
+		<Row enabled="true"><Name>c</Name><Value>3</Value></Row>
+	</Rows>
+</Export>
+`
+
+func TestParseStreamXML(t *testing.T) {
+	tests := []struct {
+		name      string
+		selection string
+		expected  int
+	}{
+		{
+			name:      "absolute path",
+			selection: "/Export/Rows/Row",
+			expected:  3,
+		},
+		{
+			name:      "pattern",
+			selection: "//Row",
+			expected:  3,
+		},
+		{
+			name:      "axis",
+			selection: "/Export/Rows/child::Row",
+			expected:  3,
+		},
+		{
+			name:      "attribute condition",
+			selection: "/Export/Rows/Row[@enabled='true']",
+			expected:  2,
+		},
+		{
+			name:      "child condition",
+			selection: "/Export/Rows/Row[number(Value) > 1]",
+			expected:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configs := []Config{
+				{
+					Selection: tt.selection,
+					Tags: map[string]string{
+						"name": "Name",
+						"site": "/Export/Header/Site",
+					},
+					Fields: map[string]string{"value": "number(Value)"},
+				},
+			}
+
+			// Use the result of parsing the document at once as reference
+			reference := &Parser{
+				DefaultMetricName: "test",
+				Configs:           configs,
+				Log:               testutil.Logger{Name: "parsers.xml"},
+			}
+			require.NoError(t, reference.Init())
+			expected, err := reference.Parse([]byte(streamingXML))
+			require.NoError(t, err)
+			require.Len(t, expected, tt.expected)
+
+			parser := &Parser{
+				DefaultMetricName: "test",
+				Streaming:         true,
+				Configs:           configs,
+				Log:               testutil.Logger{Name: "parsers.xml"},
+			}
+			require.NoError(t, parser.Init())
+
+			var actual []telegraf.Metric
+			err = parser.ParseStream(strings.NewReader(streamingXML), func(m telegraf.Metric) error {
+				actual = append(actual, m)
+				return nil
+			})
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime())
+		})
+	}
+}
+
+func TestParseStreamXMLEmptySelection(t *testing.T) {
+	parser := &Parser{
+		DefaultMetricName: "test",
+		Streaming:         true,
+		Configs: []Config{
+			{
+				Selection: "/Export/Rows/NonExisting",
+				Fields:    map[string]string{"value": "number(Value)"},
+			},
+		},
+		Log: testutil.Logger{Name: "parsers.xml"},
+	}
+	require.NoError(t, parser.Init())
+
+	fn := func(telegraf.Metric) error { return nil }
+	err := parser.ParseStream(strings.NewReader(streamingXML), fn)
+	require.EqualError(t, err, "cannot parse with empty selection node")
+
+	parser.AllowEmptySelection = true
+	require.NoError(t, parser.ParseStream(strings.NewReader(streamingXML), fn))
+}
+
+func TestParseStreamInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		configs  []Config
+		expected string
+	}{
+		{
+			name:     "unsupported format",
+			format:   "xpath_json",
+			configs:  []Config{{Selection: "/rows"}},
+			expected: `streaming is not supported for data-format "xpath_json"`,
+		},
+		{
+			name:     "no config",
+			expected: "streaming requires at least one parsing section",
+		},
+		{
+			name:     "no selection",
+			configs:  []Config{{}},
+			expected: "streaming requires a 'metric_selection'",
+		},
+		{
+			name:     "different selections",
+			configs:  []Config{{Selection: "/Export/Rows/Row"}, {Selection: "/Export/Header"}},
+			expected: "streaming requires the same 'metric_selection' for all parsing sections",
+		},
+		{
+			name:     "invalid selection",
+			configs:  []Config{{Selection: "/Export/Rows/Row["}},
+			expected: "invalid 'metric_selection'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &Parser{
+				Format:            tt.format,
+				DefaultMetricName: "test",
+				Streaming:         true,
+				Configs:           tt.configs,
+				Log:               testutil.Logger{Name: "parsers.xml"},
+			}
+			require.ErrorContains(t, parser.Init(), tt.expected)
+		})
+	}
+}
+
+func TestParseStreamJSON(t *testing.T) {
+	parser := &Parser{
+		Format:            "xpath_json",
+		DefaultMetricName: "test",
+		Configs: []Config{
+			{
+				Selection: "/readings/*",
+				Tags:      map[string]string{"name": "name"},
+				Fields:    map[string]string{"value": "number(value)"},
+			},
+		},
+		Log: testutil.Logger{Name: "parsers.xpath_json"},
+	}
+	require.NoError(t, parser.Init())
+
+	// Each document of newline delimited JSON data is parsed separately
+	input := `{"readings": [{"name": "a", "value": 1}, {"name": "b", "value": 2}]}
+{"readings": [{"name": "c", "value": 3}]}
+`
+	expected := []telegraf.Metric{
+		metric.New("test", map[string]string{"name": "a"}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0)),
+		metric.New("test", map[string]string{"name": "b"}, map[string]interface{}{"value": 2.0}, time.Unix(0, 0)),
+		metric.New("test", map[string]string{"name": "c"}, map[string]interface{}{"value": 3.0}, time.Unix(0, 0)),
+	}
+
+	var actual []telegraf.Metric
+	err := parser.ParseStream(strings.NewReader(input), func(m telegraf.Metric) error {
+		actual = append(actual, m)
+		return nil
+	})
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreTime())
+}
+
+func TestStripConditions(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"/Export/Rows/Row", "/Export/Rows/Row"},
+		{"/Export/Rows/Row[@enabled='true']", "/Export/Rows/Row"},
+		{"/Export[Header]/Rows/Row[Value > 1][1]", "/Export/Rows/Row"},
+		{"//Row[contains(Name, ']')]", "//Row"},
+		{"/Export/Rows/Row[Value[@unit=\"m\"] > 1]", "/Export/Rows/Row"},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, stripConditions(tt.query), tt.query)
+	}
+}
+
 func TestEmptySelectionAllowed(t *testing.T) {
 	var tests = []struct {
 		name    string
@@ -0,0 +1,5 @@
+//go:build !custom || processors || processors.cel
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/processors/cel" // register plugin
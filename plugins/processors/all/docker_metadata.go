@@ -0,0 +1,5 @@
+//go:build !custom || processors || processors.docker_metadata
+
+package all
+
+import _ "github.com/influxdata/telegraf/plugins/processors/docker_metadata" // register plugin
@@ -0,0 +1,280 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package anomaly
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/simplelru"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal/choice"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+var availableMethods = []string{"ewma", "mad", "holt_winters"}
+
+type Anomaly struct {
+	Fields       []string        `toml:"fields"`
+	Method       string          `toml:"method"`
+	Threshold    float64         `toml:"threshold"`
+	Warmup       uint64          `toml:"warmup"`
+	Alpha        float64         `toml:"alpha"`
+	Window       int             `toml:"window"`
+	SeasonLength int             `toml:"season_length"`
+	Beta         float64         `toml:"beta"`
+	Gamma        float64         `toml:"gamma"`
+	ScoreSuffix  string          `toml:"score_suffix"`
+	Tag          string          `toml:"tag"`
+	MaxSeries    int             `toml:"max_series"`
+	SeriesTTL    config.Duration `toml:"series_ttl"`
+	Log          telegraf.Logger `toml:"-"`
+
+	fieldFilter filter.Filter
+	series      *simplelru.LRU[seriesKey, *series]
+}
+
+// seriesKey identifies a field of a series
+type seriesKey struct {
+	id    uint64
+	field string
+}
+
+// series holds the model of a field of a series, only the model of the
+// configured method is set
+type series struct {
+	ID          uint64       `json:"id"`
+	Field       string       `json:"field"`
+	LastSeen    time.Time    `json:"last_seen"`
+	Count       uint64       `json:"count"`
+	EWMA        *ewma        `json:"ewma,omitempty"`
+	MAD         *mad         `json:"mad,omitempty"`
+	HoltWinters *holtWinters `json:"holt_winters,omitempty"`
+}
+
+func (*Anomaly) SampleConfig() string {
+	return sampleConfig
+}
+
+func (a *Anomaly) Init() error {
+	if len(a.Fields) == 0 {
+		return errors.New("no fields configured")
+	}
+	f, err := filter.Compile(a.Fields)
+	if err != nil {
+		return fmt.Errorf("creating field filter failed: %w", err)
+	}
+	a.fieldFilter = f
+
+	if err := choice.Check(a.Method, availableMethods); err != nil {
+		return fmt.Errorf("invalid 'method' setting: %w", err)
+	}
+	switch a.Method {
+	case "ewma":
+		if a.Alpha <= 0 || a.Alpha > 1 {
+			return errors.New("'alpha' must be in the range (0, 1]")
+		}
+	case "mad":
+		if a.Window < 3 {
+			return errors.New("'window' must be at least 3")
+		}
+	case "holt_winters":
+		if a.SeasonLength < 2 {
+			return errors.New("'season_length' must be at least 2")
+		}
+		if a.Alpha <= 0 || a.Alpha > 1 {
+			return errors.New("'alpha' must be in the range (0, 1]")
+		}
+		if a.Beta < 0 || a.Beta > 1 {
+			return errors.New("'beta' must be in the range [0, 1]")
+		}
+		if a.Gamma < 0 || a.Gamma > 1 {
+			return errors.New("'gamma' must be in the range [0, 1]")
+		}
+	}
+
+	if a.Threshold <= 0 {
+		return errors.New("'threshold' must be positive")
+	}
+	if a.ScoreSuffix == "" {
+		return errors.New("'score_suffix' must not be empty")
+	}
+	if a.SeriesTTL < 0 {
+		return errors.New("'series_ttl' must not be negative")
+	}
+	if a.MaxSeries < 1 {
+		return errors.New("'max_series' must be positive")
+	}
+	cache, err := simplelru.NewLRU[seriesKey, *series](a.MaxSeries, nil)
+	if err != nil {
+		return fmt.Errorf("creating series cache failed: %w", err)
+	}
+	a.series = cache
+
+	return nil
+}
+
+func (a *Anomaly) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		a.process(m)
+	}
+	return in
+}
+
+func (a *Anomaly) process(m telegraf.Metric) {
+	id := m.HashID()
+
+	// Collect the scores first to not modify the fields while iterating
+	scores := make(map[string]float64)
+	for _, field := range m.FieldList() {
+		if !a.fieldFilter.Match(field.Key) {
+			continue
+		}
+		value, ok := toFloat(field.Value)
+		if !ok {
+			continue
+		}
+		if score, ready := a.update(seriesKey{id, field.Key}, value, m.Time()); ready {
+			scores[field.Key] = score
+		}
+	}
+
+	// No tag is added if all fields are still warming up
+	if len(scores) == 0 {
+		return
+	}
+
+	var anomalous bool
+	for field, score := range scores {
+		m.AddField(field+a.ScoreSuffix, score)
+		anomalous = anomalous || score > a.Threshold
+	}
+	if a.Tag != "" {
+		if anomalous {
+			m.AddTag(a.Tag, "true")
+		} else {
+			m.AddTag(a.Tag, "false")
+		}
+	}
+}
+
+// update scores the value against the model of the series before feeding the
+// value into the model. The score is only valid if the series is warmed up.
+func (a *Anomaly) update(key seriesKey, value float64, t time.Time) (float64, bool) {
+	s, found := a.series.Get(key)
+	if !found || (a.SeriesTTL > 0 && t.Sub(s.LastSeen) > time.Duration(a.SeriesTTL)) {
+		// Start over with a new model for new or expired series
+		s = a.newSeries(key)
+		a.series.Add(key, s)
+	}
+	s.LastSeen = t
+
+	var score float64
+	warmup := a.Warmup
+	switch {
+	case s.EWMA != nil:
+		score = s.EWMA.update(value, a.Alpha)
+	case s.MAD != nil:
+		score = s.MAD.update(value, a.Window)
+	case s.HoltWinters != nil:
+		score = s.HoltWinters.update(value, a.SeasonLength, a.Alpha, a.Beta, a.Gamma)
+		// The first season is required to initialize the model
+		warmup += uint64(a.SeasonLength)
+	}
+	s.Count++
+
+	return score, s.Count > warmup
+}
+
+func (a *Anomaly) newSeries(key seriesKey) *series {
+	s := &series{ID: key.id, Field: key.field}
+	switch a.Method {
+	case "ewma":
+		s.EWMA = &ewma{}
+	case "mad":
+		s.MAD = &mad{}
+	case "holt_winters":
+		s.HoltWinters = &holtWinters{}
+	}
+	return s
+}
+
+func (a *Anomaly) GetState() interface{} {
+	// Keys are ordered from the oldest to the newest to restore the recency
+	// when adding them to the cache again
+	state := make([]*series, 0, a.series.Len())
+	for _, key := range a.series.Keys() {
+		if s, found := a.series.Peek(key); found {
+			state = append(state, s)
+		}
+	}
+	return state
+}
+
+func (a *Anomaly) SetState(state interface{}) error {
+	restored, ok := state.([]*series)
+	if !ok {
+		return fmt.Errorf("state has wrong type %T", state)
+	}
+
+	for _, s := range restored {
+		// Skip models of a different method, e.g. if the configuration changed
+		var valid bool
+		switch a.Method {
+		case "ewma":
+			valid = s.EWMA != nil
+		case "mad":
+			valid = s.MAD != nil
+		case "holt_winters":
+			valid = s.HoltWinters != nil && s.HoltWinters.valid(a.SeasonLength)
+		}
+		if !valid {
+			a.Log.Debugf("Ignoring state of field %q for series %d not matching the method", s.Field, s.ID)
+			continue
+		}
+		a.series.Add(seriesKey{s.ID, s.Field}, s)
+	}
+	return nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	var f float64
+	switch v := v.(type) {
+	case float64:
+		f = v
+	case int64:
+		f = float64(v)
+	case uint64:
+		f = float64(v)
+	default:
+		return 0, false
+	}
+	return f, !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+func init() {
+	processors.Add("anomaly", func() telegraf.Processor {
+		return &Anomaly{
+			Fields:      []string{"*"},
+			Method:      "ewma",
+			Threshold:   3.0,
+			Warmup:      10,
+			Alpha:       0.1,
+			Window:      30,
+			Beta:        0.05,
+			Gamma:       0.1,
+			ScoreSuffix: "_anomaly_score",
+			Tag:         "anomaly",
+			MaxSeries:   10000,
+			SeriesTTL:   config.Duration(time.Hour),
+		}
+	})
+}
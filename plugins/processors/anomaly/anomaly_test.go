@@ -0,0 +1,331 @@
+package anomaly
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+// noise is a deterministic pattern added to the test series
+var noise = []float64{0.5, -0.3, 0.1, -0.6, 0.4, -0.2, 0.3, -0.1}
+
+func TestInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   *Anomaly
+		expected string
+	}{
+		{
+			name:     "no fields",
+			plugin:   &Anomaly{Method: "ewma"},
+			expected: "no fields configured",
+		},
+		{
+			name:     "invalid method",
+			plugin:   &Anomaly{Fields: []string{"*"}, Method: "foo"},
+			expected: "invalid 'method' setting",
+		},
+		{
+			name:     "invalid alpha",
+			plugin:   &Anomaly{Fields: []string{"*"}, Method: "ewma", Alpha: 1.5},
+			expected: "'alpha' must be in the range (0, 1]",
+		},
+		{
+			name:     "invalid window",
+			plugin:   &Anomaly{Fields: []string{"*"}, Method: "mad", Window: 2},
+			expected: "'window' must be at least 3",
+		},
+		{
+			name:     "missing season length",
+			plugin:   &Anomaly{Fields: []string{"*"}, Method: "holt_winters", Alpha: 0.1},
+			expected: "'season_length' must be at least 2",
+		},
+		{
+			name:     "invalid gamma",
+			plugin:   &Anomaly{Fields: []string{"*"}, Method: "holt_winters", SeasonLength: 24, Alpha: 0.1, Gamma: -1},
+			expected: "'gamma' must be in the range [0, 1]",
+		},
+		{
+			name:     "invalid threshold",
+			plugin:   &Anomaly{Fields: []string{"*"}, Method: "ewma", Alpha: 0.1},
+			expected: "'threshold' must be positive",
+		},
+		{
+			name:     "invalid max series",
+			plugin:   &Anomaly{Fields: []string{"*"}, Method: "ewma", Alpha: 0.1, Threshold: 3, ScoreSuffix: "_score"},
+			expected: "'max_series' must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestMethods(t *testing.T) {
+	// Seasonal pattern with a period of four samples
+	pattern := []float64{10, 20, 30, 20}
+
+	tests := []struct {
+		name      string
+		method    string
+		value     func(i int) float64
+		normal    float64
+		anomalous float64
+	}{
+		{
+			name:      "ewma",
+			method:    "ewma",
+			value:     func(i int) float64 { return 100 + noise[i%len(noise)] },
+			normal:    100.2,
+			anomalous: 110,
+		},
+		{
+			name:      "mad",
+			method:    "mad",
+			value:     func(i int) float64 { return 100 + noise[i%len(noise)] },
+			normal:    99.8,
+			anomalous: 90,
+		},
+		{
+			name:   "holt_winters",
+			method: "holt_winters",
+			value: func(i int) float64 {
+				return pattern[i%len(pattern)] + noise[i%len(noise)]
+			},
+			// The next value in the season is the peak of the pattern which
+			// is normal for the season but would be an outlier otherwise
+			normal:    30.1,
+			anomalous: 12,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newPlugin(tt.method)
+			plugin.SeasonLength = len(pattern)
+			require.NoError(t, plugin.Init())
+
+			// Feed enough samples to warm up, the number is divisible by the
+			// season length to continue with the peak of the pattern
+			var input []telegraf.Metric
+			for i := range 42 {
+				input = append(input, newMetric("server01", tt.value(i), i))
+			}
+			input = append(input, newMetric("server01", tt.normal, 42), newMetric("server01", tt.anomalous, 43))
+			actual := plugin.Apply(input...)
+			require.Len(t, actual, 44)
+
+			// No score or tag is added during warm-up
+			warmup := int(plugin.Warmup)
+			if tt.method == "holt_winters" {
+				warmup += plugin.SeasonLength
+			}
+			for _, m := range actual[:warmup] {
+				require.False(t, m.HasTag("anomaly"))
+				require.False(t, m.HasField("value_anomaly_score"))
+			}
+
+			// Warmed up samples have a score and flag
+			for i, m := range actual[warmup:42] {
+				tag, found := m.GetTag("anomaly")
+				require.Truef(t, found, "no tag in metric %d", warmup+i)
+				require.Equalf(t, "false", tag, "metric %d flagged with %v", warmup+i, m.Fields())
+			}
+
+			normal := actual[42]
+			require.Equal(t, "false", normal.Tags()["anomaly"])
+			require.Less(t, normal.Fields()["value_anomaly_score"], 3.0)
+
+			anomalous := actual[43]
+			require.Equal(t, "true", anomalous.Tags()["anomaly"])
+			require.Greater(t, anomalous.Fields()["value_anomaly_score"], 3.0)
+		})
+	}
+}
+
+func TestSeries(t *testing.T) {
+	plugin := newPlugin("ewma")
+	plugin.Fields = []string{"value", "status"}
+	plugin.Warmup = 2
+	require.NoError(t, plugin.Init())
+
+	tm := time.Unix(0, 0)
+	input := []telegraf.Metric{
+		metric.New("test", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0, "other": 1.0, "status": "ok"}, tm),
+		metric.New("test", map[string]string{"host": "b"}, map[string]interface{}{"value": int64(100)}, tm),
+		metric.New("test", map[string]string{"host": "a"}, map[string]interface{}{"value": 2.0, "other": 2.0, "status": "ok"}, tm),
+		metric.New("test", map[string]string{"host": "b"}, map[string]interface{}{"value": int64(100)}, tm),
+		metric.New("test", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0, "other": 1.0, "status": "ok"}, tm),
+		metric.New("test", map[string]string{"host": "b"}, map[string]interface{}{"value": int64(100)}, tm),
+		metric.New("test", map[string]string{"host": "b"}, map[string]interface{}{"value": int64(101)}, tm),
+	}
+
+	expected := []telegraf.Metric{
+		metric.New("test", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0, "other": 1.0, "status": "ok"}, tm),
+		metric.New("test", map[string]string{"host": "b"}, map[string]interface{}{"value": int64(100)}, tm),
+		metric.New("test", map[string]string{"host": "a"}, map[string]interface{}{"value": 2.0, "other": 2.0, "status": "ok"}, tm),
+		metric.New("test", map[string]string{"host": "b"}, map[string]interface{}{"value": int64(100)}, tm),
+		metric.New(
+			"test",
+			map[string]string{"host": "a", "anomaly": "false"},
+			map[string]interface{}{"value": 1.0, "other": 1.0, "status": "ok", "value_anomaly_score": 1.0 / 3.0},
+			tm,
+		),
+		metric.New(
+			"test",
+			map[string]string{"host": "b", "anomaly": "false"},
+			map[string]interface{}{"value": int64(100), "value_anomaly_score": 0.0},
+			tm,
+		),
+		// Any change of a constant series is an anomaly
+		metric.New(
+			"test",
+			map[string]string{"host": "b", "anomaly": "true"},
+			map[string]interface{}{"value": int64(101), "value_anomaly_score": 1 / minDeviation},
+			tm,
+		),
+	}
+
+	actual := plugin.Apply(input...)
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.IgnoreFields("value_anomaly_score"))
+	for i := range expected {
+		e, _ := expected[i].GetField("value_anomaly_score")
+		a, _ := actual[i].GetField("value_anomaly_score")
+		if e == nil {
+			require.Nil(t, a)
+			continue
+		}
+		require.InDelta(t, e, a, 1e-6)
+	}
+}
+
+func TestSeriesTTL(t *testing.T) {
+	plugin := newPlugin("ewma")
+	plugin.Warmup = 2
+	plugin.SeriesTTL = config.Duration(time.Minute)
+	require.NoError(t, plugin.Init())
+
+	// The series is warmed up after three samples
+	for i := range 3 {
+		actual := plugin.Apply(newMetric("a", 1.0, i))
+		require.Equal(t, i == 2, actual[0].HasTag("anomaly"))
+	}
+
+	// After the TTL the warm-up starts over
+	actual := plugin.Apply(metric.New("test", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0}, time.Unix(200, 0)))
+	require.False(t, actual[0].HasTag("anomaly"))
+}
+
+func TestMaxSeries(t *testing.T) {
+	plugin := newPlugin("ewma")
+	plugin.Warmup = 1
+	plugin.MaxSeries = 2
+	require.NoError(t, plugin.Init())
+
+	plugin.Apply(newMetric("a", 1.0, 0), newMetric("a", 1.0, 1))
+	require.True(t, plugin.Apply(newMetric("a", 1.0, 2))[0].HasTag("anomaly"))
+
+	// Adding two more series evicts the least recently used one
+	plugin.Apply(newMetric("b", 1.0, 3), newMetric("c", 1.0, 4))
+	require.Equal(t, 2, plugin.series.Len())
+	require.False(t, plugin.Apply(newMetric("a", 1.0, 5))[0].HasTag("anomaly"))
+}
+
+func TestState(t *testing.T) {
+	var input []telegraf.Metric
+	for i := range 30 {
+		input = append(input, newMetric("a", 10+noise[i%len(noise)], i))
+	}
+
+	for _, method := range availableMethods {
+		t.Run(method, func(t *testing.T) {
+			// Process the metrics in one go as reference
+			reference := newPlugin(method)
+			reference.SeasonLength = 4
+			reference.Warmup = 5
+			require.NoError(t, reference.Init())
+			expected := reference.Apply(copyMetrics(input)...)
+
+			// Process the first half, then persist the state and continue with
+			// a new instance
+			first := newPlugin(method)
+			first.SeasonLength = 4
+			first.Warmup = 5
+			require.NoError(t, first.Init())
+			actual := first.Apply(copyMetrics(input[:15])...)
+
+			buf, err := json.Marshal(first.GetState())
+			require.NoError(t, err)
+
+			second := newPlugin(method)
+			second.SeasonLength = 4
+			second.Warmup = 5
+			require.NoError(t, second.Init())
+			var state []*series
+			require.NoError(t, json.Unmarshal(buf, &state))
+			require.NoError(t, second.SetState(state))
+			actual = append(actual, second.Apply(copyMetrics(input[15:])...)...)
+
+			testutil.RequireMetricsEqual(t, expected, actual)
+		})
+	}
+}
+
+func TestStateMethodChanged(t *testing.T) {
+	first := newPlugin("ewma")
+	first.Warmup = 1
+	require.NoError(t, first.Init())
+	first.Apply(newMetric("a", 1.0, 0), newMetric("a", 1.0, 1))
+
+	second := newPlugin("mad")
+	second.Warmup = 1
+	second.Log = testutil.Logger{}
+	require.NoError(t, second.Init())
+	require.NoError(t, second.SetState(first.GetState()))
+	require.Zero(t, second.series.Len())
+}
+
+func newPlugin(method string) *Anomaly {
+	return &Anomaly{
+		Fields:      []string{"*"},
+		Method:      method,
+		Threshold:   3.0,
+		Warmup:      10,
+		Alpha:       0.1,
+		Window:      30,
+		Beta:        0.05,
+		Gamma:       0.1,
+		ScoreSuffix: "_anomaly_score",
+		Tag:         "anomaly",
+		MaxSeries:   100,
+		SeriesTTL:   config.Duration(time.Hour),
+		Log:         testutil.Logger{},
+	}
+}
+
+func newMetric(host string, value float64, i int) telegraf.Metric {
+	return metric.New(
+		"test",
+		map[string]string{"host": host},
+		map[string]interface{}{"value": value},
+		time.Unix(int64(i)*10, 0),
+	)
+}
+
+func copyMetrics(in []telegraf.Metric) []telegraf.Metric {
+	out := make([]telegraf.Metric, 0, len(in))
+	for _, m := range in {
+		out = append(out, m.Copy())
+	}
+	return out
+}
@@ -0,0 +1,136 @@
+package anomaly
+
+import (
+	"math"
+	"slices"
+)
+
+// minDeviation is the lower bound of the deviation used for scoring to avoid
+// dividing by zero for constant series. Any change of a constant series thus
+// results in a very high score.
+const minDeviation = 1e-9
+
+// madScale makes the median absolute deviation a consistent estimator of the
+// standard deviation for normally distributed data
+const madScale = 1.4826
+
+func score(diff, deviation float64) float64 {
+	return math.Abs(diff) / max(deviation, minDeviation)
+}
+
+// ewma scores values by their z-score using an exponentially weighted moving
+// average and variance
+type ewma struct {
+	Mean     float64 `json:"mean"`
+	Variance float64 `json:"variance"`
+	Started  bool    `json:"started"`
+}
+
+func (e *ewma) update(value, alpha float64) float64 {
+	if !e.Started {
+		e.Mean = value
+		e.Started = true
+		return 0
+	}
+
+	diff := value - e.Mean
+	s := score(diff, math.Sqrt(e.Variance))
+
+	incr := alpha * diff
+	e.Mean += incr
+	e.Variance = (1 - alpha) * (e.Variance + diff*incr)
+
+	return s
+}
+
+// mad scores values by their distance to the median of a sliding window
+// relative to the median absolute deviation of the window
+type mad struct {
+	Window []float64 `json:"window"`
+}
+
+func (m *mad) update(value float64, size int) float64 {
+	var s float64
+	if len(m.Window) > 0 {
+		center := median(m.Window)
+		deviations := make([]float64, 0, len(m.Window))
+		for _, v := range m.Window {
+			deviations = append(deviations, math.Abs(v-center))
+		}
+		s = score(value-center, madScale*median(deviations))
+	}
+
+	m.Window = append(m.Window, value)
+	if len(m.Window) > size {
+		m.Window = m.Window[len(m.Window)-size:]
+	}
+
+	return s
+}
+
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// holtWinters scores values by the deviation from the forecast of an additive
+// Holt-Winters model relative to the exponentially weighted standard deviation
+// of the forecast errors. The first season is used to initialize the model.
+type holtWinters struct {
+	Level    float64   `json:"level"`
+	Trend    float64   `json:"trend"`
+	Seasonal []float64 `json:"seasonal"`
+	Index    int       `json:"index"`
+	Variance float64   `json:"variance"`
+	Started  bool      `json:"started"`
+}
+
+func (hw *holtWinters) update(value float64, season int, alpha, beta, gamma float64) float64 {
+	if !hw.Started {
+		hw.Seasonal = append(hw.Seasonal, value)
+		if len(hw.Seasonal) < season {
+			return 0
+		}
+
+		// Use the mean of the first season as level and the deviation from
+		// the level as initial seasonal components
+		var sum float64
+		for _, v := range hw.Seasonal {
+			sum += v
+		}
+		hw.Level = sum / float64(season)
+		for i := range hw.Seasonal {
+			hw.Seasonal[i] -= hw.Level
+		}
+		hw.Started = true
+		return 0
+	}
+
+	seasonal := hw.Seasonal[hw.Index]
+	diff := value - (hw.Level + hw.Trend + seasonal)
+	s := score(diff, math.Sqrt(hw.Variance))
+
+	level := alpha*(value-seasonal) + (1-alpha)*(hw.Level+hw.Trend)
+	hw.Trend = beta*(level-hw.Level) + (1-beta)*hw.Trend
+	hw.Level = level
+	hw.Seasonal[hw.Index] = gamma*(value-level) + (1-gamma)*seasonal
+	hw.Index = (hw.Index + 1) % season
+	hw.Variance = (1-alpha)*hw.Variance + alpha*diff*diff
+
+	return s
+}
+
+// valid checks if the model matches the season length, e.g. after restoring
+// the state with a changed configuration
+func (hw *holtWinters) valid(season int) bool {
+	if hw.Started {
+		return len(hw.Seasonal) == season && hw.Index >= 0 && hw.Index < season
+	}
+	return len(hw.Seasonal) < season
+}
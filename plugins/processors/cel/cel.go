@@ -0,0 +1,85 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package cel
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/telegraf"
+	common_cel "github.com/influxdata/telegraf/plugins/common/cel"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+type CEL struct {
+	Rules []rule          `toml:"rule"`
+	Log   telegraf.Logger `toml:"-"`
+}
+
+func (*CEL) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *CEL) Init() error {
+	if len(c.Rules) == 0 {
+		return errors.New("no rules configured")
+	}
+
+	env, err := common_cel.NewEnvironment()
+	if err != nil {
+		return err
+	}
+
+	for i := range c.Rules {
+		if err := c.Rules[i].init(env); err != nil {
+			return fmt.Errorf("initialization of rule %d failed: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *CEL) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range in {
+		tags := m.Tags()
+		fields := m.Fields()
+		vars := common_cel.Variables(m.Name(), tags, fields, m.Time())
+
+		for i, r := range c.Rules {
+			ok, err := r.applies(vars)
+			if err != nil {
+				c.Log.Errorf("Evaluating condition of rule %d failed for metric %q: %v", i+1, m.Name(), err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+
+			value, err := r.evaluate(vars)
+			if err != nil {
+				c.Log.Errorf("Evaluating rule %d failed for metric %q: %v", i+1, m.Name(), err)
+				continue
+			}
+
+			// Make the result available to the following rules
+			if r.Tag != "" {
+				v := value.(string)
+				m.AddTag(r.Tag, v)
+				tags[r.Tag] = v
+			} else {
+				m.AddField(r.Field, value)
+				fields[r.Field] = value
+			}
+		}
+	}
+	return in
+}
+
+func init() {
+	processors.Add("cel", func() telegraf.Processor {
+		return &CEL{}
+	})
+}
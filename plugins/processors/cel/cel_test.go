@@ -0,0 +1,233 @@
+package cel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    []rule
+		expected string
+	}{
+		{
+			name:     "no rules",
+			expected: "no rules configured",
+		},
+		{
+			name:     "no target",
+			rules:    []rule{{Expression: "1"}},
+			expected: "either 'field' or 'tag' must be set",
+		},
+		{
+			name:     "both targets",
+			rules:    []rule{{Field: "a", Tag: "b", Expression: "1"}},
+			expected: "only one of 'field' or 'tag' can be set",
+		},
+		{
+			name:     "invalid type",
+			rules:    []rule{{Field: "a", Expression: "1", Type: "complex"}},
+			expected: `invalid type "complex"`,
+		},
+		{
+			name:     "no expression",
+			rules:    []rule{{Field: "a"}},
+			expected: "'expression' must be set",
+		},
+		{
+			name:     "undeclared reference",
+			rules:    []rule{{Field: "a", Expression: "foo + 1"}},
+			expected: "undeclared reference to 'foo'",
+		},
+		{
+			name:     "non-scalar result",
+			rules:    []rule{{Field: "a", Expression: "[1, 2]"}},
+			expected: "expression must return a scalar value",
+		},
+		{
+			name:     "non-boolean condition",
+			rules:    []rule{{Field: "a", Expression: "1", Condition: "name"}},
+			expected: "condition must return a boolean",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &CEL{Rules: tt.rules, Log: testutil.Logger{}}
+			require.ErrorContains(t, plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestCases(t *testing.T) {
+	now := time.Unix(1714000000, 0)
+	tests := []struct {
+		name     string
+		rules    []rule
+		input    telegraf.Metric
+		expected telegraf.Metric
+	}{
+		{
+			name: "computed field",
+			rules: []rule{
+				{
+					Field:      "used_percent",
+					Expression: "double(fields.used) / double(fields.total) * 100.0",
+					Condition:  "'used' in fields && 'total' in fields",
+				},
+			},
+			input: metric.New("mem", map[string]string{}, map[string]interface{}{"used": 25, "total": 100}, now),
+			expected: metric.New(
+				"mem",
+				map[string]string{},
+				map[string]interface{}{"used": 25, "total": 100, "used_percent": 25.0},
+				now,
+			),
+		},
+		{
+			name: "condition not met",
+			rules: []rule{
+				{
+					Field:      "used_percent",
+					Expression: "double(fields.used) / double(fields.total) * 100.0",
+					Condition:  "'used' in fields && 'total' in fields",
+				},
+			},
+			input:    metric.New("mem", map[string]string{}, map[string]interface{}{"free": 25}, now),
+			expected: metric.New("mem", map[string]string{}, map[string]interface{}{"free": 25}, now),
+		},
+		{
+			name: "computed tag",
+			rules: []rule{
+				{Tag: "location", Expression: "tags.region + '-' + tags.zone"},
+			},
+			input: metric.New("mem", map[string]string{"region": "eu", "zone": "a"}, map[string]interface{}{"value": 1}, now),
+			expected: metric.New(
+				"mem",
+				map[string]string{"region": "eu", "zone": "a", "location": "eu-a"},
+				map[string]interface{}{"value": 1},
+				now,
+			),
+		},
+		{
+			name: "non-string tag",
+			rules: []rule{
+				{Tag: "large", Expression: "fields.value > 10"},
+			},
+			input: metric.New("test", map[string]string{}, map[string]interface{}{"value": 42}, now),
+			expected: metric.New(
+				"test",
+				map[string]string{"large": "true"},
+				map[string]interface{}{"value": 42},
+				now,
+			),
+		},
+		{
+			name: "type coercion",
+			rules: []rule{
+				{Field: "as_int", Expression: "fields.value * 2.5", Type: "int"},
+				{Field: "as_float", Expression: "fields.count", Type: "float"},
+				{Field: "as_string", Expression: "fields.count", Type: "string"},
+				{Field: "as_bool", Expression: "fields.count", Type: "bool"},
+				{Field: "as_uint", Expression: "'42'", Type: "uint"},
+			},
+			input: metric.New("test", map[string]string{}, map[string]interface{}{"value": 3.0, "count": 5}, now),
+			expected: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{
+					"value":     3.0,
+					"count":     5,
+					"as_int":    int64(7),
+					"as_float":  5.0,
+					"as_string": "5",
+					"as_bool":   true,
+					"as_uint":   uint64(42),
+				},
+				now,
+			),
+		},
+		{
+			name: "chained rules",
+			rules: []rule{
+				{Field: "total", Expression: "fields.a + fields.b"},
+				{Field: "double_total", Expression: "fields.total * 2"},
+				{Tag: "name", Expression: "name + '_' + string(fields.double_total)"},
+			},
+			input: metric.New("test", map[string]string{}, map[string]interface{}{"a": 1, "b": 2}, now),
+			expected: metric.New(
+				"test",
+				map[string]string{"name": "test_6"},
+				map[string]interface{}{"a": 1, "b": 2, "total": 3, "double_total": 6},
+				now,
+			),
+		},
+		{
+			name: "timestamp",
+			rules: []rule{
+				{Field: "ts", Expression: "time"},
+				{Field: "hour", Expression: "time.getHours()"},
+			},
+			input: metric.New("test", map[string]string{}, map[string]interface{}{"value": 1}, now),
+			expected: metric.New(
+				"test",
+				map[string]string{},
+				map[string]interface{}{"value": 1, "ts": now.UnixNano(), "hour": int64(now.UTC().Hour())},
+				now,
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &CEL{Rules: tt.rules, Log: testutil.Logger{}}
+			require.NoError(t, plugin.Init())
+
+			actual := plugin.Apply(tt.input)
+			testutil.RequireMetricsEqual(t, []telegraf.Metric{tt.expected}, actual)
+		})
+	}
+}
+
+func TestEvaluationError(t *testing.T) {
+	plugin := &CEL{
+		Rules: []rule{
+			{Field: "result", Expression: "fields.missing + 1"},
+			{Field: "other", Expression: "fields.value + 1"},
+		},
+		Log: testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	input := metric.New("test", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	expected := []telegraf.Metric{
+		metric.New("test", map[string]string{}, map[string]interface{}{"value": 1, "other": 2}, time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, plugin.Apply(input))
+}
+
+func TestTracking(t *testing.T) {
+	var delivered int
+	notify := func(telegraf.DeliveryInfo) { delivered++ }
+
+	input := metric.New("test", map[string]string{}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	tm, _ := metric.WithTracking(input, notify)
+
+	plugin := &CEL{
+		Rules: []rule{{Field: "result", Expression: "fields.value + 1"}},
+		Log:   testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	for _, m := range plugin.Apply(tm) {
+		m.Accept()
+	}
+	require.Eventually(t, func() bool { return delivered == 1 }, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,95 @@
+package cel
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/telegraf/internal"
+	common_cel "github.com/influxdata/telegraf/plugins/common/cel"
+)
+
+type rule struct {
+	Field      string `toml:"field"`
+	Tag        string `toml:"tag"`
+	Expression string `toml:"expression"`
+	Condition  string `toml:"condition"`
+	Type       string `toml:"type"`
+
+	program   *common_cel.Program
+	condition *common_cel.Program
+}
+
+func (r *rule) init(env *common_cel.Environment) error {
+	if r.Field == "" && r.Tag == "" {
+		return errors.New("either 'field' or 'tag' must be set")
+	}
+	if r.Field != "" && r.Tag != "" {
+		return errors.New("only one of 'field' or 'tag' can be set")
+	}
+
+	switch r.Type {
+	case "", "int", "uint", "float", "bool", "string":
+	default:
+		return fmt.Errorf("invalid type %q", r.Type)
+	}
+
+	if r.Expression == "" {
+		return errors.New("'expression' must be set")
+	}
+	program, err := env.Compile(r.Expression)
+	if err != nil {
+		return fmt.Errorf("compiling expression failed: %w", err)
+	}
+	if !program.IsScalar() {
+		return fmt.Errorf("expression must return a scalar value but returns %q", program.OutputType())
+	}
+	r.program = program
+
+	if r.Condition != "" {
+		program, err := env.Compile(r.Condition)
+		if err != nil {
+			return fmt.Errorf("compiling condition failed: %w", err)
+		}
+		if !program.IsBool() {
+			return fmt.Errorf("condition must return a boolean but returns %q", program.OutputType())
+		}
+		r.condition = program
+	}
+
+	return nil
+}
+
+func (r *rule) applies(vars map[string]interface{}) (bool, error) {
+	if r.condition == nil {
+		return true, nil
+	}
+
+	return r.condition.EvalBool(vars)
+}
+
+func (r *rule) evaluate(vars map[string]interface{}) (interface{}, error) {
+	value, err := r.program.Eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tags are always strings
+	if r.Tag != "" {
+		return internal.ToString(value)
+	}
+
+	switch r.Type {
+	case "int":
+		return internal.ToInt64(value)
+	case "uint":
+		return internal.ToUint64(value)
+	case "float":
+		return internal.ToFloat64(value)
+	case "bool":
+		return internal.ToBool(value)
+	case "string":
+		return internal.ToString(value)
+	}
+
+	return common_cel.FieldValue(value)
+}
@@ -0,0 +1,181 @@
+package dedup
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/filter"
+)
+
+// deadbandTag marks the deadband references in the persisted state
+const deadbandTag = "_dedup_deadband"
+
+// deadband configures report-by-exception for the matching fields
+type deadband struct {
+	Fields    []string        `toml:"fields"`
+	Absolute  float64         `toml:"absolute"`
+	Percent   float64         `toml:"percent"`
+	Heartbeat config.Duration `toml:"heartbeat"`
+
+	filter filter.Filter
+}
+
+// reference is the last forwarded value of a field under deadband control
+type reference struct {
+	value     interface{}
+	time      time.Time
+	heartbeat time.Duration
+}
+
+// deadbandSeries holds the references of all fields of a series
+type deadbandSeries struct {
+	name   string
+	tags   map[string]string
+	fields map[string]reference
+}
+
+func (db *deadband) init(interval config.Duration) error {
+	if len(db.Fields) == 0 {
+		return errors.New("missing 'fields'")
+	}
+
+	switch {
+	case db.Absolute < 0 || db.Percent < 0:
+		return errors.New("deadband must not be negative")
+	case db.Absolute > 0 && db.Percent > 0:
+		return errors.New("only one of 'absolute' or 'percent' can be set")
+	case db.Absolute == 0 && db.Percent == 0:
+		return errors.New("either 'absolute' or 'percent' must be set")
+	}
+
+	if db.Heartbeat < 0 {
+		return errors.New("heartbeat must not be negative")
+	}
+	if db.Heartbeat == 0 {
+		db.Heartbeat = interval
+	}
+
+	f, err := filter.Compile(db.Fields)
+	if err != nil {
+		return err
+	}
+	db.filter = f
+
+	return nil
+}
+
+// exceeded returns true if the value has to be forwarded because it left
+// the deadband around the reference or because the heartbeat is due.
+// Non-numeric values are forwarded on any change.
+func (db *deadband) exceeded(ref reference, value interface{}, t time.Time) bool {
+	if t.Sub(ref.time) >= time.Duration(db.Heartbeat) {
+		return true
+	}
+
+	current, ok := toFloat(value)
+	if !ok {
+		return value != ref.value
+	}
+	last, ok := toFloat(ref.value)
+	if !ok {
+		return true
+	}
+
+	delta := math.Abs(current - last)
+	if db.Absolute > 0 {
+		return delta > db.Absolute
+	}
+	return delta > db.Percent/100*math.Abs(last)
+}
+
+// deadband returns the deadband for the given field or nil if the field is
+// not under deadband control
+func (d *Dedup) deadband(field string) *deadband {
+	for i := range d.Deadbands {
+		if d.Deadbands[i].filter.Match(field) {
+			return &d.Deadbands[i]
+		}
+	}
+	return nil
+}
+
+// applyDeadbands removes all fields from the metric staying within their
+// deadband and updates the references of the forwarded fields. The function
+// returns true if the metric contains fields under deadband control.
+func (d *Dedup) applyDeadbands(m telegraf.Metric, id uint64) bool {
+	var controlled bool
+	var suppressed []string
+	for _, f := range m.FieldList() {
+		db := d.deadband(f.Key)
+		if db == nil {
+			continue
+		}
+		controlled = true
+
+		series, found := d.references[id]
+		if !found {
+			series = &deadbandSeries{name: m.Name(), tags: m.Tags(), fields: make(map[string]reference)}
+			d.references[id] = series
+		}
+		if ref, found := series.fields[f.Key]; found && !db.exceeded(ref, f.Value, m.Time()) {
+			suppressed = append(suppressed, f.Key)
+			continue
+		}
+		series.fields[f.Key] = reference{value: f.Value, time: m.Time(), heartbeat: time.Duration(db.Heartbeat)}
+	}
+
+	for _, key := range suppressed {
+		m.RemoveField(key)
+	}
+	return controlled
+}
+
+// cleanupReferences removes the references with due heartbeat as the next
+// value will be forwarded anyway
+func (d *Dedup) cleanupReferences() {
+	for id, series := range d.references {
+		for field, ref := range series.fields {
+			if time.Since(ref.time) >= ref.heartbeat {
+				delete(series.fields, field)
+			}
+		}
+		if len(series.fields) == 0 {
+			delete(d.references, id)
+		}
+	}
+}
+
+// restoreReference adds the persisted references contained in the metric
+func (d *Dedup) restoreReference(m telegraf.Metric) {
+	m.RemoveTag(deadbandTag)
+
+	id := m.HashID()
+	for _, f := range m.FieldList() {
+		// Ignore fields no longer under deadband control
+		db := d.deadband(f.Key)
+		if db == nil {
+			continue
+		}
+		series, found := d.references[id]
+		if !found {
+			series = &deadbandSeries{name: m.Name(), tags: m.Tags(), fields: make(map[string]reference)}
+			d.references[id] = series
+		}
+		series.fields[f.Key] = reference{value: f.Value, time: m.Time(), heartbeat: time.Duration(db.Heartbeat)}
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/processors"
 	serializers_influx "github.com/influxdata/telegraf/plugins/serializers/influx"
@@ -18,9 +19,12 @@ var sampleConfig string
 
 type Dedup struct {
 	DedupInterval config.Duration `toml:"dedup_interval"`
+	Deadbands     []deadband      `toml:"deadband"`
 	FlushTime     time.Time
 	Cache         map[uint64]telegraf.Metric
 	Log           telegraf.Logger `toml:"-"`
+
+	references map[uint64]*deadbandSeries
 }
 
 // Remove expired items from cache
@@ -37,6 +41,7 @@ func (d *Dedup) cleanup() {
 		}
 	}
 	d.Cache = keep
+	d.cleanupReferences()
 }
 
 // Save item to cache
@@ -49,65 +54,22 @@ func (*Dedup) SampleConfig() string {
 	return sampleConfig
 }
 
+func (d *Dedup) Init() error {
+	for i := range d.Deadbands {
+		if err := d.Deadbands[i].init(d.DedupInterval); err != nil {
+			return fmt.Errorf("initialization of deadband %d failed: %w", i+1, err)
+		}
+	}
+	d.references = make(map[uint64]*deadbandSeries)
+
+	return nil
+}
+
 // main processing method
 func (d *Dedup) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
 	idx := 0
 	for _, metric := range metrics {
-		id := metric.HashID()
-		m, ok := d.Cache[id]
-
-		// If not in cache then just save it
-		if !ok {
-			d.save(metric, id)
-			metrics[idx] = metric
-			idx++
-			continue
-		}
-
-		// If cache item has expired then refresh it
-		if time.Since(m.Time()) >= time.Duration(d.DedupInterval) {
-			d.save(metric, id)
-			metrics[idx] = metric
-			idx++
-			continue
-		}
-
-		// For each field compare value with the cached one
-		changed := false
-		added := false
-		sametime := metric.Time() == m.Time()
-		for _, f := range metric.FieldList() {
-			if value, ok := m.GetField(f.Key); ok {
-				if value != f.Value {
-					changed = true
-					break
-				}
-			} else if sametime {
-				// This field isn't in the cached metric but it's the
-				// same series and timestamp. Merge it into the cached
-				// metric.
-
-				// Metrics have a ValueType that applies to all values
-				// in the metric. If an input needs to produce values
-				// with different ValueTypes but the same timestamp,
-				// they have to produce multiple metrics. (See the
-				// system input for an example.) In this case, dedup
-				// ignores the ValueTypes of the metrics and merges
-				// the fields into one metric for the dup check.
-
-				m.AddField(f.Key, f.Value)
-				added = true
-			}
-		}
-		// If any field value has changed then refresh the cache
-		if changed {
-			d.save(metric, id)
-			metrics[idx] = metric
-			idx++
-			continue
-		}
-
-		if sametime && added {
+		if d.process(metric) {
 			metrics[idx] = metric
 			idx++
 			continue
@@ -121,12 +83,108 @@ func (d *Dedup) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
 	return metrics
 }
 
+// process returns true if the metric should be forwarded. Fields under
+// deadband control are checked individually, all other fields are only
+// forwarded if any of them changed.
+func (d *Dedup) process(metric telegraf.Metric) bool {
+	id := metric.HashID()
+	if len(d.Deadbands) == 0 || !d.applyDeadbands(metric, id) {
+		return !d.duplicate(metric, id)
+	}
+
+	// Check the fields not under deadband control on a copy without
+	// the controlled fields
+	rest := metric
+	if wm, ok := metric.(telegraf.UnwrappableMetric); ok {
+		rest = wm.Unwrap()
+	}
+	rest = rest.Copy()
+	for _, f := range metric.FieldList() {
+		if d.deadband(f.Key) != nil {
+			rest.RemoveField(f.Key)
+		}
+	}
+	if len(rest.FieldList()) > 0 && d.duplicate(rest, id) {
+		for _, f := range rest.FieldList() {
+			metric.RemoveField(f.Key)
+		}
+	}
+
+	return len(metric.FieldList()) > 0
+}
+
+// duplicate returns true if all field values of the metric are repetitions
+// of the cached values
+func (d *Dedup) duplicate(metric telegraf.Metric, id uint64) bool {
+	m, ok := d.Cache[id]
+
+	// If not in cache then just save it
+	if !ok {
+		d.save(metric, id)
+		return false
+	}
+
+	// If cache item has expired then refresh it
+	if time.Since(m.Time()) >= time.Duration(d.DedupInterval) {
+		d.save(metric, id)
+		return false
+	}
+
+	// For each field compare value with the cached one
+	changed := false
+	added := false
+	sametime := metric.Time() == m.Time()
+	for _, f := range metric.FieldList() {
+		if value, ok := m.GetField(f.Key); ok {
+			if value != f.Value {
+				changed = true
+				break
+			}
+		} else if sametime {
+			// This field isn't in the cached metric but it's the
+			// same series and timestamp. Merge it into the cached
+			// metric.
+
+			// Metrics have a ValueType that applies to all values
+			// in the metric. If an input needs to produce values
+			// with different ValueTypes but the same timestamp,
+			// they have to produce multiple metrics. (See the
+			// system input for an example.) In this case, dedup
+			// ignores the ValueTypes of the metrics and merges
+			// the fields into one metric for the dup check.
+
+			m.AddField(f.Key, f.Value)
+			added = true
+		}
+	}
+	// If any field value has changed then refresh the cache
+	if changed {
+		d.save(metric, id)
+		return false
+	}
+
+	return !sametime || !added
+}
+
 func (d *Dedup) GetState() interface{} {
 	s := &serializers_influx.Serializer{}
-	v := make([]telegraf.Metric, 0, len(d.Cache))
+	v := make([]telegraf.Metric, 0, len(d.Cache)+len(d.references))
 	for _, value := range d.Cache {
 		v = append(v, value)
 	}
+
+	// Add the deadband references as separate metrics per field as the
+	// fields might have been forwarded at different times
+	for _, series := range d.references {
+		for field, ref := range series.fields {
+			tags := make(map[string]string, len(series.tags)+1)
+			for k, v := range series.tags {
+				tags[k] = v
+			}
+			tags[deadbandTag] = "true"
+			v = append(v, metric.New(series.name, tags, map[string]interface{}{field: ref.value}, ref.time))
+		}
+	}
 	state, err := s.SerializeBatch(v)
 	if err != nil {
 		d.Log.Errorf("dedup processor failed to serialize metric batch: %v", err)
@@ -144,9 +202,19 @@ func (d *Dedup) SetState(state interface{}) error {
 		return fmt.Errorf("state has wrong type %T", state)
 	}
 	metrics, err := p.Parse(data)
-	if err == nil {
-		d.Apply(metrics...)
+	if err != nil {
+		return nil
+	}
+
+	cached := make([]telegraf.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		if m.HasTag(deadbandTag) {
+			d.restoreReference(m)
+		} else {
+			cached = append(cached, m)
+		}
 	}
+	d.Apply(cached...)
 	return nil
 }
 
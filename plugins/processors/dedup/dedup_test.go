@@ -530,3 +530,200 @@ func TestStatePersistence(t *testing.T) {
 	}
 	require.Len(t, actualState, expectedLen)
 }
+
+func TestDeadbandInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		deadband deadband
+		expected string
+	}{
+		{
+			name:     "no fields",
+			deadband: deadband{Absolute: 1},
+			expected: "missing 'fields'",
+		},
+		{
+			name:     "no deadband",
+			deadband: deadband{Fields: []string{"*"}},
+			expected: "either 'absolute' or 'percent' must be set",
+		},
+		{
+			name:     "both deadbands",
+			deadband: deadband{Fields: []string{"*"}, Absolute: 1, Percent: 1},
+			expected: "only one of 'absolute' or 'percent' can be set",
+		},
+		{
+			name:     "negative deadband",
+			deadband: deadband{Fields: []string{"*"}, Absolute: -1},
+			expected: "deadband must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Dedup{
+				DedupInterval: config.Duration(10 * time.Minute),
+				Deadbands:     []deadband{tt.deadband},
+				Cache:         make(map[uint64]telegraf.Metric),
+			}
+			require.ErrorContains(t, plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestDeadband(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		deadbands []deadband
+		input     []telegraf.Metric
+		expected  []telegraf.Metric
+	}{
+		{
+			name:      "absolute",
+			deadbands: []deadband{{Fields: []string{"temp*"}, Absolute: 0.5}},
+			input: []telegraf.Metric{
+				metric.New("m", map[string]string{}, map[string]interface{}{"temperature": 20.0}, now.Add(-4*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"temperature": 20.3}, now.Add(-3*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"temperature": 20.5}, now.Add(-2*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"temperature": 20.6}, now.Add(-1*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"temperature": 19.9}, now),
+			},
+			expected: []telegraf.Metric{
+				metric.New("m", map[string]string{}, map[string]interface{}{"temperature": 20.0}, now.Add(-4*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"temperature": 20.6}, now.Add(-1*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"temperature": 19.9}, now),
+			},
+		},
+		{
+			name:      "percent",
+			deadbands: []deadband{{Fields: []string{"pressure"}, Percent: 10}},
+			input: []telegraf.Metric{
+				metric.New("m", map[string]string{}, map[string]interface{}{"pressure": 100}, now.Add(-3*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"pressure": 109}, now.Add(-2*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"pressure": 111}, now.Add(-1*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"pressure": 101}, now),
+			},
+			expected: []telegraf.Metric{
+				metric.New("m", map[string]string{}, map[string]interface{}{"pressure": 100}, now.Add(-3*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"pressure": 111}, now.Add(-1*time.Second)),
+			},
+		},
+		{
+			name:      "heartbeat",
+			deadbands: []deadband{{Fields: []string{"*"}, Absolute: 1, Heartbeat: config.Duration(time.Minute)}},
+			input: []telegraf.Metric{
+				metric.New("m", map[string]string{}, map[string]interface{}{"value": 1}, now.Add(-90*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"value": 1}, now.Add(-60*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"value": 1}, now.Add(-30*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"value": 1}, now),
+			},
+			expected: []telegraf.Metric{
+				metric.New("m", map[string]string{}, map[string]interface{}{"value": 1}, now.Add(-90*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"value": 1}, now.Add(-30*time.Second)),
+			},
+		},
+		{
+			name:      "per series",
+			deadbands: []deadband{{Fields: []string{"value"}, Absolute: 1}},
+			input: []telegraf.Metric{
+				metric.New("m", map[string]string{"id": "a"}, map[string]interface{}{"value": 1}, now.Add(-1*time.Second)),
+				metric.New("m", map[string]string{"id": "b"}, map[string]interface{}{"value": 5}, now.Add(-1*time.Second)),
+				metric.New("m", map[string]string{"id": "a"}, map[string]interface{}{"value": 1.5}, now),
+				metric.New("m", map[string]string{"id": "b"}, map[string]interface{}{"value": 1.5}, now),
+			},
+			expected: []telegraf.Metric{
+				metric.New("m", map[string]string{"id": "a"}, map[string]interface{}{"value": 1}, now.Add(-1*time.Second)),
+				metric.New("m", map[string]string{"id": "b"}, map[string]interface{}{"value": 5}, now.Add(-1*time.Second)),
+				metric.New("m", map[string]string{"id": "b"}, map[string]interface{}{"value": 1.5}, now),
+			},
+		},
+		{
+			name:      "non-numeric",
+			deadbands: []deadband{{Fields: []string{"*"}, Absolute: 1}},
+			input: []telegraf.Metric{
+				metric.New("m", map[string]string{}, map[string]interface{}{"state": "on"}, now.Add(-2*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"state": "on"}, now.Add(-1*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"state": "off"}, now),
+			},
+			expected: []telegraf.Metric{
+				metric.New("m", map[string]string{}, map[string]interface{}{"state": "on"}, now.Add(-2*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"state": "off"}, now),
+			},
+		},
+		{
+			name:      "mixed fields",
+			deadbands: []deadband{{Fields: []string{"temperature"}, Absolute: 1}},
+			input: []telegraf.Metric{
+				metric.New("m", map[string]string{}, map[string]interface{}{"temperature": 20.0, "status": 1}, now.Add(-3*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"temperature": 20.5, "status": 1}, now.Add(-2*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"temperature": 21.5, "status": 1}, now.Add(-1*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"temperature": 21.0, "status": 2}, now),
+			},
+			expected: []telegraf.Metric{
+				metric.New("m", map[string]string{}, map[string]interface{}{"temperature": 20.0, "status": 1}, now.Add(-3*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"temperature": 21.5}, now.Add(-1*time.Second)),
+				metric.New("m", map[string]string{}, map[string]interface{}{"status": 2}, now),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Dedup{
+				DedupInterval: config.Duration(10 * time.Minute),
+				Deadbands:     tt.deadbands,
+				FlushTime:     now.Add(-1 * time.Second),
+				Cache:         make(map[uint64]telegraf.Metric),
+			}
+			require.NoError(t, plugin.Init())
+
+			var actual []telegraf.Metric
+			for _, m := range tt.input {
+				actual = append(actual, plugin.Apply(m)...)
+			}
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestDeadbandStatePersistence(t *testing.T) {
+	now := time.Now()
+
+	// Configure the plugin
+	newPlugin := func() *Dedup {
+		return &Dedup{
+			DedupInterval: config.Duration(10 * time.Hour), // use a long interval to avoid flaky tests
+			Deadbands:     []deadband{{Fields: []string{"temperature"}, Absolute: 1}},
+			FlushTime:     now.Add(-1 * time.Second),
+			Cache:         make(map[uint64]telegraf.Metric),
+		}
+	}
+	plugin := newPlugin()
+	require.NoError(t, plugin.Init())
+
+	input := []telegraf.Metric{
+		metric.New("m", map[string]string{"id": "a"}, map[string]interface{}{"temperature": 20.0, "status": 1}, now.Add(-1*time.Second)),
+	}
+	testutil.RequireMetricsEqual(t, input, plugin.Apply(input[0].Copy()))
+
+	// Restore the state in a new instance and check that the reference
+	// values are used
+	var pi telegraf.StatefulPlugin = plugin
+	state := pi.GetState()
+	require.Contains(t, string(state.([]byte)), "m,_dedup_deadband=true,id=a temperature=20")
+
+	restored := newPlugin()
+	require.NoError(t, restored.Init())
+	require.NoError(t, restored.SetState(state))
+
+	input = []telegraf.Metric{
+		metric.New("m", map[string]string{"id": "a"}, map[string]interface{}{"temperature": 20.5, "status": 1}, now),
+		metric.New("m", map[string]string{"id": "a"}, map[string]interface{}{"temperature": 21.5, "status": 1}, now),
+	}
+	expected := []telegraf.Metric{
+		metric.New("m", map[string]string{"id": "a"}, map[string]interface{}{"temperature": 21.5}, now),
+	}
+	testutil.RequireMetricsEqual(t, expected, restored.Apply(input...))
+}
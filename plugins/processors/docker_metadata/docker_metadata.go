@@ -0,0 +1,418 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package docker_metadata
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/docker"
+	common "github.com/influxdata/telegraf/plugins/common/docker"
+	"github.com/influxdata/telegraf/plugins/common/tls"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+const (
+	defaultEndpoint = "unix:///var/run/docker.sock"
+
+	// Length of the short container ID shown by 'docker ps'
+	shortIDLength = 12
+
+	labelPodName   = "io.kubernetes.pod.name"
+	labelNamespace = "io.kubernetes.pod.namespace"
+
+	// Time to cache the container of a process
+	pidCacheTTL = time.Minute
+)
+
+type DockerMetadata struct {
+	Endpoint       string          `toml:"endpoint"`
+	PIDTag         string          `toml:"pid_tag"`
+	PIDField       string          `toml:"pid_field"`
+	ContainerIDTag string          `toml:"container_id_tag"`
+	IPTag          string          `toml:"ip_tag"`
+	HostProc       string          `toml:"host_proc"`
+	IncludeLabels  []string        `toml:"include_labels"`
+	Timeout        config.Duration `toml:"timeout"`
+	RetryInterval  config.Duration `toml:"retry_interval"`
+	Log            telegraf.Logger `toml:"-"`
+	tls.ClientConfig
+
+	labelFilter filter.Filter
+	pids        *common.PIDCache
+	client      *client.Client
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+
+	containers map[string]*containerInfo
+	ips        map[string]*containerInfo
+	sync.RWMutex
+}
+
+type containerInfo struct {
+	id      string
+	name    string
+	image   string
+	version string
+	labels  map[string]string
+	ips     []string
+}
+
+func (*DockerMetadata) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *DockerMetadata) Init() error {
+	if d.PIDTag == "" && d.PIDField == "" && d.ContainerIDTag == "" && d.IPTag == "" {
+		return errors.New("at least one of 'pid_tag', 'pid_field', 'container_id_tag' or 'ip_tag' must be set")
+	}
+
+	if d.Endpoint == "" {
+		d.Endpoint = defaultEndpoint
+	}
+	if d.HostProc == "" {
+		d.HostProc = internal.GetProcPath()
+	}
+
+	var err error
+	if d.labelFilter, err = filter.Compile(d.IncludeLabels); err != nil {
+		return fmt.Errorf("creating label filter failed: %w", err)
+	}
+
+	d.pids = common.NewPIDCache(d.HostProc, pidCacheTTL)
+	d.containers = make(map[string]*containerInfo)
+	d.ips = make(map[string]*containerInfo)
+
+	return nil
+}
+
+func (d *DockerMetadata) Start(telegraf.Accumulator) error {
+	c, err := d.newClient()
+	if err != nil {
+		return fmt.Errorf("creating client failed: %w", err)
+	}
+	d.client = c
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+
+	// Subscribe to the events before listing the containers to not miss any
+	// container started in between
+	messages, errs := d.subscribe(ctx)
+	if err := d.refresh(ctx); err != nil {
+		d.Log.Errorf("Listing containers failed: %v", err)
+	}
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.watch(ctx, messages, errs)
+	}()
+
+	return nil
+}
+
+func (d *DockerMetadata) Add(m telegraf.Metric, acc telegraf.Accumulator) error {
+	if info := d.lookup(m); info != nil {
+		d.enrich(m, info)
+	}
+	acc.AddMetric(m)
+	return nil
+}
+
+func (d *DockerMetadata) Stop() {
+	if d.cancel != nil {
+		d.cancel()
+	}
+	d.wg.Wait()
+
+	if d.client != nil {
+		if err := d.client.Close(); err != nil {
+			d.Log.Errorf("Closing client failed: %v", err)
+		}
+	}
+}
+
+func (d *DockerMetadata) newClient() (*client.Client, error) {
+	if d.Endpoint == "ENV" {
+		return client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	}
+
+	tlsConfig, err := d.ClientConfig.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	httpClient := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+	}
+
+	return client.NewClientWithOpts(
+		client.WithHTTPClient(httpClient),
+		client.WithAPIVersionNegotiation(),
+		client.WithHost(d.Endpoint),
+	)
+}
+
+func (d *DockerMetadata) subscribe(ctx context.Context) (<-chan events.Message, <-chan error) {
+	options := events.ListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("type", string(events.ContainerEventType)),
+			filters.Arg("event", string(events.ActionStart)),
+			filters.Arg("event", string(events.ActionDestroy)),
+		),
+	}
+	return d.client.Events(ctx, options)
+}
+
+func (d *DockerMetadata) watch(ctx context.Context, messages <-chan events.Message, errs <-chan error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-messages:
+			d.handle(ctx, msg)
+		case err := <-errs:
+			if ctx.Err() != nil {
+				return
+			}
+			d.Log.Errorf("Receiving events failed: %v", err)
+
+			// Resubscribe and list the containers again as we might have
+			// missed events in the meantime
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Duration(d.RetryInterval)):
+			}
+			messages, errs = d.subscribe(ctx)
+			if err := d.refresh(ctx); err != nil {
+				d.Log.Errorf("Listing containers failed: %v", err)
+			}
+		}
+	}
+}
+
+func (d *DockerMetadata) handle(ctx context.Context, msg events.Message) {
+	switch msg.Action {
+	case events.ActionStart:
+		tctx, cancel := context.WithTimeout(ctx, time.Duration(d.Timeout))
+		defer cancel()
+		resp, err := d.client.ContainerInspect(tctx, msg.Actor.ID)
+		if err != nil {
+			d.Log.Errorf("Inspecting container %q failed: %v", msg.Actor.ID, err)
+			return
+		}
+		d.store(fromInspect(resp))
+	case events.ActionDestroy:
+		d.remove(msg.Actor.ID)
+		d.pids.Evict(msg.Actor.ID)
+	}
+}
+
+func (d *DockerMetadata) refresh(ctx context.Context) error {
+	tctx, cancel := context.WithTimeout(ctx, time.Duration(d.Timeout))
+	defer cancel()
+	list, err := d.client.ContainerList(tctx, container.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, c := range list {
+		d.store(fromSummary(c))
+	}
+	return nil
+}
+
+func (d *DockerMetadata) store(info *containerInfo) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.containers[info.id] = info
+	if len(info.id) > shortIDLength {
+		d.containers[info.id[:shortIDLength]] = info
+	}
+	for _, ip := range info.ips {
+		d.ips[ip] = info
+	}
+}
+
+func (d *DockerMetadata) remove(id string) {
+	d.Lock()
+	defer d.Unlock()
+
+	info, found := d.containers[id]
+	if !found {
+		return
+	}
+	delete(d.containers, info.id)
+	if len(info.id) > shortIDLength {
+		delete(d.containers, info.id[:shortIDLength])
+	}
+	for _, ip := range info.ips {
+		// The address might have been reused by another container already
+		if d.ips[ip] == info {
+			delete(d.ips, ip)
+		}
+	}
+}
+
+// lookup returns the container for the metric
+func (d *DockerMetadata) lookup(m telegraf.Metric) *containerInfo {
+	d.RLock()
+	defer d.RUnlock()
+
+	if d.ContainerIDTag != "" {
+		if id, found := m.GetTag(d.ContainerIDTag); found {
+			if info, found := d.containers[common.TrimContainerRuntime(id)]; found {
+				return info
+			}
+		}
+	}
+
+	if d.PIDTag != "" || d.PIDField != "" {
+		if pid, found := d.pid(m); found {
+			id, err := d.pids.ContainerID(pid)
+			if err != nil {
+				d.Log.Debugf("Getting container of process %d failed: %v", pid, err)
+			} else if info, found := d.containers[id]; found && id != "" {
+				return info
+			}
+		}
+	}
+
+	if d.IPTag != "" {
+		if ip, found := m.GetTag(d.IPTag); found {
+			return d.ips[ip]
+		}
+	}
+
+	return nil
+}
+
+func (d *DockerMetadata) pid(m telegraf.Metric) (int64, bool) {
+	if d.PIDTag != "" {
+		if v, found := m.GetTag(d.PIDTag); found {
+			pid, err := strconv.ParseInt(v, 10, 64)
+			return pid, err == nil
+		}
+	}
+	if d.PIDField != "" {
+		if v, found := m.GetField(d.PIDField); found {
+			pid, err := internal.ToInt64(v)
+			return pid, err == nil
+		}
+	}
+	return 0, false
+}
+
+func (d *DockerMetadata) enrich(m telegraf.Metric, info *containerInfo) {
+	m.AddTag("container_name", info.name)
+	m.AddTag("container_image", info.image)
+	m.AddTag("container_version", info.version)
+
+	// Containers started by Kubernetes carry the pod information as labels
+	if name, found := info.labels[labelPodName]; found {
+		m.AddTag("pod_name", name)
+	}
+	if namespace, found := info.labels[labelNamespace]; found {
+		m.AddTag("namespace", namespace)
+	}
+
+	for key, value := range info.labels {
+		if d.labelFilter != nil && d.labelFilter.Match(key) {
+			m.AddTag("label_"+key, value)
+		}
+	}
+}
+
+func fromSummary(c container.Summary) *containerInfo {
+	var name string
+	if len(c.Names) > 0 {
+		name = strings.TrimPrefix(c.Names[0], "/")
+	}
+
+	var ips []string
+	if c.NetworkSettings != nil {
+		ips = endpointIPs(c.NetworkSettings.Networks)
+	}
+
+	return newContainerInfo(c.ID, name, c.Image, c.Labels, ips)
+}
+
+func fromInspect(c container.InspectResponse) *containerInfo {
+	var id, name string
+	if c.ContainerJSONBase != nil {
+		id = c.ID
+		name = strings.TrimPrefix(c.Name, "/")
+	}
+
+	var image string
+	var labels map[string]string
+	if c.Config != nil {
+		image = c.Config.Image
+		labels = c.Config.Labels
+	}
+
+	var ips []string
+	if c.NetworkSettings != nil {
+		ips = endpointIPs(c.NetworkSettings.Networks)
+	}
+
+	return newContainerInfo(id, name, image, labels, ips)
+}
+
+func newContainerInfo(id, name, image string, labels map[string]string, ips []string) *containerInfo {
+	imageName, imageVersion := docker.ParseImage(image)
+	return &containerInfo{
+		id:      id,
+		name:    name,
+		image:   imageName,
+		version: imageVersion,
+		labels:  labels,
+		ips:     ips,
+	}
+}
+
+func endpointIPs(networks map[string]*network.EndpointSettings) []string {
+	ips := make([]string, 0, len(networks))
+	for _, n := range networks {
+		if n == nil {
+			continue
+		}
+		if n.IPAddress != "" {
+			ips = append(ips, n.IPAddress)
+		}
+		if n.GlobalIPv6Address != "" {
+			ips = append(ips, n.GlobalIPv6Address)
+		}
+	}
+	return ips
+}
+
+func init() {
+	processors.AddStreaming("docker_metadata", func() telegraf.StreamingProcessor {
+		return &DockerMetadata{
+			Timeout:       config.Duration(5 * time.Second),
+			RetryInterval: config.Duration(5 * time.Second),
+		}
+	})
+}
@@ -0,0 +1,260 @@
+package docker_metadata
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/network"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+const (
+	containerWeb    = "3f4b2c1d9e8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f4a3b2c"
+	containerDB     = "0a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f9"
+	containerWorker = "ffeeddccbbaa99887766554433221100ffeeddccbbaa99887766554433221100"
+)
+
+func TestInitFail(t *testing.T) {
+	plugin := &DockerMetadata{Log: testutil.Logger{}}
+	require.ErrorContains(t, plugin.Init(), "at least one of")
+
+	plugin = &DockerMetadata{IPTag: "ip", IncludeLabels: []string{"a[b"}, Log: testutil.Logger{}}
+	require.ErrorContains(t, plugin.Init(), "creating label filter failed")
+}
+
+func TestEnrich(t *testing.T) {
+	server := newDockerServer(t, []container.Summary{
+		{
+			ID:     containerWeb,
+			Names:  []string{"/web"},
+			Image:  "nginx:1.27",
+			Labels: map[string]string{"com.example.team": "frontend", "other": "ignored"},
+			NetworkSettings: &container.NetworkSettingsSummary{
+				Networks: map[string]*network.EndpointSettings{
+					"bridge": {IPAddress: "172.17.0.2", GlobalIPv6Address: "fd00::2"},
+				},
+			},
+		},
+		{
+			ID:    containerDB,
+			Names: []string{"/k8s_postgres_db-0_shop_1234_0"},
+			Image: "registry.example.org:5000/postgres",
+			Labels: map[string]string{
+				"io.kubernetes.pod.name":      "db-0",
+				"io.kubernetes.pod.namespace": "shop",
+			},
+		},
+	}, nil)
+	defer server.Close()
+
+	// Setup a process running in the web container
+	procfs := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(procfs, "1234"), 0750))
+	cgroup := "0::/system.slice/docker-" + containerWeb + ".scope\n"
+	require.NoError(t, os.WriteFile(filepath.Join(procfs, "1234", "cgroup"), []byte(cgroup), 0600))
+
+	plugin := &DockerMetadata{
+		Endpoint:       server.endpoint(),
+		PIDTag:         "pid",
+		ContainerIDTag: "container_id",
+		IPTag:          "ip",
+		HostProc:       procfs,
+		IncludeLabels:  []string{"com.example.*"},
+		Timeout:        config.Duration(5 * time.Second),
+		RetryInterval:  config.Duration(100 * time.Millisecond),
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	webTags := func(tags map[string]string) map[string]string {
+		tags["container_name"] = "web"
+		tags["container_image"] = "nginx"
+		tags["container_version"] = "1.27"
+		tags["label_com.example.team"] = "frontend"
+		return tags
+	}
+
+	input := []telegraf.Metric{
+		metric.New("procstat", map[string]string{"pid": "1234"}, map[string]interface{}{"cpu": 1.0}, time.Unix(0, 0)),
+		metric.New("statsd", map[string]string{"container_id": containerWeb[:12]}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		metric.New("prometheus", map[string]string{"ip": "fd00::2"}, map[string]interface{}{"value": 2}, time.Unix(0, 0)),
+		metric.New("statsd", map[string]string{"container_id": "docker://" + containerDB}, map[string]interface{}{"value": 3}, time.Unix(0, 0)),
+		metric.New("prometheus", map[string]string{"ip": "172.17.0.3"}, map[string]interface{}{"value": 4}, time.Unix(0, 0)),
+		metric.New("procstat", map[string]string{"pid": "4321"}, map[string]interface{}{"cpu": 2.0}, time.Unix(0, 0)),
+	}
+	expected := []telegraf.Metric{
+		metric.New("procstat", webTags(map[string]string{"pid": "1234"}), map[string]interface{}{"cpu": 1.0}, time.Unix(0, 0)),
+		metric.New("statsd", webTags(map[string]string{"container_id": containerWeb[:12]}), map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		metric.New("prometheus", webTags(map[string]string{"ip": "fd00::2"}), map[string]interface{}{"value": 2}, time.Unix(0, 0)),
+		metric.New(
+			"statsd",
+			map[string]string{
+				"container_id":      "docker://" + containerDB,
+				"container_name":    "k8s_postgres_db-0_shop_1234_0",
+				"container_image":   "registry.example.org:5000/postgres",
+				"container_version": "unknown",
+				"pod_name":          "db-0",
+				"namespace":         "shop",
+			},
+			map[string]interface{}{"value": 3},
+			time.Unix(0, 0),
+		),
+		metric.New("prometheus", map[string]string{"ip": "172.17.0.3"}, map[string]interface{}{"value": 4}, time.Unix(0, 0)),
+		metric.New("procstat", map[string]string{"pid": "4321"}, map[string]interface{}{"cpu": 2.0}, time.Unix(0, 0)),
+	}
+
+	for _, m := range input {
+		require.NoError(t, plugin.Add(m, &acc))
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.SortMetrics())
+}
+
+func TestEvents(t *testing.T) {
+	server := newDockerServer(t, nil, map[string]container.InspectResponse{
+		containerWorker: {
+			ContainerJSONBase: &container.ContainerJSONBase{ID: containerWorker, Name: "/worker"},
+			Config:            &container.Config{Image: "busybox:latest"},
+			NetworkSettings: &container.NetworkSettings{
+				Networks: map[string]*network.EndpointSettings{
+					"bridge": {IPAddress: "172.17.0.5"},
+				},
+			},
+		},
+	})
+	defer server.Close()
+
+	plugin := &DockerMetadata{
+		Endpoint:      server.endpoint(),
+		IPTag:         "ip",
+		Timeout:       config.Duration(5 * time.Second),
+		RetryInterval: config.Duration(100 * time.Millisecond),
+		Log:           testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	// Metrics of unknown containers pass unmodified
+	input := metric.New("prometheus", map[string]string{"ip": "172.17.0.5"}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	require.NoError(t, plugin.Add(input.Copy(), &acc))
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{input}, acc.GetTelegrafMetrics())
+
+	// Start the container and wait for the cache to pick it up
+	server.send(events.ActionStart, containerWorker)
+	require.Eventually(t, func() bool {
+		return plugin.lookup(input) != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	acc.ClearMetrics()
+	expected := metric.New(
+		"prometheus",
+		map[string]string{
+			"ip":                "172.17.0.5",
+			"container_name":    "worker",
+			"container_image":   "busybox",
+			"container_version": "latest",
+		},
+		map[string]interface{}{"value": 1},
+		time.Unix(0, 0),
+	)
+	require.NoError(t, plugin.Add(input.Copy(), &acc))
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{expected}, acc.GetTelegrafMetrics())
+
+	// Remove the container again
+	server.send(events.ActionDestroy, containerWorker)
+	require.Eventually(t, func() bool {
+		return plugin.lookup(input) == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// dockerServer is a minimal stand-in for the Docker engine API serving the
+// container list, inspect and event requests
+type dockerServer struct {
+	*httptest.Server
+
+	events chan []byte
+}
+
+var versionPrefix = regexp.MustCompile(`^/v[0-9.]+`)
+
+func newDockerServer(t *testing.T, list []container.Summary, inspect map[string]container.InspectResponse) *dockerServer {
+	s := &dockerServer{events: make(chan []byte, 10)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Api-Version", "1.45")
+		w.Header().Set("Content-Type", "application/json")
+
+		path := versionPrefix.ReplaceAllString(r.URL.Path, "")
+		switch {
+		case path == "/_ping":
+			w.WriteHeader(http.StatusOK)
+		case path == "/containers/json":
+			if err := json.NewEncoder(w).Encode(list); err != nil {
+				t.Error(err)
+			}
+		case strings.HasPrefix(path, "/containers/") && strings.HasSuffix(path, "/json"):
+			id := strings.TrimSuffix(strings.TrimPrefix(path, "/containers/"), "/json")
+			resp, found := inspect[id]
+			if !found {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if err := json.NewEncoder(w).Encode(resp); err != nil {
+				t.Error(err)
+			}
+		case path == "/events":
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			for {
+				select {
+				case <-r.Context().Done():
+					return
+				case event := <-s.events:
+					if _, err := w.Write(event); err != nil {
+						return
+					}
+					w.(http.Flusher).Flush()
+				}
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return s
+}
+
+func (s *dockerServer) endpoint() string {
+	return "tcp://" + strings.TrimPrefix(s.URL, "http://")
+}
+
+func (s *dockerServer) send(action events.Action, id string) {
+	msg := events.Message{
+		Type:   events.ContainerEventType,
+		Action: action,
+		Actor:  events.Actor{ID: id},
+	}
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	s.events <- append(buf, '\n')
+}
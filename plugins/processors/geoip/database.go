@@ -0,0 +1,88 @@
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// record contains the data of interest of all supported database types.
+// The MaxMind and DB-IP city, country and ASN databases use the same keys.
+type record struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Country struct {
+		ISOCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Location struct {
+		Latitude  *float64 `maxminddb:"latitude"`
+		Longitude *float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+	ASN          uint   `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+// database is a MMDB database file reopened on changes
+type database struct {
+	path    string
+	modTime time.Time
+	size    int64
+	reader  *maxminddb.Reader
+}
+
+func openDatabase(path string) (*database, error) {
+	db := &database{path: path}
+	if _, err := db.reload(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// reload reopens the database if the file changed since the last load and
+// returns true in this case. The current database is kept on errors.
+func (db *database) reload() (bool, error) {
+	info, err := os.Stat(db.path)
+	if err != nil {
+		return false, fmt.Errorf("accessing database %q failed: %w", db.path, err)
+	}
+	if db.reader != nil && info.ModTime().Equal(db.modTime) && info.Size() == db.size {
+		return false, nil
+	}
+
+	reader, err := maxminddb.Open(db.path)
+	if err != nil {
+		return false, fmt.Errorf("opening database %q failed: %w", db.path, err)
+	}
+
+	if db.reader != nil {
+		db.reader.Close()
+	}
+	db.reader = reader
+	db.modTime = info.ModTime()
+	db.size = info.Size()
+
+	return true, nil
+}
+
+// lookup decodes the record for the address into r and returns true if the
+// address was found in the database
+func (db *database) lookup(addr netip.Addr, r *record) (bool, error) {
+	_, found, err := db.reader.LookupNetwork(net.IP(addr.AsSlice()), r)
+	return found, err
+}
+
+// close releases the memory mapping of the currently loaded database
+func (db *database) close() error {
+	if db.reader == nil {
+		return nil
+	}
+	err := db.reader.Close()
+	db.reader = nil
+	return err
+}
@@ -0,0 +1,316 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package geoip
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strconv"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/internal/choice"
+	"github.com/influxdata/telegraf/plugins/common/s2cell"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+var availableItems = []string{"country_code", "country", "city", "location", "asn", "as_org"}
+
+type GeoIP struct {
+	Databases       []string        `toml:"databases"`
+	Lookups         []lookup        `toml:"lookup"`
+	Include         []string        `toml:"include"`
+	Language        string          `toml:"language"`
+	CacheSize       int             `toml:"cache_size"`
+	ReloadInterval  config.Duration `toml:"reload_interval"`
+	PrivateNetworks []string        `toml:"private_networks"`
+	PrivateLabel    string          `toml:"private_label"`
+	S2Cell          bool            `toml:"s2_cell"`
+	S2CellLevel     int             `toml:"s2_cell_level"`
+	Log             telegraf.Logger `toml:"-"`
+
+	databases  []*database
+	cache      *lru.Cache[netip.Addr, *result]
+	private    []netip.Prefix
+	include    map[string]bool
+	lastReload time.Time
+}
+
+// lookup defines the source of the address and the prefix of the added data
+type lookup struct {
+	Tag    string `toml:"tag"`
+	Field  string `toml:"field"`
+	Prefix string `toml:"prefix"`
+}
+
+// result is the information found for an address
+type result struct {
+	found       bool
+	countryCode string
+	country     string
+	city        string
+	latitude    float64
+	longitude   float64
+	hasLocation bool
+	s2cell      string
+	asn         uint
+	asOrg       string
+}
+
+func (*GeoIP) SampleConfig() string {
+	return sampleConfig
+}
+
+func (g *GeoIP) Init() error {
+	if len(g.Databases) == 0 {
+		return errors.New("no databases configured")
+	}
+	if len(g.Lookups) == 0 {
+		return errors.New("no lookups configured")
+	}
+	for i, l := range g.Lookups {
+		if (l.Tag == "") == (l.Field == "") {
+			return fmt.Errorf("lookup %d: exactly one of 'tag' or 'field' must be set", i+1)
+		}
+	}
+
+	if len(g.Include) == 0 {
+		g.Include = availableItems
+	}
+	if err := choice.CheckSlice(g.Include, availableItems); err != nil {
+		return fmt.Errorf("invalid 'include' setting: %w", err)
+	}
+	g.include = make(map[string]bool, len(g.Include))
+	for _, item := range g.Include {
+		g.include[item] = true
+	}
+
+	if g.S2CellLevel < 0 || g.S2CellLevel > 30 {
+		return fmt.Errorf("invalid cell level %d", g.S2CellLevel)
+	}
+
+	for _, n := range g.PrivateNetworks {
+		prefix, err := netip.ParsePrefix(n)
+		if err != nil {
+			return fmt.Errorf("parsing private network %q failed: %w", n, err)
+		}
+		g.private = append(g.private, prefix.Masked())
+	}
+
+	if g.CacheSize < 1 {
+		return errors.New("cache size must be positive")
+	}
+	cache, err := lru.New[netip.Addr, *result](g.CacheSize)
+	if err != nil {
+		return fmt.Errorf("creating cache failed: %w", err)
+	}
+	g.cache = cache
+
+	g.databases = make([]*database, 0, len(g.Databases))
+	for _, path := range g.Databases {
+		db, err := openDatabase(path)
+		if err != nil {
+			return err
+		}
+		g.databases = append(g.databases, db)
+	}
+	g.lastReload = time.Now()
+
+	return nil
+}
+
+func (*GeoIP) Start(telegraf.Accumulator) error {
+	return nil
+}
+
+func (g *GeoIP) Add(m telegraf.Metric, acc telegraf.Accumulator) error {
+	g.reload()
+
+	for _, l := range g.Lookups {
+		g.enrich(m, l)
+	}
+	acc.AddMetric(m)
+	return nil
+}
+
+func (g *GeoIP) Stop() {
+	for _, db := range g.databases {
+		if err := db.close(); err != nil {
+			g.Log.Errorf("Closing database %q failed: %v", db.path, err)
+		}
+	}
+}
+
+func (g *GeoIP) enrich(m telegraf.Metric, l lookup) {
+	var raw string
+	if l.Tag != "" {
+		v, found := m.GetTag(l.Tag)
+		if !found {
+			return
+		}
+		raw = v
+	} else {
+		v, found := m.GetField(l.Field)
+		if !found {
+			return
+		}
+		s, ok := v.(string)
+		if !ok {
+			g.Log.Debugf("Field %q of metric %q is not a string but %T", l.Field, m.Name(), v)
+			return
+		}
+		raw = s
+	}
+
+	addr, err := netip.ParseAddr(raw)
+	if err != nil {
+		g.Log.Debugf("Parsing address %q of metric %q failed: %v", raw, m.Name(), err)
+		return
+	}
+	addr = addr.Unmap()
+
+	if g.isPrivate(addr) {
+		if g.PrivateLabel != "" {
+			m.AddTag(l.Prefix+"network", g.PrivateLabel)
+		}
+		return
+	}
+
+	r, found := g.cache.Get(addr)
+	if !found {
+		r = g.resolve(addr)
+		g.cache.Add(addr, r)
+	}
+	if !r.found {
+		return
+	}
+
+	if g.include["country_code"] && r.countryCode != "" {
+		m.AddTag(l.Prefix+"country_code", r.countryCode)
+	}
+	if g.include["country"] && r.country != "" {
+		m.AddTag(l.Prefix+"country", r.country)
+	}
+	if g.include["city"] && r.city != "" {
+		m.AddTag(l.Prefix+"city", r.city)
+	}
+	if g.include["asn"] && r.asn != 0 {
+		m.AddTag(l.Prefix+"asn", strconv.FormatUint(uint64(r.asn), 10))
+	}
+	if g.include["as_org"] && r.asOrg != "" {
+		m.AddTag(l.Prefix+"as_org", r.asOrg)
+	}
+	if r.hasLocation {
+		if g.include["location"] {
+			m.AddField(l.Prefix+"latitude", r.latitude)
+			m.AddField(l.Prefix+"longitude", r.longitude)
+		}
+		if r.s2cell != "" {
+			m.AddTag(l.Prefix+"s2_cell", r.s2cell)
+		}
+	}
+}
+
+// resolve looks up the address in all databases with earlier databases
+// taking precedence for information contained in multiple databases
+func (g *GeoIP) resolve(addr netip.Addr) *result {
+	r := &result{}
+	for i := len(g.databases) - 1; i >= 0; i-- {
+		db := g.databases[i]
+
+		var rec record
+		found, err := db.lookup(addr, &rec)
+		if err != nil {
+			g.Log.Errorf("Looking up %q in %q failed: %v", addr, db.path, err)
+			continue
+		}
+		if !found {
+			continue
+		}
+		r.found = true
+
+		if rec.Country.ISOCode != "" {
+			r.countryCode = rec.Country.ISOCode
+		}
+		if name := rec.Country.Names[g.Language]; name != "" {
+			r.country = name
+		}
+		if name := rec.City.Names[g.Language]; name != "" {
+			r.city = name
+		}
+		if rec.Location.Latitude != nil && rec.Location.Longitude != nil {
+			r.latitude = *rec.Location.Latitude
+			r.longitude = *rec.Location.Longitude
+			r.hasLocation = true
+		}
+		if rec.ASN != 0 {
+			r.asn = rec.ASN
+		}
+		if rec.Organization != "" {
+			r.asOrg = rec.Organization
+		}
+	}
+
+	if g.S2Cell && r.hasLocation {
+		if token, ok := s2cell.Token(r.latitude, r.longitude, g.S2CellLevel); ok {
+			r.s2cell = token
+		}
+	}
+
+	return r
+}
+
+// isPrivate returns true for addresses not routed on the internet or part of
+// the configured private networks
+func (g *GeoIP) isPrivate(addr netip.Addr) bool {
+	if addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return true
+	}
+	return slices.ContainsFunc(g.private, func(p netip.Prefix) bool { return p.Contains(addr) })
+}
+
+// reload reopens changed databases at most once per reload interval
+func (g *GeoIP) reload() {
+	if g.ReloadInterval <= 0 || time.Since(g.lastReload) < time.Duration(g.ReloadInterval) {
+		return
+	}
+	g.lastReload = time.Now()
+
+	var changed bool
+	for _, db := range g.databases {
+		reloaded, err := db.reload()
+		if err != nil {
+			g.Log.Errorf("Reloading database failed, keeping previous version: %v", err)
+			continue
+		}
+		if reloaded {
+			g.Log.Infof("Reloaded database %q", db.path)
+			changed = true
+		}
+	}
+
+	// Remove all cached results as they might be outdated
+	if changed {
+		g.cache.Purge()
+	}
+}
+
+func init() {
+	processors.AddStreaming("geoip", func() telegraf.StreamingProcessor {
+		return &GeoIP{
+			Language:       "en",
+			CacheSize:      1000,
+			ReloadInterval: config.Duration(time.Minute),
+			S2CellLevel:    9,
+		}
+	})
+}
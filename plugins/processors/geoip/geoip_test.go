@@ -0,0 +1,335 @@
+package geoip
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestInitFail(t *testing.T) {
+	city := filepath.Join("testdata", "city.mmdb")
+
+	tests := []struct {
+		name     string
+		plugin   *GeoIP
+		expected string
+	}{
+		{
+			name:     "no databases",
+			plugin:   &GeoIP{Lookups: []lookup{{Tag: "src"}}},
+			expected: "no databases configured",
+		},
+		{
+			name:     "no lookups",
+			plugin:   &GeoIP{Databases: []string{city}},
+			expected: "no lookups configured",
+		},
+		{
+			name:     "tag and field",
+			plugin:   &GeoIP{Databases: []string{city}, Lookups: []lookup{{Tag: "src", Field: "src"}}},
+			expected: "exactly one of 'tag' or 'field' must be set",
+		},
+		{
+			name:     "invalid include",
+			plugin:   &GeoIP{Databases: []string{city}, Lookups: []lookup{{Tag: "src"}}, Include: []string{"postcode"}},
+			expected: "invalid 'include' setting",
+		},
+		{
+			name:     "invalid private network",
+			plugin:   &GeoIP{Databases: []string{city}, Lookups: []lookup{{Tag: "src"}}, PrivateNetworks: []string{"10.0.0.0"}},
+			expected: "parsing private network",
+		},
+		{
+			name:     "missing database",
+			plugin:   &GeoIP{Databases: []string{"nonexisting.mmdb"}, Lookups: []lookup{{Tag: "src"}}},
+			expected: "accessing database",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.plugin.CacheSize = 10
+			tt.plugin.Log = testutil.Logger{}
+			require.ErrorContains(t, tt.plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestCases(t *testing.T) {
+	city := filepath.Join("testdata", "city.mmdb")
+	asn := filepath.Join("testdata", "asn.mmdb")
+
+	tests := []struct {
+		name     string
+		plugin   *GeoIP
+		input    telegraf.Metric
+		expected telegraf.Metric
+	}{
+		{
+			name: "city and asn from tag",
+			plugin: &GeoIP{
+				Databases: []string{city, asn},
+				Lookups:   []lookup{{Tag: "src", Prefix: "src_"}},
+			},
+			input: metric.New("flow", map[string]string{"src": "81.2.69.160"}, map[string]interface{}{"bytes": 42}, time.Unix(0, 0)),
+			expected: metric.New(
+				"flow",
+				map[string]string{
+					"src":              "81.2.69.160",
+					"src_country_code": "GB",
+					"src_country":      "United Kingdom",
+					"src_city":         "London",
+					"src_asn":          "20712",
+					"src_as_org":       "Andrews & Arnold Ltd",
+				},
+				map[string]interface{}{"bytes": 42, "src_latitude": 51.5142, "src_longitude": -0.0931},
+				time.Unix(0, 0),
+			),
+		},
+		{
+			name: "field with include",
+			plugin: &GeoIP{
+				Databases: []string{city, asn},
+				Lookups:   []lookup{{Field: "dst", Prefix: "dst_"}},
+				Include:   []string{"country_code", "asn"},
+			},
+			input: metric.New("flow", map[string]string{}, map[string]interface{}{"dst": "2a02:ec0::1"}, time.Unix(0, 0)),
+			expected: metric.New(
+				"flow",
+				map[string]string{"dst_country_code": "DE", "dst_asn": "64496"},
+				map[string]interface{}{"dst": "2a02:ec0::1"},
+				time.Unix(0, 0),
+			),
+		},
+		{
+			name: "multiple lookups",
+			plugin: &GeoIP{
+				Databases: []string{city},
+				Lookups:   []lookup{{Tag: "src", Prefix: "src_"}, {Tag: "dst", Prefix: "dst_"}},
+				Include:   []string{"country_code"},
+			},
+			input: metric.New(
+				"flow",
+				map[string]string{"src": "81.2.69.1", "dst": "2a02:ec0::2"},
+				map[string]interface{}{"bytes": 42},
+				time.Unix(0, 0),
+			),
+			expected: metric.New(
+				"flow",
+				map[string]string{"src": "81.2.69.1", "dst": "2a02:ec0::2", "src_country_code": "GB", "dst_country_code": "DE"},
+				map[string]interface{}{"bytes": 42},
+				time.Unix(0, 0),
+			),
+		},
+		{
+			name: "not found",
+			plugin: &GeoIP{
+				Databases: []string{city, asn},
+				Lookups:   []lookup{{Tag: "src"}},
+			},
+			input:    metric.New("flow", map[string]string{"src": "8.8.8.8"}, map[string]interface{}{"bytes": 42}, time.Unix(0, 0)),
+			expected: metric.New("flow", map[string]string{"src": "8.8.8.8"}, map[string]interface{}{"bytes": 42}, time.Unix(0, 0)),
+		},
+		{
+			name: "invalid address",
+			plugin: &GeoIP{
+				Databases: []string{city},
+				Lookups:   []lookup{{Tag: "src"}},
+			},
+			input:    metric.New("flow", map[string]string{"src": "foo"}, map[string]interface{}{"bytes": 42}, time.Unix(0, 0)),
+			expected: metric.New("flow", map[string]string{"src": "foo"}, map[string]interface{}{"bytes": 42}, time.Unix(0, 0)),
+		},
+		{
+			name: "private address",
+			plugin: &GeoIP{
+				Databases: []string{city},
+				Lookups:   []lookup{{Tag: "src", Prefix: "src_"}},
+			},
+			input:    metric.New("flow", map[string]string{"src": "192.168.1.1"}, map[string]interface{}{"bytes": 42}, time.Unix(0, 0)),
+			expected: metric.New("flow", map[string]string{"src": "192.168.1.1"}, map[string]interface{}{"bytes": 42}, time.Unix(0, 0)),
+		},
+		{
+			name: "private address with label",
+			plugin: &GeoIP{
+				Databases:    []string{city},
+				Lookups:      []lookup{{Tag: "src", Prefix: "src_"}},
+				PrivateLabel: "internal",
+			},
+			input: metric.New("flow", map[string]string{"src": "fe80::1"}, map[string]interface{}{"bytes": 42}, time.Unix(0, 0)),
+			expected: metric.New(
+				"flow",
+				map[string]string{"src": "fe80::1", "src_network": "internal"},
+				map[string]interface{}{"bytes": 42},
+				time.Unix(0, 0),
+			),
+		},
+		{
+			name: "custom private network",
+			plugin: &GeoIP{
+				Databases:       []string{city},
+				Lookups:         []lookup{{Tag: "src", Prefix: "src_"}},
+				PrivateNetworks: []string{"81.2.69.0/28"},
+				PrivateLabel:    "internal",
+			},
+			input: metric.New("flow", map[string]string{"src": "81.2.69.1"}, map[string]interface{}{"bytes": 42}, time.Unix(0, 0)),
+			expected: metric.New(
+				"flow",
+				map[string]string{"src": "81.2.69.1", "src_network": "internal"},
+				map[string]interface{}{"bytes": 42},
+				time.Unix(0, 0),
+			),
+		},
+		{
+			name: "s2 cell",
+			plugin: &GeoIP{
+				Databases: []string{city},
+				Lookups:   []lookup{{Tag: "src"}},
+				Include:   []string{"city"},
+				S2Cell:    true,
+			},
+			input: metric.New("flow", map[string]string{"src": "81.2.69.160"}, map[string]interface{}{"bytes": 42}, time.Unix(0, 0)),
+			expected: metric.New(
+				"flow",
+				map[string]string{"src": "81.2.69.160", "city": "London", "s2_cell": "487604"},
+				map[string]interface{}{"bytes": 42},
+				time.Unix(0, 0),
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.plugin.Language = "en"
+			tt.plugin.CacheSize = 10
+			tt.plugin.S2CellLevel = 9
+			tt.plugin.Log = testutil.Logger{}
+			require.NoError(t, tt.plugin.Init())
+
+			var acc testutil.Accumulator
+			require.NoError(t, tt.plugin.Start(&acc))
+			defer tt.plugin.Stop()
+
+			require.NoError(t, tt.plugin.Add(tt.input, &acc))
+			testutil.RequireMetricsEqual(t, []telegraf.Metric{tt.expected}, acc.GetTelegrafMetrics())
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+	plugin := &GeoIP{
+		Databases: []string{filepath.Join("testdata", "city.mmdb")},
+		Lookups:   []lookup{{Tag: "src"}},
+		Language:  "en",
+		CacheSize: 2,
+		Log:       testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	for _, addr := range []string{"81.2.69.1", "81.2.69.1", "81.2.69.2", "8.8.8.8", "192.168.1.1"} {
+		m := metric.New("flow", map[string]string{"src": addr}, map[string]interface{}{"bytes": 42}, time.Unix(0, 0))
+		require.NoError(t, plugin.Add(m, &acc))
+	}
+
+	// Private addresses are not cached and the oldest entry must be evicted
+	require.Equal(t, 2, plugin.cache.Len())
+	require.False(t, plugin.cache.Contains(mustParseAddr(t, "81.2.69.1")))
+	require.True(t, plugin.cache.Contains(mustParseAddr(t, "8.8.8.8")))
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "city.mmdb")
+	copyDatabase(t, filepath.Join("testdata", "city.mmdb"), fn)
+
+	plugin := &GeoIP{
+		Databases:      []string{fn},
+		Lookups:        []lookup{{Tag: "src"}},
+		Include:        []string{"city"},
+		Language:       "en",
+		CacheSize:      10,
+		ReloadInterval: config.Duration(time.Nanosecond),
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	input := metric.New("flow", map[string]string{"src": "81.2.69.160"}, map[string]interface{}{"bytes": 42}, time.Unix(0, 0))
+	expected := metric.New("flow", map[string]string{"src": "81.2.69.160", "city": "London"}, map[string]interface{}{"bytes": 42}, time.Unix(0, 0))
+	require.NoError(t, plugin.Add(input.Copy(), &acc))
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{expected}, acc.GetTelegrafMetrics())
+
+	// Replace the database atomically and make sure the modification time
+	// differs even on filesystems with coarse timestamps
+	copyDatabase(t, filepath.Join("testdata", "city_updated.mmdb"), fn)
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(fn, future, future))
+
+	expected = metric.New("flow", map[string]string{"src": "81.2.69.160", "city": "Londinium"}, map[string]interface{}{"bytes": 42}, time.Unix(0, 0))
+	acc.ClearMetrics()
+	require.NoError(t, plugin.Add(input.Copy(), &acc))
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{expected}, acc.GetTelegrafMetrics())
+
+	// A broken database must not affect the processing
+	broken := filepath.Join(dir, "broken.mmdb")
+	require.NoError(t, os.WriteFile(broken, []byte("garbage"), 0600))
+	require.NoError(t, os.Rename(broken, fn))
+	require.NoError(t, os.Chtimes(fn, future.Add(time.Minute), future.Add(time.Minute)))
+	acc.ClearMetrics()
+	require.NoError(t, plugin.Add(input.Copy(), &acc))
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{expected}, acc.GetTelegrafMetrics())
+}
+
+func TestStopClosesDatabases(t *testing.T) {
+	plugin := &GeoIP{
+		Databases: []string{filepath.Join("testdata", "city.mmdb"), filepath.Join("testdata", "asn.mmdb")},
+		Lookups:   []lookup{{Tag: "src"}},
+		Language:  "en",
+		CacheSize: 10,
+		Log:       testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	plugin.Stop()
+
+	for _, db := range plugin.databases {
+		require.Nil(t, db.reader)
+	}
+}
+
+// copyDatabase atomically replaces dst with the content of src
+func copyDatabase(t *testing.T, src, dst string) {
+	t.Helper()
+
+	buf, err := os.ReadFile(src)
+	require.NoError(t, err)
+
+	tmp := dst + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, buf, 0600))
+	require.NoError(t, os.Rename(tmp, dst))
+}
+
+func mustParseAddr(t *testing.T, s string) netip.Addr {
+	t.Helper()
+
+	addr, err := netip.ParseAddr(s)
+	require.NoError(t, err)
+	return addr
+}
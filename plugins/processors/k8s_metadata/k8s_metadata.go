@@ -0,0 +1,367 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package k8s_metadata
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/common/docker"
+	"github.com/influxdata/telegraf/plugins/common/tls"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+const (
+	indexIP        = "ip"
+	indexContainer = "container"
+
+	// Time to cache the container of a process
+	pidCacheTTL = time.Minute
+)
+
+type K8sMetadata struct {
+	URL                string          `toml:"url"`
+	BearerToken        config.Secret   `toml:"bearer_token"`
+	KubeConfig         string          `toml:"kubeconfig"`
+	Namespace          string          `toml:"namespace"`
+	NodeName           string          `toml:"node_name"`
+	PIDTag             string          `toml:"pid_tag"`
+	PIDField           string          `toml:"pid_field"`
+	ContainerIDTag     string          `toml:"container_id_tag"`
+	IPTag              string          `toml:"ip_tag"`
+	HostProc           string          `toml:"host_proc"`
+	IncludeLabels      []string        `toml:"include_labels"`
+	IncludeAnnotations []string        `toml:"include_annotations"`
+	SyncTimeout        config.Duration `toml:"sync_timeout"`
+	Log                telegraf.Logger `toml:"-"`
+	tls.ClientConfig
+
+	labelFilter      filter.Filter
+	annotationFilter filter.Filter
+	pids             *docker.PIDCache
+	restConfig       *rest.Config
+	informer         cache.SharedIndexInformer
+	cancel           context.CancelFunc
+	wg               sync.WaitGroup
+}
+
+func (*K8sMetadata) SampleConfig() string {
+	return sampleConfig
+}
+
+func (k *K8sMetadata) Init() error {
+	if k.PIDTag == "" && k.PIDField == "" && k.ContainerIDTag == "" && k.IPTag == "" {
+		return errors.New("at least one of 'pid_tag', 'pid_field', 'container_id_tag' or 'ip_tag' must be set")
+	}
+
+	if k.HostProc == "" {
+		k.HostProc = internal.GetProcPath()
+	}
+	k.pids = docker.NewPIDCache(k.HostProc, pidCacheTTL)
+
+	var err error
+	if k.labelFilter, err = filter.Compile(k.IncludeLabels); err != nil {
+		return fmt.Errorf("creating label filter failed: %w", err)
+	}
+	if k.annotationFilter, err = filter.Compile(k.IncludeAnnotations); err != nil {
+		return fmt.Errorf("creating annotation filter failed: %w", err)
+	}
+
+	cfg, err := k.loadConfig()
+	if err != nil {
+		return fmt.Errorf("creating client configuration failed: %w", err)
+	}
+	k.restConfig = cfg
+
+	return nil
+}
+
+func (k *K8sMetadata) Start(telegraf.Accumulator) error {
+	client, err := kubernetes.NewForConfig(k.restConfig)
+	if err != nil {
+		return fmt.Errorf("creating client failed: %w", err)
+	}
+
+	// Watch the pods restricted to the namespace and node if configured
+	options := []informers.SharedInformerOption{
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			if k.NodeName != "" {
+				opts.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", k.NodeName).String()
+			}
+		}),
+	}
+	if k.Namespace != "" {
+		options = append(options, informers.WithNamespace(k.Namespace))
+	}
+	factory := informers.NewSharedInformerFactoryWithOptions(client, 0, options...)
+
+	k.informer = factory.Core().V1().Pods().Informer()
+	err = k.informer.AddIndexers(cache.Indexers{
+		indexIP:        indexPodIPs,
+		indexContainer: indexContainerIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("adding indexers failed: %w", err)
+	}
+	if err := k.informer.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		k.Log.Errorf("Watching pods failed: %v", err)
+	}); err != nil {
+		return fmt.Errorf("setting error handler failed: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	k.cancel = cancel
+	k.wg.Add(1)
+	go func() {
+		defer k.wg.Done()
+		k.informer.Run(ctx.Done())
+	}()
+
+	// Wait for the initial list of pods to avoid passing through metrics
+	// unmodified on startup, but do not block forever
+	syncCtx, syncCancel := context.WithTimeout(ctx, time.Duration(k.SyncTimeout))
+	defer syncCancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), k.informer.HasSynced) {
+		k.Log.Warn("Pod cache not synchronized yet, metrics might not be enriched until synchronized")
+	}
+
+	return nil
+}
+
+func (k *K8sMetadata) Add(m telegraf.Metric, acc telegraf.Accumulator) error {
+	if pod, container := k.lookup(m); pod != nil {
+		k.enrich(m, pod, container)
+	}
+	acc.AddMetric(m)
+	return nil
+}
+
+func (k *K8sMetadata) Stop() {
+	if k.cancel != nil {
+		k.cancel()
+	}
+	k.wg.Wait()
+}
+
+func (k *K8sMetadata) loadConfig() (*rest.Config, error) {
+	if k.URL == "" {
+		if k.KubeConfig != "" {
+			return clientcmd.BuildConfigFromFlags("", k.KubeConfig)
+		}
+		return rest.InClusterConfig()
+	}
+
+	cfg := &rest.Config{
+		Host: k.URL,
+		TLSClientConfig: rest.TLSClientConfig{
+			Insecure:   k.InsecureSkipVerify,
+			CAFile:     k.TLSCA,
+			CertFile:   k.TLSCert,
+			KeyFile:    k.TLSKey,
+			ServerName: k.ServerName,
+		},
+	}
+	if !k.BearerToken.Empty() {
+		token, err := k.BearerToken.Get()
+		if err != nil {
+			return nil, fmt.Errorf("getting token failed: %w", err)
+		}
+		cfg.BearerToken = strings.TrimSpace(token.String())
+		token.Destroy()
+	}
+	return cfg, nil
+}
+
+// lookup returns the pod and the name of the container for the metric
+func (k *K8sMetadata) lookup(m telegraf.Metric) (*corev1.Pod, string) {
+	if k.ContainerIDTag != "" {
+		if id, found := m.GetTag(k.ContainerIDTag); found {
+			if pod, container := k.byContainerID(docker.TrimContainerRuntime(id)); pod != nil {
+				return pod, container
+			}
+		}
+	}
+
+	if k.PIDTag != "" || k.PIDField != "" {
+		if pid, found := k.pid(m); found {
+			id, err := k.pids.ContainerID(pid)
+			if err != nil {
+				k.Log.Debugf("Getting container of process %d failed: %v", pid, err)
+			} else if id != "" {
+				if pod, container := k.byContainerID(id); pod != nil {
+					return pod, container
+				}
+			}
+		}
+	}
+
+	if k.IPTag != "" {
+		if ip, found := m.GetTag(k.IPTag); found {
+			return k.byIndex(indexIP, ip), ""
+		}
+	}
+
+	return nil, ""
+}
+
+func (k *K8sMetadata) pid(m telegraf.Metric) (int64, bool) {
+	if k.PIDTag != "" {
+		if v, found := m.GetTag(k.PIDTag); found {
+			pid, err := strconv.ParseInt(v, 10, 64)
+			return pid, err == nil
+		}
+	}
+	if k.PIDField != "" {
+		if v, found := m.GetField(k.PIDField); found {
+			pid, err := internal.ToInt64(v)
+			return pid, err == nil
+		}
+	}
+	return 0, false
+}
+
+func (k *K8sMetadata) byContainerID(id string) (*corev1.Pod, string) {
+	pod := k.byIndex(indexContainer, id)
+	if pod == nil {
+		return nil, ""
+	}
+
+	for _, statuses := range [][]corev1.ContainerStatus{pod.Status.ContainerStatuses, pod.Status.InitContainerStatuses} {
+		for _, s := range statuses {
+			if docker.TrimContainerRuntime(s.ContainerID) == id {
+				return pod, s.Name
+			}
+		}
+	}
+	return pod, ""
+}
+
+func (k *K8sMetadata) byIndex(index, value string) *corev1.Pod {
+	if k.informer == nil {
+		return nil
+	}
+
+	objs, err := k.informer.GetIndexer().ByIndex(index, value)
+	if err != nil || len(objs) == 0 {
+		return nil
+	}
+
+	// Pods using the host network share the node IP so the result is not
+	// unique in this case
+	if len(objs) > 1 {
+		k.Log.Debugf("Found %d pods for %s %q, skipping", len(objs), index, value)
+		return nil
+	}
+
+	pod, ok := objs[0].(*corev1.Pod)
+	if !ok {
+		return nil
+	}
+	return pod
+}
+
+func (k *K8sMetadata) enrich(m telegraf.Metric, pod *corev1.Pod, container string) {
+	m.AddTag("namespace", pod.Namespace)
+	m.AddTag("pod_name", pod.Name)
+	if pod.Spec.NodeName != "" {
+		m.AddTag("node_name", pod.Spec.NodeName)
+	}
+	if container != "" {
+		m.AddTag("container_name", container)
+	}
+	if deployment := deploymentName(pod); deployment != "" {
+		m.AddTag("deployment", deployment)
+	}
+
+	for key, value := range pod.Labels {
+		if k.labelFilter != nil && k.labelFilter.Match(key) {
+			m.AddTag("label_"+key, value)
+		}
+	}
+	for key, value := range pod.Annotations {
+		if k.annotationFilter != nil && k.annotationFilter.Match(key) {
+			m.AddTag("annotation_"+key, value)
+		}
+	}
+}
+
+// deploymentName derives the name of the deployment from the replica-set
+// owning the pod as the replica-set name is the deployment name followed by
+// the pod-template hash
+func deploymentName(pod *corev1.Pod) string {
+	hash, found := pod.Labels["pod-template-hash"]
+	if !found {
+		return ""
+	}
+	for _, owner := range pod.OwnerReferences {
+		if owner.Kind != "ReplicaSet" {
+			continue
+		}
+		if name, found := strings.CutSuffix(owner.Name, "-"+hash); found {
+			return name
+		}
+	}
+	return ""
+}
+
+func indexPodIPs(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil, nil
+	}
+
+	ips := make([]string, 0, len(pod.Status.PodIPs))
+	for _, ip := range pod.Status.PodIPs {
+		ips = append(ips, ip.IP)
+	}
+	if len(ips) == 0 && pod.Status.PodIP != "" {
+		ips = append(ips, pod.Status.PodIP)
+	}
+	return ips, nil
+}
+
+func indexContainerIDs(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(pod.Status.ContainerStatuses)+len(pod.Status.InitContainerStatuses))
+	for _, statuses := range [][]corev1.ContainerStatus{pod.Status.ContainerStatuses, pod.Status.InitContainerStatuses} {
+		for _, s := range statuses {
+			if s.ContainerID != "" {
+				ids = append(ids, docker.TrimContainerRuntime(s.ContainerID))
+			}
+		}
+	}
+	return ids, nil
+}
+
+func init() {
+	processors.AddStreaming("k8s_metadata", func() telegraf.StreamingProcessor {
+		return &K8sMetadata{
+			SyncTimeout: config.Duration(10 * time.Second),
+		}
+	})
+}
@@ -0,0 +1,280 @@
+package k8s_metadata
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+const (
+	containerWeb    = "3f4b2c1d9e8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b8c7d6e5f4a3b2c"
+	containerDB     = "0a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f9"
+	containerWorker = "ffeeddccbbaa99887766554433221100ffeeddccbbaa99887766554433221100"
+)
+
+func TestInitFail(t *testing.T) {
+	plugin := &K8sMetadata{URL: "http://localhost", Log: testutil.Logger{}}
+	require.ErrorContains(t, plugin.Init(), "at least one of")
+
+	plugin = &K8sMetadata{URL: "http://localhost", IPTag: "ip", IncludeLabels: []string{"a[b"}, Log: testutil.Logger{}}
+	require.ErrorContains(t, plugin.Init(), "creating label filter failed")
+}
+
+func TestEnrich(t *testing.T) {
+	server := newAPIServer(t, []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "web-5d9f7c-x7k2p",
+				Namespace:   "shop",
+				Labels:      map[string]string{"app": "web", "team": "frontend", "pod-template-hash": "5d9f7c"},
+				Annotations: map[string]string{"owner": "alice@example.org", "other": "ignored"},
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "ReplicaSet", Name: "web-5d9f7c"},
+				},
+			},
+			Spec: corev1.PodSpec{NodeName: "node1"},
+			Status: corev1.PodStatus{
+				PodIP:             "10.0.0.1",
+				PodIPs:            []corev1.PodIP{{IP: "10.0.0.1"}, {IP: "fd00::1"}},
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "nginx", ContainerID: "containerd://" + containerWeb}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "db-0",
+				Namespace:       "shop",
+				Labels:          map[string]string{"app": "db"},
+				OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "db"}},
+			},
+			Spec: corev1.PodSpec{NodeName: "node1"},
+			Status: corev1.PodStatus{
+				PodIP:             "10.0.0.2",
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "postgres", ContainerID: "docker://" + containerDB}},
+			},
+		},
+	})
+	defer server.Close()
+
+	// Setup a process running in the web container
+	procfs := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(procfs, "1234"), 0750))
+	cgroup := "0::/kubepods.slice/kubepods-burstable.slice/cri-containerd-" + containerWeb + ".scope\n"
+	require.NoError(t, os.WriteFile(filepath.Join(procfs, "1234", "cgroup"), []byte(cgroup), 0600))
+
+	plugin := &K8sMetadata{
+		URL:                server.URL,
+		NodeName:           "node1",
+		PIDTag:             "pid",
+		ContainerIDTag:     "container_id",
+		IPTag:              "ip",
+		HostProc:           procfs,
+		IncludeLabels:      []string{"app", "team"},
+		IncludeAnnotations: []string{"owner"},
+		SyncTimeout:        config.Duration(5 * time.Second),
+		Log:                testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	webTags := map[string]string{
+		"namespace":        "shop",
+		"pod_name":         "web-5d9f7c-x7k2p",
+		"node_name":        "node1",
+		"deployment":       "web",
+		"label_app":        "web",
+		"label_team":       "frontend",
+		"annotation_owner": "alice@example.org",
+	}
+	withTags := func(tags map[string]string, extra map[string]string) map[string]string {
+		result := make(map[string]string, len(tags)+len(extra))
+		for k, v := range tags {
+			result[k] = v
+		}
+		for k, v := range extra {
+			result[k] = v
+		}
+		return result
+	}
+
+	input := []telegraf.Metric{
+		metric.New("procstat", map[string]string{"pid": "1234"}, map[string]interface{}{"cpu": 1.0}, time.Unix(0, 0)),
+		metric.New("statsd", map[string]string{"container_id": containerWeb}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		metric.New("prometheus", map[string]string{"ip": "fd00::1"}, map[string]interface{}{"value": 2}, time.Unix(0, 0)),
+		metric.New("prometheus", map[string]string{"ip": "10.0.0.2"}, map[string]interface{}{"value": 3}, time.Unix(0, 0)),
+		metric.New("prometheus", map[string]string{"ip": "10.0.0.3"}, map[string]interface{}{"value": 4}, time.Unix(0, 0)),
+		metric.New("procstat", map[string]string{"pid": "4321"}, map[string]interface{}{"cpu": 2.0}, time.Unix(0, 0)),
+	}
+	expected := []telegraf.Metric{
+		metric.New(
+			"procstat",
+			withTags(webTags, map[string]string{"pid": "1234", "container_name": "nginx"}),
+			map[string]interface{}{"cpu": 1.0},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"statsd",
+			withTags(webTags, map[string]string{"container_id": containerWeb, "container_name": "nginx"}),
+			map[string]interface{}{"value": 1},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"prometheus",
+			withTags(webTags, map[string]string{"ip": "fd00::1"}),
+			map[string]interface{}{"value": 2},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"prometheus",
+			map[string]string{"ip": "10.0.0.2", "namespace": "shop", "pod_name": "db-0", "node_name": "node1", "label_app": "db"},
+			map[string]interface{}{"value": 3},
+			time.Unix(0, 0),
+		),
+		metric.New("prometheus", map[string]string{"ip": "10.0.0.3"}, map[string]interface{}{"value": 4}, time.Unix(0, 0)),
+		metric.New("procstat", map[string]string{"pid": "4321"}, map[string]interface{}{"cpu": 2.0}, time.Unix(0, 0)),
+	}
+
+	for _, m := range input {
+		require.NoError(t, plugin.Add(m, &acc))
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.SortMetrics())
+	require.Equal(t, "spec.nodeName=node1", server.fieldSelector())
+}
+
+func TestWatch(t *testing.T) {
+	server := newAPIServer(t, nil)
+	defer server.Close()
+
+	plugin := &K8sMetadata{
+		URL:            server.URL,
+		ContainerIDTag: "container_id",
+		SyncTimeout:    config.Duration(5 * time.Second),
+		Log:            testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	// Metrics of unknown containers pass unmodified
+	input := metric.New("statsd", map[string]string{"container_id": containerWorker}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	require.NoError(t, plugin.Add(input.Copy(), &acc))
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{input}, acc.GetTelegrafMetrics())
+
+	// Create the pod and wait for the cache to pick it up
+	server.send("ADDED", corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "batch", ResourceVersion: "2"},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "main", ContainerID: "cri-o://" + containerWorker}},
+		},
+	})
+	require.Eventually(t, func() bool {
+		pod, _ := plugin.byContainerID(containerWorker)
+		return pod != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	acc.ClearMetrics()
+	expected := metric.New(
+		"statsd",
+		map[string]string{"container_id": containerWorker, "namespace": "batch", "pod_name": "worker", "container_name": "main"},
+		map[string]interface{}{"value": 1},
+		time.Unix(0, 0),
+	)
+	require.NoError(t, plugin.Add(input.Copy(), &acc))
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{expected}, acc.GetTelegrafMetrics())
+
+	// Delete the pod again
+	server.send("DELETED", corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "batch", ResourceVersion: "3"},
+	})
+	require.Eventually(t, func() bool {
+		pod, _ := plugin.byContainerID(containerWorker)
+		return pod == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// apiServer is a minimal stand-in for the Kubernetes API serving the pod
+// list and watch requests
+type apiServer struct {
+	*httptest.Server
+
+	pods   []corev1.Pod
+	events chan []byte
+
+	sync.Mutex
+	selector string
+}
+
+func newAPIServer(t *testing.T, pods []corev1.Pod) *apiServer {
+	s := &apiServer{pods: pods, events: make(chan []byte, 10)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/pods" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		s.Lock()
+		s.selector = r.URL.Query().Get("fieldSelector")
+		s.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("watch") != "true" {
+			list := corev1.PodList{
+				TypeMeta: metav1.TypeMeta{Kind: "PodList", APIVersion: "v1"},
+				ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+				Items:    s.pods,
+			}
+			if err := json.NewEncoder(w).Encode(list); err != nil {
+				t.Error(err)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case event := <-s.events:
+				if _, err := w.Write(event); err != nil {
+					return
+				}
+				w.(http.Flusher).Flush()
+			}
+		}
+	}))
+	return s
+}
+
+func (s *apiServer) send(eventType string, pod corev1.Pod) {
+	pod.TypeMeta = metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"}
+	buf, err := json.Marshal(map[string]interface{}{"type": eventType, "object": pod})
+	if err != nil {
+		panic(err)
+	}
+	s.events <- append(buf, '\n')
+}
+
+func (s *apiServer) fieldSelector() string {
+	s.Lock()
+	defer s.Unlock()
+	return s.selector
+}
@@ -0,0 +1,117 @@
+package lookup
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+var formats = []string{"json", "csv_key_name_value", "csv_key_values"}
+
+// parseTable adds the mappings contained in data to the given table. The
+// name is only used to identify the data in error messages.
+func parseTable(format, name string, data []byte, mappings map[string][]telegraf.Tag) error {
+	switch format {
+	case "json":
+		return parseJSON(name, data, mappings)
+	case "csv_key_name_value":
+		return parseCSVKeyNameValue(name, bytes.NewReader(data), mappings)
+	case "csv_key_values":
+		return parseCSVKeyValues(name, bytes.NewReader(data), mappings)
+	}
+	return fmt.Errorf("invalid format %q", format)
+}
+
+func parseJSON(name string, buf []byte, mappings map[string][]telegraf.Tag) error {
+	var data map[string]map[string]string
+	if err := json.Unmarshal(buf, &data); err != nil {
+		return fmt.Errorf("parsing %q failed: %w", name, err)
+	}
+
+	for key, tags := range data {
+		for k, v := range tags {
+			mappings[key] = append(mappings[key], telegraf.Tag{Key: k, Value: v})
+		}
+	}
+	return nil
+}
+
+func parseCSVKeyNameValue(name string, r io.Reader, mappings map[string][]telegraf.Tag) error {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	line := 0
+	for {
+		line++
+		data, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("reading line %d in %q failed: %w", line, name, err)
+		}
+		if len(data) < 3 {
+			return fmt.Errorf("line %d in %q has not enough columns, requiring at least `key,name,value`", line, name)
+		}
+		if len(data)%2 != 1 {
+			return fmt.Errorf("line %d in %q has a tag-name without value", line, name)
+		}
+
+		key := data[0]
+		for i := 1; i < len(data)-1; i += 2 {
+			k, v := data[i], data[i+1]
+			mappings[key] = append(mappings[key], telegraf.Tag{Key: k, Value: v})
+		}
+	}
+
+	return nil
+}
+
+func parseCSVKeyValues(name string, r io.Reader, mappings map[string][]telegraf.Tag) error {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	// Read the first line which should be the header
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("missing header in %q", name)
+		}
+		return fmt.Errorf("reading header in %q failed: %w", name, err)
+	}
+	if len(header) < 2 {
+		return fmt.Errorf("header in %q has not enough columns, requiring at least `key,value`", name)
+	}
+	header = header[1:]
+
+	line := 1
+	for {
+		line++
+		data, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("reading line %d in %q failed: %w", line, name, err)
+		}
+
+		key := data[0]
+		for i, v := range data[1:] {
+			v = strings.TrimSpace(v)
+			if v != "" {
+				mappings[key] = append(mappings[key], telegraf.Tag{Key: header[i], Value: v})
+			}
+		}
+	}
+
+	return nil
+}
@@ -3,40 +3,82 @@ package lookup
 
 import (
 	"bytes"
+	"context"
 	_ "embed"
-	"encoding/csv"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"os"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"text/template"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/selfstat"
 )
 
 //go:embed sample.conf
 var sampleConfig string
 
-type Processor struct {
-	Filenames   []string        `toml:"files"`
-	Fileformat  string          `toml:"format"`
-	KeyTemplate string          `toml:"key"`
-	Log         telegraf.Logger `toml:"-"`
+// source provides the lookup table
+type source interface {
+	name() string
+	init() error
+	// load returns the current lookup table or nil if the table did not
+	// change since the last successful load
+	load(ctx context.Context) (map[string][]telegraf.Tag, error)
+	close() error
+}
 
-	tmpl     *template.Template
+// table is the lookup table in use, it is never modified but replaced as
+// a whole on reload
+type table struct {
 	mappings map[string][]telegraf.Tag
+	updated  time.Time
+}
+
+type Processor struct {
+	Filenames       []string        `toml:"files"`
+	Fileformat      string          `toml:"format"`
+	KeyTemplate     string          `toml:"key"`
+	HTTP            *httpSource     `toml:"http"`
+	SQL             *sqlSource      `toml:"sql"`
+	Redis           *redisSource    `toml:"redis"`
+	RefreshInterval config.Duration `toml:"refresh_interval"`
+	TTL             config.Duration `toml:"ttl"`
+	NegativeTTL     config.Duration `toml:"negative_ttl"`
+	Log             telegraf.Logger `toml:"-"`
+
+	alias     string
+	tmpl      *template.Template
+	source    source
+	table     atomic.Pointer[table]
+	missing   map[string]time.Time
+	nextPrune time.Time
+	expired   bool
+	trigger   chan struct{}
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+
+	misses       selfstat.Stat
+	reloads      selfstat.Stat
+	reloadErrors selfstat.Stat
 }
 
 func (*Processor) SampleConfig() string {
 	return sampleConfig
 }
 
+func (p *Processor) SetAlias(alias string) {
+	p.alias = alias
+}
+
 func (p *Processor) Init() error {
-	if len(p.Filenames) < 1 {
-		return errors.New("missing 'files'")
+	if len(p.Filenames) == 0 && p.HTTP == nil && p.SQL == nil && p.Redis == nil {
+		return errors.New("missing 'files' or lookup source")
 	}
 
 	if p.KeyTemplate == "" {
@@ -49,167 +91,211 @@ func (p *Processor) Init() error {
 	}
 	p.tmpl = tmpl
 
-	p.mappings = make(map[string][]telegraf.Tag)
-	switch strings.ToLower(p.Fileformat) {
-	case "", "json":
-		return p.loadJSONFiles()
-	case "csv_key_name_value":
-		return p.loadCSVKeyNameValueFiles()
-	case "csv_key_values":
-		return p.loadCSVKeyValuesFiles()
+	p.Fileformat = strings.ToLower(p.Fileformat)
+	if p.Fileformat == "" {
+		p.Fileformat = "json"
+	}
+	if !slices.Contains(formats, p.Fileformat) {
+		return fmt.Errorf("invalid format %q", p.Fileformat)
 	}
 
-	return fmt.Errorf("invalid format %q", p.Fileformat)
-}
-
-func (p *Processor) Apply(in ...telegraf.Metric) []telegraf.Metric {
-	out := make([]telegraf.Metric, 0, len(in))
-	for _, raw := range in {
-		m := raw
-		if wm, ok := raw.(telegraf.UnwrappableMetric); ok {
-			m = wm.Unwrap()
-		}
+	// Setup the source of the lookup table
+	var sources []source
+	if len(p.Filenames) > 0 {
+		sources = append(sources, &fileSource{files: p.Filenames, format: p.Fileformat})
+	}
+	if p.HTTP != nil {
+		p.HTTP.format = p.Fileformat
+		p.HTTP.log = p.Log
+		sources = append(sources, p.HTTP)
+	}
+	if p.SQL != nil {
+		sources = append(sources, p.SQL)
+	}
+	if p.Redis != nil {
+		sources = append(sources, p.Redis)
+	}
+	if len(sources) > 1 {
+		return errors.New("only one of 'files', 'http', 'sql' or 'redis' can be set")
+	}
+	p.source = sources[0]
+	if err := p.source.init(); err != nil {
+		return fmt.Errorf("initializing %s source failed: %w", p.source.name(), err)
+	}
 
-		var buf bytes.Buffer
-		if err := p.tmpl.Execute(&buf, m); err != nil {
-			p.Log.Errorf("generating key failed: %v", err)
-			p.Log.Debugf("metric was %v", m)
-		} else if tags, found := p.mappings[buf.String()]; found {
-			for _, tag := range tags {
-				m.AddTag(tag.Key, tag.Value)
-			}
-		}
-		out = append(out, raw)
+	if p.TTL > 0 && p.TTL < p.RefreshInterval {
+		return errors.New("'ttl' must not be shorter than 'refresh_interval'")
 	}
-	return out
-}
 
-func (p *Processor) loadJSONFiles() error {
-	for _, fn := range p.Filenames {
-		buf, err := os.ReadFile(fn)
-		if err != nil {
-			return fmt.Errorf("loading %q failed: %w", fn, err)
-		}
+	tags := map[string]string{"source": p.source.name()}
+	if p.alias != "" {
+		tags["alias"] = p.alias
+	}
+	p.misses = selfstat.Register("lookup", "misses", tags)
+	p.reloads = selfstat.Register("lookup", "reloads", tags)
+	p.reloadErrors = selfstat.Register("lookup", "reload_errors", tags)
 
-		var data map[string]map[string]string
-		if err := json.Unmarshal(buf, &data); err != nil {
-			return fmt.Errorf("parsing %q failed: %w", fn, err)
-		}
+	p.missing = make(map[string]time.Time)
+	p.trigger = make(chan struct{}, 1)
 
-		for key, tags := range data {
-			for k, v := range tags {
-				p.mappings[key] = append(p.mappings[key], telegraf.Tag{Key: k, Value: v})
-			}
+	// Load the initial table. Dynamic sources being refreshed start with an
+	// empty table if the source is unavailable and retry in the background.
+	if err := p.reload(context.Background()); err != nil {
+		_, static := p.source.(*fileSource)
+		if static || p.RefreshInterval <= 0 && p.NegativeTTL <= 0 {
+			return err
 		}
+		p.Log.Errorf("Loading lookup table failed, starting with an empty table: %v", err)
+		p.table.Store(&table{mappings: make(map[string][]telegraf.Tag), updated: time.Now()})
 	}
 	return nil
 }
 
-func (p *Processor) loadCSVKeyNameValueFiles() error {
-	for _, fn := range p.Filenames {
-		if err := p.loadCSVKeyNameValueFile(fn); err != nil {
-			return err
-		}
+func (p *Processor) Start(telegraf.Accumulator) error {
+	if p.RefreshInterval <= 0 && p.NegativeTTL <= 0 {
+		return nil
 	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.run(ctx)
+	}()
+
 	return nil
 }
 
-func (p *Processor) loadCSVKeyNameValueFile(fn string) error {
-	f, err := os.Open(fn)
-	if err != nil {
-		return fmt.Errorf("loading %q failed: %w", fn, err)
+func (p *Processor) Add(m telegraf.Metric, acc telegraf.Accumulator) error {
+	p.apply(m)
+	acc.AddMetric(m)
+	return nil
+}
+
+func (p *Processor) Stop() {
+	if p.cancel != nil {
+		p.cancel()
 	}
-	defer f.Close()
+	p.wg.Wait()
 
-	reader := csv.NewReader(f)
-	reader.Comment = '#'
-	reader.FieldsPerRecord = -1
-	reader.TrimLeadingSpace = true
+	if err := p.source.close(); err != nil {
+		p.Log.Errorf("Closing %s source failed: %v", p.source.name(), err)
+	}
+}
 
-	line := 0
-	for {
-		line++
-		data, err := reader.Read()
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				break
-			}
-			return fmt.Errorf("reading line %d in %q failed: %w", line, fn, err)
-		}
-		if len(data) < 3 {
-			return fmt.Errorf("line %d in %q has not enough columns, requiring at least `key,name,value`", line, fn)
-		}
-		if len(data)%2 != 1 {
-			return fmt.Errorf("line %d in %q has a tag-name without value", line, fn)
-		}
+func (p *Processor) apply(raw telegraf.Metric) {
+	m := raw
+	if wm, ok := raw.(telegraf.UnwrappableMetric); ok {
+		m = wm.Unwrap()
+	}
 
-		key := data[0]
-		for i := 1; i < len(data)-1; i += 2 {
-			k, v := data[i], data[i+1]
-			p.mappings[key] = append(p.mappings[key], telegraf.Tag{Key: k, Value: v})
+	var buf bytes.Buffer
+	if err := p.tmpl.Execute(&buf, m); err != nil {
+		p.Log.Errorf("generating key failed: %v", err)
+		p.Log.Debugf("metric was %v", m)
+		return
+	}
+	key := buf.String()
+
+	// Ignore tables that were not updated for longer than the time-to-live
+	// as the source seems to be unavailable and the data might be outdated
+	now := time.Now()
+	t := p.table.Load()
+	if p.TTL > 0 && now.Sub(t.updated) > time.Duration(p.TTL) {
+		if !p.expired {
+			p.Log.Errorf("Lookup table expired, last update at %v", t.updated)
+			p.expired = true
 		}
+		p.misses.Incr(1)
+		return
 	}
+	p.expired = false
 
-	return nil
+	tags, found := t.mappings[key]
+	if !found {
+		p.misses.Incr(1)
+		p.requestReload(key, now)
+		return
+	}
+	for _, tag := range tags {
+		m.AddTag(tag.Key, tag.Value)
+	}
 }
 
-func (p *Processor) loadCSVKeyValuesFiles() error {
-	for _, fn := range p.Filenames {
-		if err := p.loadCSVKeyValuesFile(fn); err != nil {
-			return err
-		}
+// requestReload triggers a reload of the table for keys missing in the table
+// unless the key was missing recently
+func (p *Processor) requestReload(key string, now time.Time) {
+	if p.NegativeTTL <= 0 {
+		return
 	}
-	return nil
-}
 
-func (p *Processor) loadCSVKeyValuesFile(fn string) error {
-	f, err := os.Open(fn)
-	if err != nil {
-		return fmt.Errorf("loading %q failed: %w", fn, err)
+	// Remove outdated entries from time to time to not grow indefinitely
+	if now.After(p.nextPrune) {
+		for k, expiry := range p.missing {
+			if now.After(expiry) {
+				delete(p.missing, k)
+			}
+		}
+		p.nextPrune = now.Add(time.Duration(p.NegativeTTL))
 	}
-	defer f.Close()
 
-	reader := csv.NewReader(f)
-	reader.Comment = '#'
-	reader.TrimLeadingSpace = true
+	if expiry, found := p.missing[key]; found && now.Before(expiry) {
+		return
+	}
+	p.missing[key] = now.Add(time.Duration(p.NegativeTTL))
 
-	// Read the first line which should be the header
-	header, err := reader.Read()
-	if err != nil {
-		if errors.Is(err, io.EOF) {
-			return fmt.Errorf("missing header in %q", fn)
-		}
-		return fmt.Errorf("reading header in %q failed: %w", fn, err)
+	// Do not block if a reload is pending anyway
+	select {
+	case p.trigger <- struct{}{}:
+	default:
 	}
-	if len(header) < 2 {
-		return fmt.Errorf("header in %q has not enough columns, requiring at least `key,value`", fn)
+}
+
+func (p *Processor) run(ctx context.Context) {
+	var tick <-chan time.Time
+	if p.RefreshInterval > 0 {
+		ticker := time.NewTicker(time.Duration(p.RefreshInterval))
+		defer ticker.Stop()
+		tick = ticker.C
 	}
-	header = header[1:]
 
-	line := 1
 	for {
-		line++
-		data, err := reader.Read()
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				break
-			}
-			return fmt.Errorf("reading line %d in %q failed: %w", line, fn, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		case <-p.trigger:
 		}
 
-		key := data[0]
-		for i, v := range data[1:] {
-			v = strings.TrimSpace(v)
-			if v != "" {
-				p.mappings[key] = append(p.mappings[key], telegraf.Tag{Key: header[i], Value: v})
-			}
+		if err := p.reload(ctx); err != nil && ctx.Err() == nil {
+			p.Log.Errorf("Reloading lookup table failed: %v", err)
 		}
 	}
+}
+
+// reload loads the table from the source and replaces the current table
+func (p *Processor) reload(ctx context.Context) error {
+	mappings, err := p.source.load(ctx)
+	if err != nil {
+		p.reloadErrors.Incr(1)
+		return err
+	}
+
+	if mappings == nil {
+		// The source confirmed the current table is still up-to-date
+		mappings = p.table.Load().mappings
+	} else {
+		p.reloads.Incr(1)
+	}
+	p.table.Store(&table{mappings: mappings, updated: time.Now()})
 
 	return nil
 }
+
 func init() {
-	processors.Add("lookup", func() telegraf.Processor {
+	processors.AddStreaming("lookup", func() telegraf.StreamingProcessor {
 		return &Processor{}
 	})
 }
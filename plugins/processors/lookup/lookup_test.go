@@ -1,17 +1,24 @@
 package lookup
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/docker/go-connections/nat"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go/wait"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/models"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/processors"
 	"github.com/influxdata/telegraf/testutil"
@@ -38,6 +45,39 @@ func TestInit(t *testing.T) {
 		KeyTemplate: "lala",
 	}
 	require.ErrorContains(t, plugin.Init(), "invalid format")
+
+	plugin = &Processor{
+		Filenames:   []string{"blah.json"},
+		HTTP:        &httpSource{URL: "http://localhost"},
+		KeyTemplate: "lala",
+	}
+	require.ErrorContains(t, plugin.Init(), "only one of")
+
+	plugin = &Processor{
+		HTTP:        &httpSource{},
+		KeyTemplate: "lala",
+	}
+	require.ErrorContains(t, plugin.Init(), "missing 'url'")
+
+	plugin = &Processor{
+		SQL:         &sqlSource{Driver: "foo", Query: "SELECT 1"},
+		KeyTemplate: "lala",
+	}
+	require.ErrorContains(t, plugin.Init(), `driver "foo" not supported`)
+
+	plugin = &Processor{
+		Redis:       &redisSource{Address: "localhost:6379"},
+		KeyTemplate: "lala",
+	}
+	require.ErrorContains(t, plugin.Init(), "missing 'key'")
+
+	plugin = &Processor{
+		Filenames:       []string{filepath.Join("testcases", "normal_lookup_json", "lut.json")},
+		KeyTemplate:     "lala",
+		RefreshInterval: config.Duration(time.Minute),
+		TTL:             config.Duration(time.Second),
+	}
+	require.ErrorContains(t, plugin.Init(), "'ttl' must not be shorter than 'refresh_interval'")
 }
 
 func TestCases(t *testing.T) {
@@ -49,7 +89,7 @@ func TestCases(t *testing.T) {
 	require.NotEmpty(t, folders)
 
 	// Set up for file inputs
-	processors.Add("lookup", func() telegraf.Processor {
+	processors.AddStreaming("lookup", func() telegraf.StreamingProcessor {
 		return &Processor{Log: testutil.Logger{}}
 	})
 
@@ -85,12 +125,11 @@ func TestCases(t *testing.T) {
 			require.NoError(t, cfg.LoadConfig(configFilename))
 			require.Len(t, cfg.Processors, 1, "wrong number of processors")
 
-			proc := cfg.Processors[0].Processor.(processors.HasUnwrap)
-			plugin := proc.Unwrap().(*Processor)
+			plugin := cfg.Processors[0].Processor.(*Processor)
 			require.NoError(t, plugin.Init())
 
 			// Process expected metrics and compare with resulting metrics
-			actual := plugin.Apply(input...)
+			actual := process(t, plugin, input...)
 			testutil.RequireMetricsEqual(t, expected, actual)
 		})
 	}
@@ -105,7 +144,7 @@ func TestCasesTracking(t *testing.T) {
 	require.NotEmpty(t, folders)
 
 	// Set up for file inputs
-	processors.Add("lookup", func() telegraf.Processor {
+	processors.AddStreaming("lookup", func() telegraf.StreamingProcessor {
 		return &Processor{Log: testutil.Logger{}}
 	})
 
@@ -154,12 +193,11 @@ func TestCasesTracking(t *testing.T) {
 			require.NoError(t, cfg.LoadConfig(configFilename))
 			require.Len(t, cfg.Processors, 1, "wrong number of processors")
 
-			proc := cfg.Processors[0].Processor.(processors.HasUnwrap)
-			plugin := proc.Unwrap().(*Processor)
+			plugin := cfg.Processors[0].Processor.(*Processor)
 			require.NoError(t, plugin.Init())
 
 			// Process expected metrics and compare with resulting metrics
-			actual := plugin.Apply(input...)
+			actual := process(t, plugin, input...)
 			testutil.RequireMetricsEqual(t, expected, actual)
 
 			// Simulate output acknowledging delivery
@@ -176,3 +214,339 @@ func TestCasesTracking(t *testing.T) {
 		})
 	}
 }
+
+func TestFileReload(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "lut.json")
+	require.NoError(t, os.WriteFile(fn, []byte(`{"a": {"location": "eu"}}`), 0640))
+
+	plugin := &Processor{
+		Filenames:       []string{fn},
+		KeyTemplate:     `{{.Tag "host"}}`,
+		RefreshInterval: config.Duration(50 * time.Millisecond),
+		Log:             testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	input := metric.New("test", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	expected := []telegraf.Metric{
+		metric.New("test", map[string]string{"host": "a", "location": "eu"}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+	}
+	require.NoError(t, plugin.Add(input, &acc))
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+
+	// Change the file and wait for the new table to be used
+	require.NoError(t, os.WriteFile(fn, []byte(`{"a": {"location": "us"}}`), 0640))
+	require.Eventually(t, func() bool {
+		tags, found := plugin.table.Load().mappings["a"]
+		return found && tags[0].Value == "us"
+	}, 3*time.Second, 10*time.Millisecond)
+
+	acc.ClearMetrics()
+	input = metric.New("test", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	expected = []telegraf.Metric{
+		metric.New("test", map[string]string{"host": "a", "location": "us"}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+	}
+	require.NoError(t, plugin.Add(input, &acc))
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestFileChangeDetection(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "lut.json")
+	require.NoError(t, os.WriteFile(fn, []byte(`{"a": {"location": "eu"}}`), 0640))
+
+	src := &fileSource{files: []string{fn}, format: "json"}
+	mappings, err := src.load(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, map[string][]telegraf.Tag{"a": {{Key: "location", Value: "eu"}}}, mappings)
+
+	// Unchanged content must not be parsed again
+	mappings, err = src.load(t.Context())
+	require.NoError(t, err)
+	require.Nil(t, mappings)
+
+	require.NoError(t, os.WriteFile(fn, []byte(`{"a": {"location": "us"}}`), 0640))
+	mappings, err = src.load(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, map[string][]telegraf.Tag{"a": {{Key: "location", Value: "us"}}}, mappings)
+
+	// Errors must not affect the change detection
+	require.NoError(t, os.Remove(fn))
+	_, err = src.load(t.Context())
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestHTTPSource(t *testing.T) {
+	var mu sync.Mutex
+	var requests, notModified int
+	content := `{"a": {"location": "eu"}}`
+	etag := `"v1"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests++
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		if _, err := w.Write([]byte(content)); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	plugin := &Processor{
+		HTTP:            &httpSource{URL: server.URL},
+		KeyTemplate:     `{{.Tag "host"}}`,
+		RefreshInterval: config.Duration(20 * time.Millisecond),
+		Log:             testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	// Wait for the server to confirm the current table
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return notModified > 1
+	}, 3*time.Second, 10*time.Millisecond)
+	require.Equal(t, map[string][]telegraf.Tag{"a": {{Key: "location", Value: "eu"}}}, plugin.table.Load().mappings)
+
+	// Update the table on the server
+	mu.Lock()
+	content = `{"a": {"location": "us"}}`
+	etag = `"v2"`
+	mu.Unlock()
+	require.Eventually(t, func() bool {
+		tags, found := plugin.table.Load().mappings["a"]
+		return found && tags[0].Value == "us"
+	}, 3*time.Second, 10*time.Millisecond)
+}
+
+func TestHTTPSourceError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	plugin := &Processor{
+		HTTP:        &httpSource{URL: server.URL},
+		KeyTemplate: `{{.Tag "host"}}`,
+		Log:         testutil.Logger{},
+	}
+	require.ErrorContains(t, plugin.Init(), "received status code 404")
+}
+
+func TestHTTPSourceUnavailableOnStartup(t *testing.T) {
+	var available atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !available.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if _, err := w.Write([]byte(`{"a": {"location": "eu"}}`)); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	// Start with an empty table if the source is refreshed
+	plugin := &Processor{
+		HTTP:            &httpSource{URL: server.URL},
+		KeyTemplate:     `{{.Tag "host"}}`,
+		RefreshInterval: config.Duration(20 * time.Millisecond),
+		Log:             testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+	require.Empty(t, plugin.table.Load().mappings)
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	// Metrics pass unmodified until the table is loaded
+	input := metric.New("test", map[string]string{"host": "a"}, map[string]interface{}{"value": 42}, time.Unix(0, 0))
+	require.NoError(t, plugin.Add(input.Copy(), &acc))
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{input}, acc.GetTelegrafMetrics())
+
+	available.Store(true)
+	require.Eventually(t, func() bool {
+		_, found := plugin.table.Load().mappings["a"]
+		return found
+	}, 3*time.Second, 10*time.Millisecond)
+}
+
+func TestTTL(t *testing.T) {
+	plugin := &Processor{
+		Filenames:   []string{filepath.Join("testcases", "normal_lookup_json", "lut.json")},
+		KeyTemplate: `{{.Name}}-{{.Tag "host"}}`,
+		TTL:         config.Duration(time.Minute),
+		Log:         testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	// Simulate a table that could not be updated for too long
+	outdated := *plugin.table.Load()
+	outdated.updated = time.Now().Add(-2 * time.Minute)
+	plugin.table.Store(&outdated)
+
+	misses := plugin.misses.Get()
+	input := metric.New("xyzzy", map[string]string{"host": "green"}, map[string]interface{}{"value": 3.14}, time.Unix(0, 0))
+	require.NoError(t, plugin.Add(input.Copy(), &acc))
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{input}, acc.GetTelegrafMetrics())
+	require.Equal(t, misses+1, plugin.misses.Get())
+}
+
+func TestStatisticsPerInstance(t *testing.T) {
+	instances := make([]*Processor, 0, 2)
+	for _, alias := range []string{"first", "second"} {
+		plugin := &Processor{
+			Filenames:   []string{filepath.Join("testcases", "normal_lookup_json", "lut.json")},
+			KeyTemplate: `{{.Tag "host"}}`,
+			Log:         testutil.Logger{},
+		}
+		rp := models.NewRunningProcessor(plugin, &models.ProcessorConfig{Name: "lookup", Alias: alias})
+		require.NoError(t, rp.Init())
+		require.Equal(t, alias, plugin.alias)
+		instances = append(instances, plugin)
+	}
+	first, second := instances[0], instances[1]
+	before := second.misses.Get()
+
+	var acc testutil.Accumulator
+	require.NoError(t, first.Start(&acc))
+	defer first.Stop()
+
+	input := metric.New("xyzzy", map[string]string{"host": "unknown"}, map[string]interface{}{"value": 3.14}, time.Unix(0, 0))
+	require.NoError(t, first.Add(input, &acc))
+
+	require.Equal(t, int64(1), first.misses.Get())
+	require.Equal(t, before, second.misses.Get())
+}
+
+func TestNegativeCaching(t *testing.T) {
+	var mu sync.Mutex
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		requests++
+		if _, err := w.Write([]byte(`{"a": {"location": "eu"}}`)); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	plugin := &Processor{
+		HTTP:        &httpSource{URL: server.URL},
+		KeyTemplate: `{{.Tag "host"}}`,
+		NegativeTTL: config.Duration(time.Hour),
+		Log:         testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	// The first miss triggers a reload, all subsequent misses for the same
+	// key are cached and must not trigger a reload
+	misses := plugin.misses.Get()
+	for range 10 {
+		m := metric.New("test", map[string]string{"host": "b"}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+		require.NoError(t, plugin.Add(m, &acc))
+	}
+	require.Equal(t, misses+10, plugin.misses.Get())
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests == 2
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// Hits must not trigger reloads
+	m := metric.New("test", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	require.NoError(t, plugin.Add(m, &acc))
+	require.Equal(t, misses+10, plugin.misses.Get())
+
+	// A different key misses again
+	m = metric.New("test", map[string]string{"host": "c"}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	require.NoError(t, plugin.Add(m, &acc))
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests == 3
+	}, 3*time.Second, 10*time.Millisecond)
+}
+
+func TestRedisSourceIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	servicePort := "6379"
+	container := testutil.Container{
+		Image:        "redis:alpine",
+		ExposedPorts: []string{servicePort},
+		WaitingFor:   wait.ForListeningPort(nat.Port(servicePort)),
+	}
+	require.NoError(t, container.Start(), "failed to start container")
+	defer container.Terminate()
+	address := container.Address + ":" + container.Ports[servicePort]
+
+	client := redis.NewClient(&redis.Options{Addr: address})
+	defer client.Close()
+	require.NoError(t, client.HSet(t.Context(), "lut", "a", `{"location": "eu", "rack": "C12"}`).Err())
+
+	plugin := &Processor{
+		Redis:       &redisSource{Address: address, Key: "lut"},
+		KeyTemplate: `{{.Tag "host"}}`,
+		Log:         testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	input := metric.New("test", map[string]string{"host": "a"}, map[string]interface{}{"value": 1}, time.Unix(0, 0))
+	expected := []telegraf.Metric{
+		metric.New(
+			"test",
+			map[string]string{"host": "a", "location": "eu", "rack": "C12"},
+			map[string]interface{}{"value": 1},
+			time.Unix(0, 0),
+		),
+	}
+	require.NoError(t, plugin.Add(input, &acc))
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func process(t *testing.T, plugin *Processor, input ...telegraf.Metric) []telegraf.Metric {
+	t.Helper()
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	for _, m := range input {
+		require.NoError(t, plugin.Add(m, &acc))
+	}
+	plugin.Stop()
+
+	return acc.GetTelegrafMetrics()
+}
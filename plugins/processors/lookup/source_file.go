@@ -0,0 +1,65 @@
+package lookup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"os"
+
+	"github.com/influxdata/telegraf"
+)
+
+// fileSource reads the lookup table from local files. The files are only
+// parsed if their content changed since the last load.
+type fileSource struct {
+	files  []string
+	format string
+	digest []byte
+}
+
+func (*fileSource) name() string {
+	return "file"
+}
+
+func (*fileSource) init() error {
+	return nil
+}
+
+func (s *fileSource) load(context.Context) (map[string][]telegraf.Tag, error) {
+	contents := make([][]byte, 0, len(s.files))
+	hash := sha256.New()
+	for _, fn := range s.files {
+		buf, err := os.ReadFile(fn)
+		if err != nil {
+			return nil, fmt.Errorf("loading %q failed: %w", fn, err)
+		}
+		contents = append(contents, buf)
+
+		// Include the length to detect content moving between files
+		if err := binary.Write(hash, binary.LittleEndian, uint64(len(buf))); err != nil {
+			return nil, err
+		}
+		hash.Write(buf)
+	}
+
+	digest := hash.Sum(nil)
+	if s.digest != nil && bytes.Equal(digest, s.digest) {
+		return nil, nil
+	}
+
+	mappings := make(map[string][]telegraf.Tag)
+	for i, fn := range s.files {
+		if err := parseTable(s.format, fn, contents[i], mappings); err != nil {
+			return nil, err
+		}
+	}
+	s.digest = digest
+
+	return mappings, nil
+}
+
+func (*fileSource) close() error {
+	return nil
+}
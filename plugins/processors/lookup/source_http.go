@@ -0,0 +1,127 @@
+package lookup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	common_http "github.com/influxdata/telegraf/plugins/common/http"
+)
+
+// httpSource fetches the lookup table from a HTTP endpoint. The entity-tag
+// and modification time of the last response are sent with each request so
+// the server can avoid transferring an unchanged table.
+type httpSource struct {
+	URL      string            `toml:"url"`
+	Headers  map[string]string `toml:"headers"`
+	Username config.Secret     `toml:"username"`
+	Password config.Secret     `toml:"password"`
+	common_http.HTTPClientConfig
+
+	format       string
+	log          telegraf.Logger
+	client       *http.Client
+	etag         string
+	lastModified string
+}
+
+func (*httpSource) name() string {
+	return "http"
+}
+
+func (s *httpSource) init() error {
+	if s.URL == "" {
+		return errors.New("missing 'url'")
+	}
+
+	client, err := s.HTTPClientConfig.CreateClient(context.Background(), s.log)
+	if err != nil {
+		return fmt.Errorf("creating client failed: %w", err)
+	}
+	s.client = client
+
+	return nil
+}
+
+func (s *httpSource) load(ctx context.Context) (map[string][]telegraf.Tag, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request failed: %w", err)
+	}
+	for k, v := range s.Headers {
+		if k == "Host" {
+			req.Host = v
+		} else {
+			req.Header.Add(k, v)
+		}
+	}
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+	if s.lastModified != "" {
+		req.Header.Set("If-Modified-Since", s.lastModified)
+	}
+	if err := s.setAuth(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting %q failed: %w", s.URL, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("requesting %q failed: received status code %d (%s)", s.URL, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	buf, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response of %q failed: %w", s.URL, err)
+	}
+
+	mappings := make(map[string][]telegraf.Tag)
+	if err := parseTable(s.format, s.URL, buf, mappings); err != nil {
+		return nil, err
+	}
+	s.etag = resp.Header.Get("ETag")
+	s.lastModified = resp.Header.Get("Last-Modified")
+
+	return mappings, nil
+}
+
+func (s *httpSource) setAuth(req *http.Request) error {
+	if s.Username.Empty() && s.Password.Empty() {
+		return nil
+	}
+
+	username, err := s.Username.Get()
+	if err != nil {
+		return fmt.Errorf("getting username failed: %w", err)
+	}
+	defer username.Destroy()
+
+	password, err := s.Password.Get()
+	if err != nil {
+		return fmt.Errorf("getting password failed: %w", err)
+	}
+	defer password.Destroy()
+
+	req.SetBasicAuth(username.String(), password.String())
+	return nil
+}
+
+func (s *httpSource) close() error {
+	if s.client != nil {
+		s.client.CloseIdleConnections()
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package lookup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/plugins/common/tls"
+)
+
+// redisSource reads the lookup table from a Redis hash. The hash fields are
+// used as keys and the values must contain a JSON object of tag-key and
+// tag-value pairs.
+type redisSource struct {
+	Address  string          `toml:"address"`
+	Username config.Secret   `toml:"username"`
+	Password config.Secret   `toml:"password"`
+	Database int             `toml:"database"`
+	Key      string          `toml:"key"`
+	Timeout  config.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	client *redis.Client
+}
+
+func (*redisSource) name() string {
+	return "redis"
+}
+
+func (s *redisSource) init() error {
+	if s.Address == "" {
+		return errors.New("missing 'address'")
+	}
+	if s.Key == "" {
+		return errors.New("missing 'key'")
+	}
+	if s.Timeout == 0 {
+		s.Timeout = config.Duration(5 * time.Second)
+	}
+
+	tlsCfg, err := s.ClientConfig.TLSConfig()
+	if err != nil {
+		return fmt.Errorf("creating TLS config failed: %w", err)
+	}
+
+	username, err := s.Username.Get()
+	if err != nil {
+		return fmt.Errorf("getting username failed: %w", err)
+	}
+	defer username.Destroy()
+
+	password, err := s.Password.Get()
+	if err != nil {
+		return fmt.Errorf("getting password failed: %w", err)
+	}
+	defer password.Destroy()
+
+	s.client = redis.NewClient(&redis.Options{
+		Addr:         s.Address,
+		Username:     username.String(),
+		Password:     password.String(),
+		DB:           s.Database,
+		TLSConfig:    tlsCfg,
+		DialTimeout:  time.Duration(s.Timeout),
+		ReadTimeout:  time.Duration(s.Timeout),
+		WriteTimeout: time.Duration(s.Timeout),
+	})
+
+	return nil
+}
+
+func (s *redisSource) load(ctx context.Context) (map[string][]telegraf.Tag, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.Timeout))
+	defer cancel()
+
+	entries, err := s.client.HGetAll(ctx, s.Key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("reading hash %q failed: %w", s.Key, err)
+	}
+
+	mappings := make(map[string][]telegraf.Tag, len(entries))
+	for key, value := range entries {
+		var tags map[string]string
+		if err := json.Unmarshal([]byte(value), &tags); err != nil {
+			return nil, fmt.Errorf("parsing entry %q of hash %q failed: %w", key, s.Key, err)
+		}
+		for k, v := range tags {
+			mappings[key] = append(mappings[key], telegraf.Tag{Key: k, Value: v})
+		}
+	}
+
+	return mappings, nil
+}
+
+func (s *redisSource) close() error {
+	if s.client == nil {
+		return nil
+	}
+	return s.client.Close()
+}
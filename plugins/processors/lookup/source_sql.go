@@ -0,0 +1,137 @@
+package lookup
+
+import (
+	"context"
+	dbsql "database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+)
+
+// Aliases for the driver names, identical to the ones used by inputs.sql
+var driverAliases = map[string]string{
+	"cockroach": "pgx",
+	"tidb":      "mysql",
+	"mssql":     "sqlserver",
+	"maria":     "mysql",
+	"postgres":  "pgx",
+	"oracle":    "oracle",
+}
+
+// sqlSource queries the lookup table from a database. The first column of
+// the result contains the key and all other columns are used as tags with
+// the column name as tag-key.
+type sqlSource struct {
+	Driver  string          `toml:"driver"`
+	DSN     config.Secret   `toml:"dsn"`
+	Query   string          `toml:"query"`
+	Timeout config.Duration `toml:"timeout"`
+
+	db *dbsql.DB
+}
+
+func (*sqlSource) name() string {
+	return "sql"
+}
+
+func (s *sqlSource) init() error {
+	if s.Driver == "" {
+		return errors.New("missing 'driver'")
+	}
+	if s.Query == "" {
+		return errors.New("missing 'query'")
+	}
+	if s.Timeout == 0 {
+		s.Timeout = config.Duration(5 * time.Second)
+	}
+
+	driver := s.Driver
+	if d, ok := driverAliases[s.Driver]; ok {
+		driver = d
+	}
+	available := dbsql.Drivers()
+	if !slices.Contains(available, driver) {
+		for alias, d := range driverAliases {
+			if slices.Contains(available, d) {
+				available = append(available, alias)
+			}
+		}
+		sort.Strings(available)
+		return fmt.Errorf("driver %q not supported use one of %v", s.Driver, slices.Compact(available))
+	}
+
+	dsn, err := s.DSN.Get()
+	if err != nil {
+		return fmt.Errorf("getting DSN failed: %w", err)
+	}
+	defer dsn.Destroy()
+
+	// Opening the database does not connect so this will not fail for
+	// unreachable servers
+	db, err := dbsql.Open(driver, dsn.String())
+	if err != nil {
+		return fmt.Errorf("opening database failed: %w", err)
+	}
+	s.db = db
+
+	return nil
+}
+
+func (s *sqlSource) load(ctx context.Context) (map[string][]telegraf.Tag, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.Timeout))
+	defer cancel()
+
+	rows, err := s.db.QueryContext(ctx, s.Query)
+	if err != nil {
+		return nil, fmt.Errorf("executing query failed: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("getting columns failed: %w", err)
+	}
+	if len(columns) < 2 {
+		return nil, errors.New("query result has not enough columns, requiring at least `key,value`")
+	}
+
+	values := make([]dbsql.NullString, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	mappings := make(map[string][]telegraf.Tag)
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("scanning row failed: %w", err)
+		}
+		if !values[0].Valid {
+			continue
+		}
+
+		key := values[0].String
+		for i, v := range values[1:] {
+			if v.Valid && v.String != "" {
+				mappings[key] = append(mappings[key], telegraf.Tag{Key: columns[i+1], Value: v.String})
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading rows failed: %w", err)
+	}
+
+	return mappings, nil
+}
+
+func (s *sqlSource) close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
@@ -0,0 +1,77 @@
+//go:build !mips && !mipsle && !mips64 && !ppc64 && !riscv64 && !loong64 && !mips64le && !(windows && (386 || arm))
+
+package lookup
+
+import (
+	dbsql "database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestSQLSource(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "cmdb.db")
+	db, err := dbsql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`
+		CREATE TABLE hosts (name TEXT, location TEXT, rack TEXT, cores INTEGER);
+		INSERT INTO hosts VALUES ('green', 'eu-central', 'C12-01', 8);
+		INSERT INTO hosts VALUES ('red', 'us-west', NULL, 16);
+	`)
+	require.NoError(t, err)
+
+	plugin := &Processor{
+		SQL: &sqlSource{
+			Driver: "sqlite",
+			DSN:    config.NewSecret([]byte(dsn)),
+			Query:  "SELECT name, location, rack, cores FROM hosts",
+		},
+		KeyTemplate: `{{.Tag "host"}}`,
+		Log:         testutil.Logger{},
+	}
+	require.NoError(t, plugin.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, plugin.Start(&acc))
+	defer plugin.Stop()
+
+	input := []telegraf.Metric{
+		metric.New("test", map[string]string{"host": "green"}, map[string]interface{}{"value": 1}, time.Unix(0, 0)),
+		metric.New("test", map[string]string{"host": "red"}, map[string]interface{}{"value": 2}, time.Unix(0, 0)),
+		metric.New("test", map[string]string{"host": "blue"}, map[string]interface{}{"value": 3}, time.Unix(0, 0)),
+	}
+	expected := []telegraf.Metric{
+		metric.New(
+			"test",
+			map[string]string{"host": "green", "location": "eu-central", "rack": "C12-01", "cores": "8"},
+			map[string]interface{}{"value": 1},
+			time.Unix(0, 0),
+		),
+		metric.New(
+			"test",
+			map[string]string{"host": "red", "location": "us-west", "cores": "16"},
+			map[string]interface{}{"value": 2},
+			time.Unix(0, 0),
+		),
+		metric.New("test", map[string]string{"host": "blue"}, map[string]interface{}{"value": 3}, time.Unix(0, 0)),
+	}
+	for _, m := range input {
+		require.NoError(t, plugin.Add(m, &acc))
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+
+	// Check updates to the database are picked up on reload
+	_, err = db.Exec(`UPDATE hosts SET location = 'eu-west' WHERE name = 'green'`)
+	require.NoError(t, err)
+	require.NoError(t, plugin.reload(t.Context()))
+	require.Contains(t, plugin.table.Load().mappings["green"], telegraf.Tag{Key: "location", Value: "eu-west"})
+}
@@ -0,0 +1,308 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package rate
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal/choice"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+var (
+	availableRollovers = []string{"none", "32bit", "64bit", "auto"}
+	availableOnReset   = []string{"skip", "from_zero"}
+)
+
+type Rate struct {
+	Fields      []string        `toml:"fields"`
+	CounterType bool            `toml:"counter_type"`
+	Suffix      string          `toml:"suffix"`
+	Rollover    string          `toml:"rollover"`
+	OnReset     string          `toml:"on_reset"`
+	SeriesTTL   config.Duration `toml:"series_ttl"`
+	Log         telegraf.Logger `toml:"-"`
+
+	fieldFilter filter.Filter
+	samples     map[seriesKey]*sample
+	latest      time.Time
+	lastCleanup time.Time
+}
+
+// seriesKey identifies a field of a series
+type seriesKey struct {
+	id    uint64
+	field string
+}
+
+// sample holds the last value of a counter field of a series. Integer
+// counters are kept as unsigned integer to not lose precision on large
+// values.
+type sample struct {
+	ID      uint64    `json:"id"`
+	Field   string    `json:"field"`
+	Integer bool      `json:"integer"`
+	Uint    uint64    `json:"uint,omitempty"`
+	Float   float64   `json:"float,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+func (*Rate) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *Rate) Init() error {
+	if len(r.Fields) == 0 && !r.CounterType {
+		return errors.New("no fields configured and 'counter_type' disabled")
+	}
+	f, err := filter.Compile(r.Fields)
+	if err != nil {
+		return fmt.Errorf("creating field filter failed: %w", err)
+	}
+	r.fieldFilter = f
+
+	if err := choice.Check(r.Rollover, availableRollovers); err != nil {
+		return fmt.Errorf("invalid 'rollover' setting: %w", err)
+	}
+	if err := choice.Check(r.OnReset, availableOnReset); err != nil {
+		return fmt.Errorf("invalid 'on_reset' setting: %w", err)
+	}
+	if r.SeriesTTL < 0 {
+		return errors.New("'series_ttl' must not be negative")
+	}
+
+	r.samples = make(map[seriesKey]*sample)
+
+	return nil
+}
+
+func (r *Rate) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	out := in[:0]
+	for _, m := range in {
+		r.process(m)
+
+		// Metrics without remaining fields are removed
+		if len(m.FieldList()) == 0 {
+			m.Drop()
+			continue
+		}
+		out = append(out, m)
+	}
+	r.cleanup()
+	return out
+}
+
+func (r *Rate) process(m telegraf.Metric) {
+	id := m.HashID()
+	if m.Time().After(r.latest) {
+		r.latest = m.Time()
+	}
+	counter := r.CounterType && m.Type() == telegraf.Counter
+
+	// Collect the rates first to not modify the fields while iterating
+	rates := make(map[string]float64)
+	var converted []string
+	for _, field := range m.FieldList() {
+		if !counter && (r.fieldFilter == nil || !r.fieldFilter.Match(field.Key)) {
+			continue
+		}
+		current, ok := newSample(field.Value, m.Time())
+		if !ok {
+			continue
+		}
+		converted = append(converted, field.Key)
+
+		key := seriesKey{id, field.Key}
+		if rate, ok := r.update(key, current); ok {
+			rates[field.Key] = rate
+		}
+	}
+
+	// Without suffix the counter values are replaced by the rates and
+	// counters without a rate are removed
+	for _, key := range converted {
+		rate, found := rates[key]
+		if found {
+			m.AddField(key+r.Suffix, rate)
+		} else if r.Suffix == "" {
+			m.RemoveField(key)
+		}
+	}
+	if r.Suffix == "" && counter {
+		m.SetType(telegraf.Gauge)
+	}
+}
+
+// update computes the per-second rate between the last and the current
+// sample and stores the current sample. No rate is returned for the first
+// sample of a series, out-of-order samples and, depending on the settings,
+// after counter resets.
+func (r *Rate) update(key seriesKey, current *sample) (float64, bool) {
+	last, found := r.samples[key]
+	if found && r.SeriesTTL > 0 && current.Time.Sub(last.Time) > time.Duration(r.SeriesTTL) {
+		found = false
+	}
+	if !found {
+		current.ID, current.Field = key.id, key.field
+		r.samples[key] = current
+		return 0, false
+	}
+
+	elapsed := current.Time.Sub(last.Time).Seconds()
+	if elapsed <= 0 {
+		r.Log.Debugf("Ignoring sample of field %q of series %d not newer than the last one", key.field, key.id)
+		return 0, false
+	}
+
+	delta, ok := r.delta(last, current)
+	current.ID, current.Field = key.id, key.field
+	r.samples[key] = current
+	if !ok {
+		r.Log.Debugf("Counter reset detected for field %q of series %d", key.field, key.id)
+		if r.OnReset == "skip" {
+			return 0, false
+		}
+		delta = current.value()
+	}
+	return delta / elapsed, true
+}
+
+// delta returns the increase of the counter between the two samples taking
+// rollovers into account, false is returned if the counter was reset
+func (r *Rate) delta(last, current *sample) (float64, bool) {
+	if current.Integer && last.Integer {
+		if current.Uint >= last.Uint {
+			return float64(current.Uint - last.Uint), true
+		}
+	} else if current.value() >= last.value() {
+		return current.value() - last.value(), true
+	}
+
+	// The counter decreased so check if the counter wrapped around at its
+	// maximum value. A wrap is only assumed if the resulting increase is
+	// less than half of the counter range, otherwise the counter was reset.
+	var bits uint
+	switch r.Rollover {
+	case "none":
+		return 0, false
+	case "32bit":
+		bits = 32
+	case "64bit":
+		bits = 64
+	case "auto":
+		bits = 64
+		if last.value() <= math.MaxUint32 {
+			bits = 32
+		}
+	}
+	if bits == 32 && last.value() > math.MaxUint32 {
+		return 0, false
+	}
+
+	if current.Integer && last.Integer {
+		// Unsigned integer arithmetic wraps around the 64-bit range
+		delta := current.Uint - last.Uint
+		if bits == 32 {
+			delta &= math.MaxUint32
+		}
+		if delta >= uint64(1)<<(bits-1) {
+			return 0, false
+		}
+		return float64(delta), true
+	}
+	span := math.Pow(2, float64(bits))
+	delta := span - last.value() + current.value()
+	if delta >= span/2 {
+		return 0, false
+	}
+	return delta, true
+}
+
+func (r *Rate) cleanup() {
+	// Cleaning up too often is not necessary as expired series are reset on
+	// their next sample anyway. Expiry is based on the latest metric time
+	// seen to be consistent with the check when updating a series.
+	if r.SeriesTTL == 0 {
+		return
+	}
+	if r.lastCleanup.IsZero() {
+		r.lastCleanup = r.latest
+		return
+	}
+	if r.latest.Sub(r.lastCleanup) < time.Duration(r.SeriesTTL) {
+		return
+	}
+	r.lastCleanup = r.latest
+	for key, s := range r.samples {
+		if r.latest.Sub(s.Time) > time.Duration(r.SeriesTTL) {
+			delete(r.samples, key)
+		}
+	}
+}
+
+func (r *Rate) GetState() interface{} {
+	state := make([]*sample, 0, len(r.samples))
+	for _, s := range r.samples {
+		state = append(state, s)
+	}
+	return state
+}
+
+func (r *Rate) SetState(state interface{}) error {
+	restored, ok := state.([]*sample)
+	if !ok {
+		return fmt.Errorf("state has wrong type %T", state)
+	}
+	for _, s := range restored {
+		r.samples[seriesKey{s.ID, s.Field}] = s
+		if s.Time.After(r.latest) {
+			r.latest = s.Time
+		}
+	}
+	return nil
+}
+
+func newSample(v interface{}, t time.Time) (*sample, bool) {
+	switch v := v.(type) {
+	case uint64:
+		return &sample{Integer: true, Uint: v, Time: t}, true
+	case int64:
+		if v >= 0 {
+			return &sample{Integer: true, Uint: uint64(v), Time: t}, true
+		}
+		return &sample{Float: float64(v), Time: t}, true
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, false
+		}
+		return &sample{Float: v, Time: t}, true
+	}
+	return nil, false
+}
+
+func (s *sample) value() float64 {
+	if s.Integer {
+		return float64(s.Uint)
+	}
+	return s.Float
+}
+
+func init() {
+	processors.Add("rate", func() telegraf.Processor {
+		return &Rate{
+			Suffix:    "_rate",
+			Rollover:  "auto",
+			OnReset:   "skip",
+			SeriesTTL: config.Duration(time.Hour),
+		}
+	})
+}
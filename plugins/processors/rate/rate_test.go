@@ -0,0 +1,336 @@
+package rate
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		plugin   *Rate
+		expected string
+	}{
+		{
+			name:     "no fields",
+			plugin:   &Rate{Rollover: "auto", OnReset: "skip"},
+			expected: "no fields configured and 'counter_type' disabled",
+		},
+		{
+			name:     "invalid rollover",
+			plugin:   &Rate{Fields: []string{"*"}, Rollover: "16bit", OnReset: "skip"},
+			expected: "invalid 'rollover' setting",
+		},
+		{
+			name:     "invalid reset handling",
+			plugin:   &Rate{Fields: []string{"*"}, Rollover: "auto", OnReset: "foo"},
+			expected: "invalid 'on_reset' setting",
+		},
+		{
+			name:     "negative ttl",
+			plugin:   &Rate{Fields: []string{"*"}, Rollover: "auto", OnReset: "skip", SeriesTTL: config.Duration(-1)},
+			expected: "'series_ttl' must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestRates(t *testing.T) {
+	plugin := newPlugin()
+	plugin.Fields = []string{"bytes_*"}
+	require.NoError(t, plugin.Init())
+
+	input := []telegraf.Metric{
+		newMetric(map[string]interface{}{"bytes_recv": int64(1000), "bytes_sent": uint64(500), "errors": int64(0)}, 0),
+		newMetric(map[string]interface{}{"bytes_recv": int64(6000), "bytes_sent": uint64(1500), "errors": int64(3)}, 10),
+		newMetric(map[string]interface{}{"bytes_recv": 6500.0, "errors": int64(3)}, 15),
+		newMetric(map[string]interface{}{"bytes_recv": "invalid"}, 20),
+	}
+	expected := []telegraf.Metric{
+		newMetric(map[string]interface{}{"bytes_recv": int64(1000), "bytes_sent": uint64(500), "errors": int64(0)}, 0),
+		newMetric(map[string]interface{}{
+			"bytes_recv":      int64(6000),
+			"bytes_sent":      uint64(1500),
+			"errors":          int64(3),
+			"bytes_recv_rate": 500.0,
+			"bytes_sent_rate": 100.0,
+		}, 10),
+		newMetric(map[string]interface{}{"bytes_recv": 6500.0, "errors": int64(3), "bytes_recv_rate": 100.0}, 15),
+		newMetric(map[string]interface{}{"bytes_recv": "invalid"}, 20),
+	}
+
+	actual := plugin.Apply(input...)
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestSeries(t *testing.T) {
+	plugin := newPlugin()
+	plugin.Fields = []string{"requests"}
+	require.NoError(t, plugin.Init())
+
+	input := []telegraf.Metric{
+		metric.New("http", map[string]string{"host": "a"}, map[string]interface{}{"requests": int64(100)}, time.Unix(0, 0)),
+		metric.New("http", map[string]string{"host": "b"}, map[string]interface{}{"requests": int64(5000)}, time.Unix(0, 0)),
+		metric.New("http", map[string]string{"host": "a"}, map[string]interface{}{"requests": int64(200)}, time.Unix(10, 0)),
+		metric.New("http", map[string]string{"host": "b"}, map[string]interface{}{"requests": int64(5050)}, time.Unix(10, 0)),
+	}
+	expected := []telegraf.Metric{
+		metric.New("http", map[string]string{"host": "a"}, map[string]interface{}{"requests": int64(100)}, time.Unix(0, 0)),
+		metric.New("http", map[string]string{"host": "b"}, map[string]interface{}{"requests": int64(5000)}, time.Unix(0, 0)),
+		metric.New("http", map[string]string{"host": "a"}, map[string]interface{}{"requests": int64(200), "requests_rate": 10.0}, time.Unix(10, 0)),
+		metric.New("http", map[string]string{"host": "b"}, map[string]interface{}{"requests": int64(5050), "requests_rate": 5.0}, time.Unix(10, 0)),
+	}
+
+	actual := plugin.Apply(input...)
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestCounterType(t *testing.T) {
+	plugin := newPlugin()
+	plugin.CounterType = true
+	plugin.Suffix = ""
+	require.NoError(t, plugin.Init())
+
+	input := []telegraf.Metric{
+		metric.New("http_requests_total", map[string]string{}, map[string]interface{}{"counter": 10.0}, time.Unix(0, 0), telegraf.Counter),
+		metric.New("http_requests_total", map[string]string{}, map[string]interface{}{"counter": 30.0}, time.Unix(10, 0), telegraf.Counter),
+		metric.New("temperature", map[string]string{}, map[string]interface{}{"gauge": 20.0}, time.Unix(0, 0), telegraf.Gauge),
+		metric.New("temperature", map[string]string{}, map[string]interface{}{"gauge": 30.0}, time.Unix(10, 0), telegraf.Gauge),
+	}
+	// The first counter sample is dropped as it has no fields left
+	expected := []telegraf.Metric{
+		metric.New("http_requests_total", map[string]string{}, map[string]interface{}{"counter": 2.0}, time.Unix(10, 0), telegraf.Gauge),
+		metric.New("temperature", map[string]string{}, map[string]interface{}{"gauge": 20.0}, time.Unix(0, 0), telegraf.Gauge),
+		metric.New("temperature", map[string]string{}, map[string]interface{}{"gauge": 30.0}, time.Unix(10, 0), telegraf.Gauge),
+	}
+
+	actual := plugin.Apply(input...)
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestRollover(t *testing.T) {
+	tests := []struct {
+		name     string
+		rollover string
+		onReset  string
+		first    interface{}
+		second   interface{}
+		expected interface{}
+	}{
+		{
+			name:     "increase",
+			rollover: "none",
+			first:    uint64(100),
+			second:   uint64(150),
+			expected: 5.0,
+		},
+		{
+			name:     "32bit wrap",
+			rollover: "32bit",
+			first:    uint64(math.MaxUint32 - 49),
+			second:   uint64(50),
+			expected: 10.0,
+		},
+		{
+			name:     "32bit wrap auto",
+			rollover: "auto",
+			first:    int64(math.MaxUint32 - 49),
+			second:   int64(50),
+			expected: 10.0,
+		},
+		{
+			name:     "32bit wrap float",
+			rollover: "auto",
+			first:    float64(math.MaxUint32 - 49),
+			second:   50.0,
+			expected: 10.0,
+		},
+		{
+			name:     "64bit wrap",
+			rollover: "64bit",
+			first:    uint64(math.MaxUint64 - 49),
+			second:   uint64(50),
+			expected: 10.0,
+		},
+		{
+			name:     "64bit wrap auto",
+			rollover: "auto",
+			first:    uint64(math.MaxUint64 - 49),
+			second:   uint64(50),
+			expected: 10.0,
+		},
+		{
+			name:     "64bit counter with 32bit rollover",
+			rollover: "32bit",
+			first:    uint64(math.MaxUint64 - 49),
+			second:   uint64(50),
+		},
+		{
+			name:     "no rollover",
+			rollover: "none",
+			first:    uint64(math.MaxUint32 - 49),
+			second:   uint64(50),
+		},
+		{
+			name:     "reset",
+			rollover: "auto",
+			first:    uint64(1000),
+			second:   uint64(50),
+		},
+		{
+			name:     "reset from zero",
+			rollover: "auto",
+			onReset:  "from_zero",
+			first:    uint64(1000),
+			second:   uint64(50),
+			expected: 5.0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newPlugin()
+			plugin.Fields = []string{"value"}
+			plugin.Rollover = tt.rollover
+			if tt.onReset != "" {
+				plugin.OnReset = tt.onReset
+			}
+			require.NoError(t, plugin.Init())
+
+			plugin.Apply(newMetric(map[string]interface{}{"value": tt.first}, 0))
+			actual := plugin.Apply(newMetric(map[string]interface{}{"value": tt.second}, 10))
+			require.Len(t, actual, 1)
+			rate, found := actual[0].GetField("value_rate")
+			if tt.expected == nil {
+				require.False(t, found)
+				return
+			}
+			require.True(t, found)
+			require.InDelta(t, tt.expected, rate, 1e-6)
+		})
+	}
+}
+
+func TestOutOfOrder(t *testing.T) {
+	plugin := newPlugin()
+	plugin.Fields = []string{"value"}
+	require.NoError(t, plugin.Init())
+
+	plugin.Apply(newMetric(map[string]interface{}{"value": int64(100)}, 10))
+	actual := plugin.Apply(newMetric(map[string]interface{}{"value": int64(50)}, 5))
+	require.False(t, actual[0].HasField("value_rate"))
+
+	// The rate is computed against the newest sample
+	actual = plugin.Apply(newMetric(map[string]interface{}{"value": int64(200)}, 20))
+	rate, found := actual[0].GetField("value_rate")
+	require.True(t, found)
+	require.InDelta(t, 10.0, rate, 1e-6)
+}
+
+func TestSeriesTTL(t *testing.T) {
+	plugin := newPlugin()
+	plugin.Fields = []string{"value"}
+	plugin.SeriesTTL = config.Duration(time.Minute)
+	require.NoError(t, plugin.Init())
+
+	plugin.Apply(newMetric(map[string]interface{}{"value": int64(100)}, 0))
+	actual := plugin.Apply(newMetric(map[string]interface{}{"value": int64(200)}, 61))
+	require.False(t, actual[0].HasField("value_rate"))
+
+	actual = plugin.Apply(newMetric(map[string]interface{}{"value": int64(300)}, 71))
+	rate, found := actual[0].GetField("value_rate")
+	require.True(t, found)
+	require.InDelta(t, 10.0, rate, 1e-6)
+}
+
+func TestSeriesTTLCleanup(t *testing.T) {
+	plugin := newPlugin()
+	plugin.Fields = []string{"value"}
+	plugin.SeriesTTL = config.Duration(time.Minute)
+	require.NoError(t, plugin.Init())
+
+	// Series are expired by metric time and not by wall-clock time, so old
+	// metrics like the ones used here must be kept
+	plugin.Apply(
+		newMetric(map[string]interface{}{"value": int64(100)}, 0),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"value": int64(100)}, time.Unix(30, 0)),
+	)
+	require.Len(t, plugin.samples, 2)
+
+	plugin.Apply(metric.New("mem", map[string]string{}, map[string]interface{}{"value": int64(200)}, time.Unix(100, 0)))
+	require.Len(t, plugin.samples, 1)
+}
+
+func TestState(t *testing.T) {
+	input := []telegraf.Metric{
+		newMetric(map[string]interface{}{"value": uint64(math.MaxUint64 - 10)}, 0),
+		newMetric(map[string]interface{}{"value": uint64(math.MaxUint64 - 5)}, 1),
+		newMetric(map[string]interface{}{"value": uint64(5)}, 2),
+		newMetric(map[string]interface{}{"value": uint64(10)}, 3),
+	}
+
+	// Process the metrics in one go as reference
+	reference := newPlugin()
+	reference.Fields = []string{"value"}
+	require.NoError(t, reference.Init())
+	expected := reference.Apply(copyMetrics(input)...)
+
+	// Process the first half, then persist the state and continue with a
+	// new instance
+	first := newPlugin()
+	first.Fields = []string{"value"}
+	require.NoError(t, first.Init())
+	actual := first.Apply(copyMetrics(input[:2])...)
+
+	buf, err := json.Marshal(first.GetState())
+	require.NoError(t, err)
+
+	second := newPlugin()
+	second.Fields = []string{"value"}
+	require.NoError(t, second.Init())
+	var state []*sample
+	require.NoError(t, json.Unmarshal(buf, &state))
+	require.NoError(t, second.SetState(state))
+	actual = append(actual, second.Apply(copyMetrics(input[2:])...)...)
+
+	testutil.RequireMetricsEqual(t, expected, actual)
+	for _, m := range actual[1:] {
+		require.True(t, m.HasField("value_rate"))
+	}
+}
+
+func newPlugin() *Rate {
+	return &Rate{
+		Suffix:    "_rate",
+		Rollover:  "auto",
+		OnReset:   "skip",
+		SeriesTTL: config.Duration(time.Hour),
+		Log:       testutil.Logger{},
+	}
+}
+
+func newMetric(fields map[string]interface{}, sec int64) telegraf.Metric {
+	return metric.New("net", map[string]string{"interface": "eth0"}, fields, time.Unix(sec, 0))
+}
+
+func copyMetrics(in []telegraf.Metric) []telegraf.Metric {
+	out := make([]telegraf.Metric, 0, len(in))
+	for _, m := range in {
+		out = append(out, m.Copy())
+	}
+	return out
+}
@@ -0,0 +1,69 @@
+package redact
+
+import (
+	"regexp"
+	"strings"
+)
+
+// detector finds sensitive data in values
+type detector struct {
+	pattern *regexp.Regexp
+	// verify optionally checks the matches to reduce false positives
+	verify func(string) bool
+}
+
+var detectors = map[string]*detector{
+	"email": {
+		pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	},
+	"credit_card": {
+		// Card numbers with 13 to 19 digits optionally separated by spaces or
+		// dashes
+		pattern: regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`),
+		verify:  luhn,
+	},
+	"jwt": {
+		// The header and payload are base64url-encoded JSON objects thus
+		// always start with 'eyJ'
+		pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]+\.eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`),
+	},
+}
+
+// replace calls the given function for all verified matches in the value and
+// replaces the match with the result. The number of replacements is returned.
+func (d *detector) replace(value string, fn func(string) string) (string, int) {
+	var count int
+	result := d.pattern.ReplaceAllStringFunc(value, func(match string) string {
+		if d.verify != nil && !d.verify(match) {
+			return match
+		}
+		count++
+		return fn(match)
+	})
+	return result, count
+}
+
+// luhn checks the number using the Luhn algorithm used for credit-card numbers
+func luhn(number string) bool {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, number)
+
+	var sum int
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,123 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package redact
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+type Redact struct {
+	Rules []rule          `toml:"rule"`
+	Log   telegraf.Logger `toml:"-"`
+
+	alias      string
+	redactions map[string]selfstat.Stat
+}
+
+func (*Redact) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *Redact) SetAlias(alias string) {
+	r.alias = alias
+}
+
+func (r *Redact) Init() error {
+	if len(r.Rules) == 0 {
+		return errors.New("no rules configured")
+	}
+
+	r.redactions = make(map[string]selfstat.Stat, len(availableActions))
+	for _, action := range availableActions {
+		tags := map[string]string{"action": action}
+		if r.alias != "" {
+			tags["alias"] = r.alias
+		}
+		r.redactions[action] = selfstat.Register("redact", "redactions", tags)
+	}
+
+	for i := range r.Rules {
+		if err := r.Rules[i].init(); err != nil {
+			return fmt.Errorf("initialization of rule %d failed: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Redact) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	out := make([]telegraf.Metric, 0, len(in))
+	for _, m := range in {
+		if err := r.redact(m); err != nil {
+			// Drop the metric as it might still contain sensitive data
+			r.Log.Errorf("Redacting metric %q failed, dropping metric: %v", m.Name(), err)
+			m.Drop()
+			continue
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func (r *Redact) redact(m telegraf.Metric) error {
+	for i := range r.Rules {
+		if err := r.apply(m, &r.Rules[i]); err != nil {
+			return fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (r *Redact) apply(m telegraf.Metric, rl *rule) error {
+	if rl.tagFilter != nil {
+		for _, tag := range m.TagList() {
+			if !rl.tagFilter.Match(tag.Key) {
+				continue
+			}
+			value, count, err := rl.apply(tag.Value)
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				m.AddTag(tag.Key, value)
+				r.redactions[rl.Action].Incr(int64(count))
+			}
+		}
+	}
+
+	if rl.fieldFilter != nil {
+		for _, field := range m.FieldList() {
+			if !rl.fieldFilter.Match(field.Key) {
+				continue
+			}
+			// Only string fields can contain sensitive data
+			s, ok := field.Value.(string)
+			if !ok {
+				continue
+			}
+			value, count, err := rl.apply(s)
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				field.Value = value
+				r.redactions[rl.Action].Incr(int64(count))
+			}
+		}
+	}
+	return nil
+}
+
+func init() {
+	processors.Add("redact", func() telegraf.Processor {
+		return &Redact{}
+	})
+}
@@ -0,0 +1,214 @@
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"regexp"
+
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal/choice"
+)
+
+var availableActions = []string{"replace", "hash", "truncate_ip", "strip_query"}
+
+type rule struct {
+	Tags        []string      `toml:"tags"`
+	Fields      []string      `toml:"fields"`
+	Action      string        `toml:"action"`
+	Pattern     string        `toml:"pattern"`
+	Detectors   []string      `toml:"detectors"`
+	Replacement string        `toml:"replacement"`
+	Key         config.Secret `toml:"key"`
+	TokenLength int           `toml:"token_length"`
+	IPv4Prefix  int           `toml:"ipv4_prefix"`
+	IPv6Prefix  int           `toml:"ipv6_prefix"`
+
+	tagFilter   filter.Filter
+	fieldFilter filter.Filter
+	pattern     *regexp.Regexp
+	detectors   []*detector
+}
+
+func (r *rule) init() error {
+	if len(r.Tags) == 0 && len(r.Fields) == 0 {
+		return errors.New("either 'tags' or 'fields' must be set")
+	}
+
+	var err error
+	if r.tagFilter, err = filter.Compile(r.Tags); err != nil {
+		return fmt.Errorf("creating tag filter failed: %w", err)
+	}
+	if r.fieldFilter, err = filter.Compile(r.Fields); err != nil {
+		return fmt.Errorf("creating field filter failed: %w", err)
+	}
+
+	if err := choice.Check(r.Action, availableActions); err != nil {
+		return fmt.Errorf("invalid action: %w", err)
+	}
+
+	if r.Pattern != "" {
+		if r.pattern, err = regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("compiling pattern failed: %w", err)
+		}
+	}
+	for _, name := range r.Detectors {
+		d, found := detectors[name]
+		if !found {
+			return fmt.Errorf("unknown detector %q", name)
+		}
+		r.detectors = append(r.detectors, d)
+	}
+
+	switch r.Action {
+	case "replace":
+		if r.pattern == nil && len(r.detectors) == 0 {
+			return errors.New("'pattern' or 'detectors' required for action 'replace'")
+		}
+		if r.Replacement == "" {
+			r.Replacement = "[REDACTED]"
+		}
+	case "hash":
+		if r.Key.Empty() {
+			return errors.New("'key' required for action 'hash'")
+		}
+		key, err := r.Key.Get()
+		if err != nil {
+			return fmt.Errorf("getting key failed: %w", err)
+		}
+		key.Destroy()
+
+		if r.TokenLength == 0 {
+			r.TokenLength = 16
+		}
+		if r.TokenLength < 1 || r.TokenLength > 2*sha256.Size {
+			return fmt.Errorf("'token_length' must be in the range [1, %d]", 2*sha256.Size)
+		}
+	case "truncate_ip":
+		if r.pattern != nil || len(r.detectors) > 0 {
+			return errors.New("'pattern' and 'detectors' not supported for action 'truncate_ip'")
+		}
+		if r.IPv4Prefix == 0 {
+			r.IPv4Prefix = 24
+		}
+		if r.IPv6Prefix == 0 {
+			r.IPv6Prefix = 48
+		}
+		if r.IPv4Prefix < 1 || r.IPv4Prefix > 32 {
+			return errors.New("'ipv4_prefix' must be in the range [1, 32]")
+		}
+		if r.IPv6Prefix < 1 || r.IPv6Prefix > 128 {
+			return errors.New("'ipv6_prefix' must be in the range [1, 128]")
+		}
+	case "strip_query":
+		if r.pattern != nil || len(r.detectors) > 0 {
+			return errors.New("'pattern' and 'detectors' not supported for action 'strip_query'")
+		}
+	}
+
+	return nil
+}
+
+// apply redacts the value and returns the number of redactions
+func (r *rule) apply(value string) (string, int, error) {
+	switch r.Action {
+	case "replace":
+		// Use the regular expression replacement for the pattern to support
+		// references to capture groups
+		var count int
+		if r.pattern != nil {
+			count = len(r.pattern.FindAllStringIndex(value, -1))
+			value = r.pattern.ReplaceAllString(value, r.Replacement)
+		}
+		for _, d := range r.detectors {
+			var n int
+			value, n = d.replace(value, func(string) string { return r.Replacement })
+			count += n
+		}
+		return value, count, nil
+	case "hash":
+		// Only unwrap the key for the time of hashing to keep it protected
+		key, err := r.Key.Get()
+		if err != nil {
+			return value, 0, fmt.Errorf("getting key failed: %w", err)
+		}
+		defer key.Destroy()
+
+		token := func(v string) string {
+			return r.token(key.Bytes(), v)
+		}
+		if r.pattern == nil && len(r.detectors) == 0 {
+			return token(value), 1, nil
+		}
+		value, count := r.replace(value, token)
+		return value, count, nil
+	case "truncate_ip":
+		value, count := r.truncateIP(value)
+		return value, count, nil
+	case "strip_query":
+		value, count := stripQuery(value)
+		return value, count, nil
+	}
+	return value, 0, nil
+}
+
+// replace substitutes all matches of the pattern and detectors
+func (r *rule) replace(value string, fn func(string) string) (string, int) {
+	var total int
+	if r.pattern != nil {
+		value = r.pattern.ReplaceAllStringFunc(value, func(match string) string {
+			total++
+			return fn(match)
+		})
+	}
+	for _, d := range r.detectors {
+		var count int
+		value, count = d.replace(value, fn)
+		total += count
+	}
+	return value, total
+}
+
+// token computes a stable, non-reversible token for the value
+func (r *rule) token(key []byte, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))[:r.TokenLength]
+}
+
+func (r *rule) truncateIP(value string) (string, int) {
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return value, 0
+	}
+
+	bits := r.IPv6Prefix
+	if addr.Is4() || addr.Is4In6() {
+		addr = addr.Unmap()
+		bits = r.IPv4Prefix
+	}
+	prefix, err := addr.WithZone("").Prefix(bits)
+	if err != nil {
+		return value, 0
+	}
+	return prefix.Addr().String(), 1
+}
+
+func stripQuery(value string) (string, int) {
+	u, err := url.Parse(value)
+	if err != nil || (u.RawQuery == "" && u.Fragment == "" && u.User == nil && !u.ForceQuery) {
+		return value, 0
+	}
+
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.User = nil
+	return u.String(), 1
+}
@@ -5,9 +5,8 @@ import (
 	_ "embed"
 	"fmt"
 
-	"github.com/golang/geo/s2"
-
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/s2cell"
 	"github.com/influxdata/telegraf/plugins/processors"
 )
 
@@ -45,9 +44,7 @@ func (g *Geo) Apply(in ...telegraf.Metric) []telegraf.Metric {
 			}
 		}
 		if latOk && lonOk {
-			cellID := s2.CellIDFromLatLng(s2.LatLngFromDegrees(lat, lon))
-			if cellID.IsValid() {
-				value := cellID.Parent(g.CellLevel).ToToken()
+			if value, ok := s2cell.Token(lat, lon, g.CellLevel); ok {
 				point.AddTag(g.TagKey, value)
 			}
 		}
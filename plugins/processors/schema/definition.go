@@ -0,0 +1,110 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal/choice"
+)
+
+var availableTypes = []string{"float", "integer", "unsigned", "string", "boolean"}
+
+// definition is the schema of a single measurement
+type definition struct {
+	Tags         tagDefinition               `json:"tags"`
+	Fields       map[string]*fieldDefinition `json:"fields"`
+	StrictFields bool                        `json:"strict_fields"`
+}
+
+type tagDefinition struct {
+	Required []string `json:"required"`
+	// Allowed restricts the tags to the required and allowed ones if set,
+	// supports wildcards
+	Allowed []string `json:"allowed"`
+
+	allowed    filter.Filter
+	restricted bool
+}
+
+type fieldDefinition struct {
+	Type     string   `json:"type"`
+	Unit     string   `json:"unit"`
+	Min      *float64 `json:"min"`
+	Max      *float64 `json:"max"`
+	Required bool     `json:"required"`
+}
+
+// loadDefinitions reads the measurement schemas from the given JSON files
+func loadDefinitions(files []string) (map[string]*definition, error) {
+	definitions := make(map[string]*definition)
+	for _, fn := range files {
+		buf, err := os.ReadFile(fn)
+		if err != nil {
+			return nil, fmt.Errorf("reading %q failed: %w", fn, err)
+		}
+
+		var defs map[string]*definition
+		if err := json.Unmarshal(buf, &defs); err != nil {
+			return nil, fmt.Errorf("parsing %q failed: %w", fn, err)
+		}
+
+		for name, def := range defs {
+			if _, found := definitions[name]; found {
+				return nil, fmt.Errorf("duplicate schema for measurement %q in %q", name, fn)
+			}
+			if def == nil {
+				return nil, fmt.Errorf("empty schema for measurement %q in %q", name, fn)
+			}
+			if err := def.init(); err != nil {
+				return nil, fmt.Errorf("invalid schema for measurement %q in %q: %w", name, fn, err)
+			}
+			definitions[name] = def
+		}
+	}
+
+	return definitions, nil
+}
+
+func (d *definition) init() error {
+	// The allowed tags also include the required ones, an empty list of
+	// allowed tags (but not a missing one) restricts the tags to the
+	// required tags
+	if d.Tags.Allowed != nil {
+		f, err := filter.Compile(slices.Concat(d.Tags.Required, d.Tags.Allowed))
+		if err != nil {
+			return fmt.Errorf("creating tag filter failed: %w", err)
+		}
+		d.Tags.allowed = f
+		d.Tags.restricted = true
+	}
+
+	for name, field := range d.Fields {
+		if field == nil {
+			return fmt.Errorf("empty definition of field %q", name)
+		}
+		if field.Type != "" {
+			if err := choice.Check(field.Type, availableTypes); err != nil {
+				return fmt.Errorf("invalid type of field %q: %w", name, err)
+			}
+		}
+		if (field.Min != nil || field.Max != nil) && (field.Type == "string" || field.Type == "boolean") {
+			return fmt.Errorf("range of field %q not supported for type %q", name, field.Type)
+		}
+		if field.Min != nil && field.Max != nil && *field.Min > *field.Max {
+			return fmt.Errorf("minimum of field %q exceeds maximum", name)
+		}
+	}
+
+	return nil
+}
+
+// isAllowedTag checks if the tag is allowed for the measurement
+func (d *definition) isAllowedTag(key string) bool {
+	if !d.Tags.restricted {
+		return true
+	}
+	return d.Tags.allowed != nil && d.Tags.allowed.Match(key)
+}
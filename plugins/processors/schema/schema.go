@@ -0,0 +1,394 @@
+//go:generate ../../../tools/readme_config_includer/generator
+package schema
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/choice"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+//go:embed sample.conf
+var sampleConfig string
+
+// Violations of the schema
+const (
+	violationUnknownMeasurement = "unknown_measurement"
+	violationMissingTag         = "missing_tag"
+	violationUnknownTag         = "unknown_tag"
+	violationMissingField       = "missing_field"
+	violationUnknownField       = "unknown_field"
+	violationTypeMismatch       = "type_mismatch"
+	violationOutOfRange         = "out_of_range"
+	violationUnitMismatch       = "unit_mismatch"
+)
+
+// Actions taken on violations in increasing severity
+const (
+	actionIgnore     = "ignore"
+	actionCoerce     = "coerce"
+	actionDropField  = "drop_field"
+	actionQuarantine = "quarantine"
+	actionDropMetric = "drop_metric"
+)
+
+var severity = map[string]int{
+	actionIgnore:     0,
+	actionCoerce:     1,
+	actionDropField:  1,
+	actionQuarantine: 2,
+	actionDropMetric: 3,
+}
+
+type Schema struct {
+	Files              []string        `toml:"files"`
+	UnknownMeasurement string          `toml:"unknown_measurement"`
+	MissingTag         string          `toml:"missing_tag"`
+	UnknownTag         string          `toml:"unknown_tag"`
+	MissingField       string          `toml:"missing_field"`
+	UnknownField       string          `toml:"unknown_field"`
+	TypeMismatch       string          `toml:"type_mismatch"`
+	OutOfRange         string          `toml:"out_of_range"`
+	UnitMismatch       string          `toml:"unit_mismatch"`
+	UnitTag            string          `toml:"unit_tag"`
+	QuarantineName     string          `toml:"quarantine_name"`
+	Log                telegraf.Logger `toml:"-"`
+
+	alias       string
+	definitions map[string]*definition
+	actions     map[string]string
+
+	violations        map[string]selfstat.Stat
+	fieldsCoerced     selfstat.Stat
+	fieldsDropped     selfstat.Stat
+	metricsDropped    selfstat.Stat
+	metricsQuarantine selfstat.Stat
+}
+
+// violation of the schema found for a metric
+type violation struct {
+	kind   string
+	action string
+	key    string
+}
+
+func (*Schema) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Schema) SetAlias(alias string) {
+	s.alias = alias
+}
+
+func (s *Schema) Init() error {
+	if len(s.Files) == 0 {
+		return errors.New("no schema files configured")
+	}
+	definitions, err := loadDefinitions(s.Files)
+	if err != nil {
+		return err
+	}
+	s.definitions = definitions
+
+	// Check the actions for each violation as not all actions make sense for
+	// all violations
+	metricActions := []string{actionIgnore, actionQuarantine, actionDropMetric}
+	fieldActions := []string{actionIgnore, actionDropField, actionQuarantine, actionDropMetric}
+	valueActions := []string{actionIgnore, actionCoerce, actionDropField, actionQuarantine, actionDropMetric}
+	checks := []struct {
+		kind      string
+		action    string
+		available []string
+	}{
+		{violationUnknownMeasurement, s.UnknownMeasurement, metricActions},
+		{violationMissingTag, s.MissingTag, metricActions},
+		{violationUnknownTag, s.UnknownTag, fieldActions},
+		{violationMissingField, s.MissingField, metricActions},
+		{violationUnknownField, s.UnknownField, fieldActions},
+		{violationTypeMismatch, s.TypeMismatch, valueActions},
+		{violationOutOfRange, s.OutOfRange, valueActions},
+		{violationUnitMismatch, s.UnitMismatch, fieldActions},
+	}
+	s.actions = make(map[string]string, len(checks))
+	s.violations = make(map[string]selfstat.Stat, len(checks))
+	for _, c := range checks {
+		if err := choice.Check(c.action, c.available); err != nil {
+			return fmt.Errorf("invalid action for %q: %w", c.kind, err)
+		}
+		s.actions[c.kind] = c.action
+		tags := s.statTags()
+		tags["violation"] = c.kind
+		s.violations[c.kind] = selfstat.Register("schema", "violations", tags)
+	}
+
+	if s.QuarantineName == "" {
+		return errors.New("'quarantine_name' must not be empty")
+	}
+
+	s.fieldsCoerced = selfstat.Register("schema", "fields_coerced", s.statTags())
+	s.fieldsDropped = selfstat.Register("schema", "fields_dropped", s.statTags())
+	s.metricsDropped = selfstat.Register("schema", "metrics_dropped", s.statTags())
+	s.metricsQuarantine = selfstat.Register("schema", "metrics_quarantined", s.statTags())
+
+	return nil
+}
+
+// statTags returns the tags of the internal statistics to distinguish
+// multiple instances of the plugin
+func (s *Schema) statTags() map[string]string {
+	tags := make(map[string]string)
+	if s.alias != "" {
+		tags["alias"] = s.alias
+	}
+	return tags
+}
+
+func (s *Schema) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	out := make([]telegraf.Metric, 0, len(in))
+	for _, m := range in {
+		if s.process(m) {
+			out = append(out, m)
+		} else {
+			m.Drop()
+		}
+	}
+	return out
+}
+
+// process checks the metric against the schema and handles the violations.
+// The function returns false if the metric should be dropped.
+func (s *Schema) process(m telegraf.Metric) bool {
+	def, found := s.definitions[m.Name()]
+	if !found {
+		return s.handle(m, []violation{s.violation(violationUnknownMeasurement, "")}, nil)
+	}
+
+	var violations []violation
+	for _, key := range def.Tags.Required {
+		if !m.HasTag(key) {
+			violations = append(violations, s.violation(violationMissingTag, key))
+		}
+	}
+	for _, tag := range m.TagList() {
+		if !def.isAllowedTag(tag.Key) {
+			violations = append(violations, s.violation(violationUnknownTag, tag.Key))
+		}
+	}
+
+	for name, field := range def.Fields {
+		if field.Required && !m.HasField(name) {
+			violations = append(violations, s.violation(violationMissingField, name))
+		}
+	}
+
+	unit, hasUnit := "", false
+	if s.UnitTag != "" {
+		unit, hasUnit = m.GetTag(s.UnitTag)
+	}
+
+	// Determine the coerced values while checking the fields, those are only
+	// applied if the metric is neither dropped nor quarantined
+	coerced := make(map[string]interface{})
+	for _, f := range m.FieldList() {
+		field, found := def.Fields[f.Key]
+		if !found {
+			if def.StrictFields {
+				violations = append(violations, s.violation(violationUnknownField, f.Key))
+			}
+			continue
+		}
+
+		if hasUnit && field.Unit != "" && field.Unit != unit {
+			violations = append(violations, s.violation(violationUnitMismatch, f.Key))
+		}
+
+		value := f.Value
+		if field.Type != "" && !matchesType(value, field.Type) {
+			v := s.violation(violationTypeMismatch, f.Key)
+			violations = append(violations, v)
+			if v.action != actionCoerce {
+				continue
+			}
+			converted, err := convert(value, field.Type)
+			if err != nil {
+				s.Log.Debugf("Coercing field %q of metric %q to %s failed: %v", f.Key, m.Name(), field.Type, err)
+				coerced[f.Key] = nil
+				continue
+			}
+			value = converted
+			coerced[f.Key] = value
+		}
+
+		if clamped, inRange := field.clamp(value); !inRange {
+			v := s.violation(violationOutOfRange, f.Key)
+			violations = append(violations, v)
+			if v.action == actionCoerce {
+				coerced[f.Key] = clamped
+			}
+		}
+	}
+
+	return s.handle(m, violations, coerced)
+}
+
+func (s *Schema) violation(kind, key string) violation {
+	s.violations[kind].Incr(1)
+	return violation{kind: kind, action: s.actions[kind], key: key}
+}
+
+// handle applies the most severe action of all violations to the metric and
+// returns false if the metric should be dropped
+func (s *Schema) handle(m telegraf.Metric, violations []violation, coerced map[string]interface{}) bool {
+	if len(violations) == 0 {
+		return true
+	}
+
+	// Metric-wide actions take precedence and leave the metric unmodified
+	worst := violations[0]
+	for _, v := range violations[1:] {
+		if severity[v.action] > severity[worst.action] {
+			worst = v
+		}
+	}
+	switch worst.action {
+	case actionDropMetric:
+		s.Log.Debugf("Dropping metric %q due to %s %q", m.Name(), worst.kind, worst.key)
+		s.metricsDropped.Incr(1)
+		return false
+	case actionQuarantine:
+		m.AddTag("schema_measurement", m.Name())
+		m.AddTag("schema_violation", worst.kind)
+		m.SetName(s.QuarantineName)
+		s.metricsQuarantine.Incr(1)
+		return true
+	}
+
+	// Handle the field-level actions
+	for _, v := range violations {
+		switch v.action {
+		case actionDropField:
+			switch v.kind {
+			case violationUnknownTag:
+				m.RemoveTag(v.key)
+			default:
+				if m.HasField(v.key) {
+					m.RemoveField(v.key)
+					s.fieldsDropped.Incr(1)
+				}
+			}
+		case actionCoerce:
+			value, found := coerced[v.key]
+			if !found || !m.HasField(v.key) {
+				continue
+			}
+			// Remove fields which cannot be converted
+			m.RemoveField(v.key)
+			if value == nil {
+				s.fieldsDropped.Incr(1)
+				continue
+			}
+			m.AddField(v.key, value)
+			s.fieldsCoerced.Incr(1)
+			delete(coerced, v.key)
+		}
+	}
+
+	return true
+}
+
+func matchesType(value interface{}, typ string) bool {
+	switch value.(type) {
+	case float64:
+		return typ == "float"
+	case int64:
+		return typ == "integer"
+	case uint64:
+		return typ == "unsigned"
+	case string:
+		return typ == "string"
+	case bool:
+		return typ == "boolean"
+	}
+	return false
+}
+
+func convert(value interface{}, typ string) (interface{}, error) {
+	switch typ {
+	case "float":
+		return internal.ToFloat64(value)
+	case "integer":
+		return internal.ToInt64(value)
+	case "unsigned":
+		return internal.ToUint64(value)
+	case "string":
+		return internal.ToString(value)
+	case "boolean":
+		return internal.ToBool(value)
+	}
+	return nil, fmt.Errorf("unknown type %q", typ)
+}
+
+// clamp checks if a numeric value is within the range of the field and
+// returns the value limited to the range
+func (f *fieldDefinition) clamp(value interface{}) (interface{}, bool) {
+	if f.Min == nil && f.Max == nil {
+		return value, true
+	}
+
+	var v float64
+	switch x := value.(type) {
+	case float64:
+		v = x
+	case int64:
+		v = float64(x)
+	case uint64:
+		v = float64(x)
+	default:
+		return value, true
+	}
+
+	lower, upper := math.Inf(-1), math.Inf(1)
+	if f.Min != nil {
+		lower = *f.Min
+	}
+	if f.Max != nil {
+		upper = *f.Max
+	}
+	if v >= lower && v <= upper {
+		return value, true
+	}
+
+	limit := lower
+	if v > upper {
+		limit = upper
+	}
+	switch value.(type) {
+	case int64:
+		return int64(limit), false
+	case uint64:
+		return uint64(limit), false
+	}
+	return limit, false
+}
+
+func init() {
+	processors.Add("schema", func() telegraf.Processor {
+		return &Schema{
+			UnknownMeasurement: actionIgnore,
+			MissingTag:         actionQuarantine,
+			UnknownTag:         actionIgnore,
+			MissingField:       actionQuarantine,
+			UnknownField:       actionIgnore,
+			TypeMismatch:       actionCoerce,
+			OutOfRange:         actionDropField,
+			UnitMismatch:       actionQuarantine,
+			QuarantineName:     "schema_quarantine",
+		}
+	})
+}
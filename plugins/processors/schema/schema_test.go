@@ -0,0 +1,380 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestInitFail(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		modify   func(*Schema)
+		expected string
+	}{
+		{
+			name:     "invalid json",
+			schema:   `{"cpu": [}`,
+			expected: "parsing",
+		},
+		{
+			name:     "invalid type",
+			schema:   `{"cpu": {"fields": {"usage": {"type": "double"}}}}`,
+			expected: `invalid type of field "usage"`,
+		},
+		{
+			name:     "range for string",
+			schema:   `{"cpu": {"fields": {"usage": {"type": "string", "min": 0}}}}`,
+			expected: `range of field "usage" not supported for type "string"`,
+		},
+		{
+			name:     "invalid range",
+			schema:   `{"cpu": {"fields": {"usage": {"type": "float", "min": 10, "max": 0}}}}`,
+			expected: `minimum of field "usage" exceeds maximum`,
+		},
+		{
+			name:     "invalid action",
+			schema:   `{}`,
+			modify:   func(s *Schema) { s.MissingTag = "coerce" },
+			expected: `invalid action for "missing_tag"`,
+		},
+		{
+			name:     "empty quarantine name",
+			schema:   `{}`,
+			modify:   func(s *Schema) { s.QuarantineName = "" },
+			expected: "'quarantine_name' must not be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := filepath.Join(t.TempDir(), "schema.json")
+			require.NoError(t, os.WriteFile(fn, []byte(tt.schema), 0600))
+
+			plugin := newPlugin(fn)
+			if tt.modify != nil {
+				tt.modify(plugin)
+			}
+			require.ErrorContains(t, plugin.Init(), tt.expected)
+		})
+	}
+}
+
+func TestDuplicateSchema(t *testing.T) {
+	plugin := newPlugin(filepath.Join("testdata", "schema.json"), filepath.Join("testdata", "schema.json"))
+	require.ErrorContains(t, plugin.Init(), `duplicate schema for measurement`)
+}
+
+func TestCases(t *testing.T) {
+	tm := time.Unix(1700000000, 0)
+	valid := metric.New(
+		"cpu",
+		map[string]string{"host": "server01", "cpu": "cpu0", "dc_room": "a"},
+		map[string]interface{}{"usage_user": 12.5, "usage_system": 3.5, "processes": int64(312)},
+		tm,
+	)
+
+	tests := []struct {
+		name     string
+		modify   func(*Schema)
+		input    []telegraf.Metric
+		expected []telegraf.Metric
+	}{
+		{
+			name:     "valid",
+			input:    []telegraf.Metric{valid},
+			expected: []telegraf.Metric{valid},
+		},
+		{
+			name: "unknown measurement ignored",
+			input: []telegraf.Metric{
+				metric.New("mem", map[string]string{}, map[string]interface{}{"used": 1.0}, tm),
+			},
+			expected: []telegraf.Metric{
+				metric.New("mem", map[string]string{}, map[string]interface{}{"used": 1.0}, tm),
+			},
+		},
+		{
+			name:   "unknown measurement dropped",
+			modify: func(s *Schema) { s.UnknownMeasurement = "drop_metric" },
+			input: []telegraf.Metric{
+				metric.New("mem", map[string]string{}, map[string]interface{}{"used": 1.0}, tm),
+				valid,
+			},
+			expected: []telegraf.Metric{valid},
+		},
+		{
+			name: "missing tag quarantined",
+			input: []telegraf.Metric{
+				metric.New("cpu", map[string]string{"host": "server01"}, map[string]interface{}{"usage_user": 12.5}, tm),
+			},
+			expected: []telegraf.Metric{
+				metric.New(
+					"schema_quarantine",
+					map[string]string{"host": "server01", "schema_measurement": "cpu", "schema_violation": "missing_tag"},
+					map[string]interface{}{"usage_user": 12.5},
+					tm,
+				),
+			},
+		},
+		{
+			name:   "unknown tag removed",
+			modify: func(s *Schema) { s.UnknownTag = "drop_field" },
+			input: []telegraf.Metric{
+				metric.New("cpu", map[string]string{"host": "server01", "cpu": "cpu0", "rack": "r1"}, map[string]interface{}{"usage_user": 12.5}, tm),
+			},
+			expected: []telegraf.Metric{
+				metric.New("cpu", map[string]string{"host": "server01", "cpu": "cpu0"}, map[string]interface{}{"usage_user": 12.5}, tm),
+			},
+		},
+		{
+			name:   "unknown tag dropped",
+			modify: func(s *Schema) { s.UnknownTag = "drop_metric" },
+			input: []telegraf.Metric{
+				metric.New("cpu", map[string]string{"host": "server01", "cpu": "cpu0", "rack": "r1"}, map[string]interface{}{"usage_user": 12.5}, tm),
+			},
+		},
+		{
+			name: "missing field quarantined",
+			input: []telegraf.Metric{
+				metric.New("cpu", map[string]string{"host": "server01", "cpu": "cpu0"}, map[string]interface{}{"usage_system": 3.5}, tm),
+			},
+			expected: []telegraf.Metric{
+				metric.New(
+					"schema_quarantine",
+					map[string]string{"host": "server01", "cpu": "cpu0", "schema_measurement": "cpu", "schema_violation": "missing_field"},
+					map[string]interface{}{"usage_system": 3.5},
+					tm,
+				),
+			},
+		},
+		{
+			name:   "unknown field removed",
+			modify: func(s *Schema) { s.UnknownField = "drop_field" },
+			input: []telegraf.Metric{
+				metric.New("cpu", map[string]string{"host": "server01", "cpu": "cpu0"}, map[string]interface{}{"usage_user": 12.5, "usage_idle": 80.0}, tm),
+				metric.New("disk", map[string]string{"path": "/"}, map[string]interface{}{"free": uint64(1024), "used": uint64(512)}, tm),
+			},
+			expected: []telegraf.Metric{
+				metric.New("cpu", map[string]string{"host": "server01", "cpu": "cpu0"}, map[string]interface{}{"usage_user": 12.5}, tm),
+				// Unknown fields are allowed for non-strict schemas
+				metric.New("disk", map[string]string{"path": "/"}, map[string]interface{}{"free": uint64(1024), "used": uint64(512)}, tm),
+			},
+		},
+		{
+			name: "type coerced",
+			input: []telegraf.Metric{
+				metric.New(
+					"cpu",
+					map[string]string{"host": "server01", "cpu": "cpu0"},
+					map[string]interface{}{"usage_user": int64(12), "usage_system": "3.5", "processes": 312.0},
+					tm,
+				),
+				metric.New(
+					"disk",
+					map[string]string{"path": "/"},
+					map[string]interface{}{"free": int64(1024), "mounted": "true", "fstype": int64(4)},
+					tm,
+				),
+			},
+			expected: []telegraf.Metric{
+				metric.New(
+					"cpu",
+					map[string]string{"host": "server01", "cpu": "cpu0"},
+					map[string]interface{}{"usage_user": 12.0, "usage_system": 3.5, "processes": int64(312)},
+					tm,
+				),
+				metric.New(
+					"disk",
+					map[string]string{"path": "/"},
+					map[string]interface{}{"free": uint64(1024), "mounted": true, "fstype": "4"},
+					tm,
+				),
+			},
+		},
+		{
+			name: "type coercion failed",
+			input: []telegraf.Metric{
+				metric.New(
+					"cpu",
+					map[string]string{"host": "server01", "cpu": "cpu0"},
+					map[string]interface{}{"usage_user": 12.5, "usage_system": "n/a"},
+					tm,
+				),
+			},
+			expected: []telegraf.Metric{
+				metric.New("cpu", map[string]string{"host": "server01", "cpu": "cpu0"}, map[string]interface{}{"usage_user": 12.5}, tm),
+			},
+		},
+		{
+			name:   "type mismatch quarantined",
+			modify: func(s *Schema) { s.TypeMismatch = "quarantine" },
+			input: []telegraf.Metric{
+				metric.New("disk", map[string]string{"path": "/"}, map[string]interface{}{"free": "1024"}, tm),
+			},
+			expected: []telegraf.Metric{
+				metric.New(
+					"schema_quarantine",
+					map[string]string{"path": "/", "schema_measurement": "disk", "schema_violation": "type_mismatch"},
+					map[string]interface{}{"free": "1024"},
+					tm,
+				),
+			},
+		},
+		{
+			name: "out of range removed",
+			input: []telegraf.Metric{
+				metric.New(
+					"cpu",
+					map[string]string{"host": "server01", "cpu": "cpu0"},
+					map[string]interface{}{"usage_user": 12.5, "usage_system": 103.5, "processes": int64(-1)},
+					tm,
+				),
+			},
+			expected: []telegraf.Metric{
+				metric.New("cpu", map[string]string{"host": "server01", "cpu": "cpu0"}, map[string]interface{}{"usage_user": 12.5}, tm),
+			},
+		},
+		{
+			name:   "out of range clamped",
+			modify: func(s *Schema) { s.OutOfRange = "coerce" },
+			input: []telegraf.Metric{
+				metric.New(
+					"cpu",
+					map[string]string{"host": "server01", "cpu": "cpu0"},
+					map[string]interface{}{"usage_user": 12.5, "usage_system": "103.5", "processes": int64(-1)},
+					tm,
+				),
+			},
+			expected: []telegraf.Metric{
+				metric.New(
+					"cpu",
+					map[string]string{"host": "server01", "cpu": "cpu0"},
+					map[string]interface{}{"usage_user": 12.5, "usage_system": 100.0, "processes": int64(0)},
+					tm,
+				),
+			},
+		},
+		{
+			name:   "unit mismatch",
+			modify: func(s *Schema) { s.UnitTag = "unit" },
+			input: []telegraf.Metric{
+				metric.New("disk", map[string]string{"path": "/", "unit": "bytes"}, map[string]interface{}{"free": uint64(1024)}, tm),
+				metric.New("disk", map[string]string{"path": "/", "unit": "kB"}, map[string]interface{}{"free": uint64(1)}, tm),
+			},
+			expected: []telegraf.Metric{
+				metric.New("disk", map[string]string{"path": "/", "unit": "bytes"}, map[string]interface{}{"free": uint64(1024)}, tm),
+				metric.New(
+					"schema_quarantine",
+					map[string]string{"path": "/", "unit": "kB", "schema_measurement": "disk", "schema_violation": "unit_mismatch"},
+					map[string]interface{}{"free": uint64(1)},
+					tm,
+				),
+			},
+		},
+		{
+			name:   "drop metric takes precedence",
+			modify: func(s *Schema) { s.OutOfRange = "drop_metric" },
+			input: []telegraf.Metric{
+				metric.New("cpu", map[string]string{"host": "server01"}, map[string]interface{}{"usage_user": 112.5}, tm),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := newPlugin(filepath.Join("testdata", "schema.json"))
+			if tt.modify != nil {
+				tt.modify(plugin)
+			}
+			require.NoError(t, plugin.Init())
+
+			input := make([]telegraf.Metric, 0, len(tt.input))
+			for _, m := range tt.input {
+				input = append(input, m.Copy())
+			}
+			actual := plugin.Apply(input...)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestStatistics(t *testing.T) {
+	plugin := newPlugin(filepath.Join("testdata", "schema.json"))
+	require.NoError(t, plugin.Init())
+
+	before := map[string]int64{
+		"type_mismatch":       plugin.violations["type_mismatch"].Get(),
+		"out_of_range":        plugin.violations["out_of_range"].Get(),
+		"missing_tag":         plugin.violations["missing_tag"].Get(),
+		"fields_coerced":      plugin.fieldsCoerced.Get(),
+		"fields_dropped":      plugin.fieldsDropped.Get(),
+		"metrics_quarantined": plugin.metricsQuarantine.Get(),
+	}
+
+	tm := time.Unix(1700000000, 0)
+	plugin.Apply(
+		metric.New(
+			"cpu",
+			map[string]string{"host": "server01", "cpu": "cpu0"},
+			map[string]interface{}{"usage_user": "12.5", "usage_system": 103.5},
+			tm,
+		),
+		metric.New("cpu", map[string]string{"host": "server01"}, map[string]interface{}{"usage_user": 12.5}, tm),
+	)
+
+	require.Equal(t, int64(1), plugin.violations["type_mismatch"].Get()-before["type_mismatch"])
+	require.Equal(t, int64(1), plugin.violations["out_of_range"].Get()-before["out_of_range"])
+	require.Equal(t, int64(1), plugin.violations["missing_tag"].Get()-before["missing_tag"])
+	require.Equal(t, int64(1), plugin.fieldsCoerced.Get()-before["fields_coerced"])
+	require.Equal(t, int64(1), plugin.fieldsDropped.Get()-before["fields_dropped"])
+	require.Equal(t, int64(1), plugin.metricsQuarantine.Get()-before["metrics_quarantined"])
+}
+
+func TestStatisticsPerInstance(t *testing.T) {
+	instances := make([]*Schema, 0, 2)
+	for _, alias := range []string{"first", "second"} {
+		plugin := newPlugin(filepath.Join("testdata", "schema.json"))
+		rp := models.NewRunningProcessor(
+			processors.NewStreamingProcessorFromProcessor(plugin),
+			&models.ProcessorConfig{Name: "schema", Alias: alias},
+		)
+		require.NoError(t, rp.Init())
+		require.Equal(t, alias, plugin.alias)
+		instances = append(instances, plugin)
+	}
+	first, second := instances[0], instances[1]
+	before := second.violations["missing_tag"].Get()
+
+	tm := time.Unix(1700000000, 0)
+	first.Apply(metric.New("cpu", map[string]string{"host": "server01"}, map[string]interface{}{"usage_user": 12.5}, tm))
+
+	require.Equal(t, int64(1), first.violations["missing_tag"].Get())
+	require.Equal(t, before, second.violations["missing_tag"].Get())
+}
+
+func newPlugin(files ...string) *Schema {
+	return &Schema{
+		Files:              files,
+		UnknownMeasurement: "ignore",
+		MissingTag:         "quarantine",
+		UnknownTag:         "ignore",
+		MissingField:       "quarantine",
+		UnknownField:       "ignore",
+		TypeMismatch:       "coerce",
+		OutOfRange:         "drop_field",
+		UnitMismatch:       "quarantine",
+		QuarantineName:     "schema_quarantine",
+		Log:                testutil.Logger{},
+	}
+}
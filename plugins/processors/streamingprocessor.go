@@ -39,6 +39,12 @@ func (sp *streamingProcessor) Add(m telegraf.Metric, acc telegraf.Accumulator) e
 func (*streamingProcessor) Stop() {
 }
 
+// SetAlias forwards the alias to the wrapped processor if it implements the
+// telegraf.PluginWithAlias interface
+func (sp *streamingProcessor) SetAlias(alias string) {
+	models.SetAliasOnPlugin(sp.processor, alias)
+}
+
 // Make the streamingProcessor of type Initializer to be able
 // to call the Init method of the wrapped processor if
 // needed
@@ -0,0 +1,7 @@
+//go:build !custom || serializers || serializers.arrow
+
+package all
+
+import (
+	_ "github.com/influxdata/telegraf/plugins/serializers/arrow" // register plugin
+)
@@ -0,0 +1,92 @@
+package arrow
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/columnar"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// Serializer encodes a batch of metrics in the Apache Arrow IPC streaming
+// format.
+type Serializer struct {
+	TimestampColumn   string `toml:"arrow_timestamp_column"`
+	MeasurementColumn string `toml:"arrow_measurement_column"`
+	TypeWidening      string `toml:"arrow_type_widening"`
+	Compression       string `toml:"arrow_compression"`
+
+	builder *columnar.Builder
+	options []ipc.Option
+}
+
+func (s *Serializer) Init() error {
+	switch s.Compression {
+	case "", "none":
+		s.Compression = "none"
+	case "lz4":
+		s.options = append(s.options, ipc.WithLZ4())
+	case "zstd":
+		s.options = append(s.options, ipc.WithZstd())
+	default:
+		return fmt.Errorf("invalid 'arrow_compression' %q", s.Compression)
+	}
+
+	// Put all metrics into a single record as a batch must be serialized
+	// with a single schema to be readable as a whole
+	if s.MeasurementColumn == "" {
+		s.MeasurementColumn = "measurement"
+	}
+
+	s.builder = &columnar.Builder{
+		TimestampColumn:   s.TimestampColumn,
+		MeasurementColumn: s.MeasurementColumn,
+		TypeWidening:      s.TypeWidening,
+	}
+	return s.builder.Init()
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+// SerializeBatch writes all metrics of the batch as a single IPC stream
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	records, err := s.builder.Build(metrics)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, r := range records {
+			r.Release()
+		}
+	}()
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+	record := records[0]
+
+	var buf bytes.Buffer
+	options := append([]ipc.Option{ipc.WithSchema(record.Schema())}, s.options...)
+	writer := ipc.NewWriter(&buf, options...)
+	if err := writer.Write(record); err != nil {
+		return nil, fmt.Errorf("writing record failed: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("closing writer failed: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func init() {
+	serializers.Add("arrow",
+		func() telegraf.Serializer {
+			return &Serializer{}
+		},
+	)
+}
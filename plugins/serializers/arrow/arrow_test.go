@@ -0,0 +1,129 @@
+package arrow
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+func TestSerializeBatch(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 1.5}, time.Unix(1, 0)),
+		metric.New("mem", map[string]string{"host": "a"}, map[string]interface{}{"free": uint64(10)}, time.Unix(2, 0)),
+		metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"usage": int64(2)}, time.Unix(3, 0)),
+	}
+
+	for _, compression := range []string{"", "lz4", "zstd"} {
+		t.Run(compression, func(t *testing.T) {
+			serializer := &Serializer{Compression: compression}
+			require.NoError(t, serializer.Init())
+
+			buf, err := serializer.SerializeBatch(metrics)
+			require.NoError(t, err)
+
+			// All measurements must be contained in a single stream
+			r := bytes.NewReader(buf)
+			result := readStream(t, r)
+			require.Equal(t, []string{"timestamp", "measurement", "host", "free", "usage"}, result.names)
+			require.Equal(t, arrow.PrimitiveTypes.Float64, result.schema.Field(4).Type)
+			expected := []string{
+				`[1000000000 2000000000 3000000000]`,
+				`["cpu" "mem" "cpu"]`,
+				`["a" "a" "b"]`,
+				`[(null) 10 (null)]`,
+				`[1.5 (null) 2]`,
+			}
+			require.Equal(t, expected, result.columns)
+			require.Zero(t, r.Len())
+		})
+	}
+}
+
+func TestSerializeBatchMeasurementColumn(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"usage": 1.5}, time.Unix(1, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"free": int64(10)}, time.Unix(2, 0)),
+	}
+
+	serializer := &Serializer{MeasurementColumn: "name"}
+	require.NoError(t, serializer.Init())
+
+	buf, err := serializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	r := bytes.NewReader(buf)
+	result := readStream(t, r)
+	require.Equal(t, []string{"timestamp", "name", "free", "usage"}, result.names)
+	require.Equal(t, []string{`[1000000000 2000000000]`, `["cpu" "mem"]`, `[(null) 10]`, `[1.5 (null)]`}, result.columns)
+	require.Zero(t, r.Len())
+}
+
+func TestSerializeEmpty(t *testing.T) {
+	serializer := &Serializer{}
+	require.NoError(t, serializer.Init())
+
+	buf, err := serializer.SerializeBatch(nil)
+	require.NoError(t, err)
+	require.Empty(t, buf)
+}
+
+func TestSerializeBatchTypeConflict(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": int64(1)}, time.Unix(1, 0)),
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"value": "on"}, time.Unix(2, 0)),
+	}
+
+	serializer := &Serializer{}
+	require.NoError(t, serializer.Init())
+	_, err := serializer.SerializeBatch(metrics)
+	require.ErrorContains(t, err, "conflicting types")
+
+	serializer = &Serializer{TypeWidening: "string"}
+	require.NoError(t, serializer.Init())
+	buf, err := serializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	result := readStream(t, bytes.NewReader(buf))
+	require.Equal(t, `["1" "on"]`, result.columns[2])
+}
+
+func TestInitErrors(t *testing.T) {
+	require.ErrorContains(t, (&Serializer{Compression: "snappy"}).Init(), "invalid 'arrow_compression'")
+	require.ErrorContains(t, (&Serializer{TypeWidening: "all"}).Init(), "invalid type widening")
+}
+
+type stream struct {
+	schema  *arrow.Schema
+	names   []string
+	columns []string
+}
+
+func readStream(t *testing.T, r *bytes.Reader) stream {
+	t.Helper()
+
+	reader, err := ipc.NewReader(r)
+	require.NoError(t, err)
+	defer reader.Release()
+
+	// The reader must consume the whole stream including the end-of-stream
+	// marker and find exactly one record
+	require.True(t, reader.Next())
+	record := reader.Record()
+
+	s := stream{schema: reader.Schema()}
+	for i, f := range s.schema.Fields() {
+		s.names = append(s.names, f.Name)
+		s.columns = append(s.columns, record.Column(i).String())
+	}
+	require.False(t, reader.Next())
+	require.NoError(t, reader.Err())
+
+	return s
+}
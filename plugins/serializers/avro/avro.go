@@ -0,0 +1,571 @@
+package avro
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/schemaregistry"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// Delay before retrying a failed schema registration to avoid hammering the
+// registry on every metric
+const registerRetryDelay = 10 * time.Second
+
+// Serializer encodes metrics in Apache Avro format. If a schema registry is
+// configured, the schemas are registered and the messages are written in
+// Confluent Wire Format
+// (https://docs.confluent.io/platform/current/schema-registry/serdes-develop/index.html#wire-format).
+type Serializer struct {
+	SchemaRegistry  string          `toml:"avro_schema_registry"`
+	CaCertPath      string          `toml:"avro_schema_registry_cert"`
+	Subject         string          `toml:"avro_subject"`
+	Schema          string          `toml:"avro_schema"`
+	Namespace       string          `toml:"avro_namespace"`
+	Format          string          `toml:"avro_format"`
+	Timestamp       string          `toml:"avro_timestamp"`
+	TimestampFormat string          `toml:"avro_timestamp_format"`
+	Log             telegraf.Logger `toml:"-"`
+
+	registry   *schemaregistry.Client
+	retryDelay time.Duration
+	static     *schemaInfo
+	schemas    map[string]*schemaInfo
+	sync.Mutex
+}
+
+type schemaInfo struct {
+	schema  string
+	subject string
+	record  *recordSchema
+	codec   *goavro.Codec
+	id      int
+
+	// Registration state protected by the mutex to register each schema only
+	// once without blocking the lookup of other schemas
+	registered bool
+	err        error
+	retry      time.Time
+	sync.Mutex
+}
+
+type recordSchema struct {
+	Type      string        `json:"type"`
+	Name      string        `json:"name"`
+	Namespace string        `json:"namespace,omitempty"`
+	Fields    []fieldSchema `json:"fields"`
+}
+
+type fieldSchema struct {
+	Name    string          `json:"name"`
+	Type    interface{}     `json:"type"`
+	Default json.RawMessage `json:"default,omitempty"`
+}
+
+func (s *Serializer) Init() error {
+	switch s.Format {
+	case "":
+		s.Format = "binary"
+	case "binary", "json":
+		// Do nothing as those are valid settings
+	default:
+		return fmt.Errorf("unknown 'avro_format' %q", s.Format)
+	}
+
+	switch s.TimestampFormat {
+	case "":
+		s.TimestampFormat = "unix_ns"
+	case "unix", "unix_ms", "unix_us", "unix_ns":
+		// Valid values
+	default:
+		return fmt.Errorf("invalid timestamp format %q", s.TimestampFormat)
+	}
+
+	if s.Timestamp == "" {
+		s.Timestamp = "timestamp"
+	}
+
+	// Generated schemas differ per measurement and cannot be registered
+	// under one subject as the registry rejects incompatible versions
+	if s.Subject != "" && s.Schema == "" {
+		return errors.New("'avro_subject' requires a static 'avro_schema'")
+	}
+
+	if s.SchemaRegistry != "" {
+		if s.Format != "binary" {
+			return errors.New("schema registry can only be used with 'binary' format")
+		}
+		registry, err := schemaregistry.NewClient(s.SchemaRegistry, s.CaCertPath)
+		if err != nil {
+			return fmt.Errorf("error connecting to the schema registry %q: %w", s.SchemaRegistry, err)
+		}
+		s.registry = registry
+		s.retryDelay = registerRetryDelay
+	}
+
+	if s.Schema != "" {
+		info, err := s.newSchemaInfo(s.Schema)
+		if err != nil {
+			return fmt.Errorf("invalid schema: %w", err)
+		}
+		s.static = info
+	}
+	s.schemas = make(map[string]*schemaInfo)
+
+	return nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.serialize(nil, metric)
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	var buf []byte
+	for _, m := range metrics {
+		var err error
+		if buf, err = s.serialize(buf, m); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func (s *Serializer) serialize(buf []byte, metric telegraf.Metric) ([]byte, error) {
+	info, err := s.lookupSchema(metric)
+	if err != nil {
+		return nil, err
+	}
+
+	native, err := s.native(info.record, metric)
+	if err != nil {
+		return nil, fmt.Errorf("converting metric %q failed: %w", metric.Name(), err)
+	}
+
+	if s.Format == "json" {
+		buf, err = info.codec.TextualFromNative(buf, native)
+		if err != nil {
+			return nil, err
+		}
+		return append(buf, '\n'), nil
+	}
+
+	// Prepend the magic byte and the schema ID as mandated by the Confluent
+	// Wire Format
+	if s.registry != nil {
+		buf = append(buf, 0)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(info.id))
+	}
+	return info.codec.BinaryFromNative(buf, native)
+}
+
+// lookupSchema returns the schema to use for the given metric, either the
+// configured one or the one generated from the metric's structure. Schemas
+// are registered with the registry on first use and cached afterwards.
+func (s *Serializer) lookupSchema(metric telegraf.Metric) (*schemaInfo, error) {
+	info, err := s.cachedSchema(metric)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.registry != nil {
+		if err := s.register(info); err != nil {
+			return nil, err
+		}
+	}
+
+	return info, nil
+}
+
+func (s *Serializer) cachedSchema(metric telegraf.Metric) (*schemaInfo, error) {
+	if s.static != nil {
+		return s.static, nil
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	key := signature(metric)
+	if info, found := s.schemas[key]; found {
+		return info, nil
+	}
+
+	schema, err := s.generateSchema(metric)
+	if err != nil {
+		return nil, fmt.Errorf("generating schema for metric %q failed: %w", metric.Name(), err)
+	}
+	info, err := s.newSchemaInfo(schema)
+	if err != nil {
+		return nil, fmt.Errorf("generated schema for metric %q is invalid: %w", metric.Name(), err)
+	}
+	s.schemas[key] = info
+
+	return info, nil
+}
+
+// register adds the schema to the registry if not done yet. Failures are
+// returned for subsequent metrics until the retry delay passed.
+func (s *Serializer) register(info *schemaInfo) error {
+	info.Lock()
+	defer info.Unlock()
+
+	if info.registered {
+		return nil
+	}
+	if info.err != nil && time.Now().Before(info.retry) {
+		return info.err
+	}
+
+	id, err := s.registry.Register(info.subject, info.schema)
+	if err != nil {
+		info.err = err
+		info.retry = time.Now().Add(s.retryDelay)
+		return err
+	}
+	info.id = id
+	info.registered = true
+	info.err = nil
+
+	return nil
+}
+
+func (s *Serializer) newSchemaInfo(schema string) (*schemaInfo, error) {
+	var record recordSchema
+	if err := json.Unmarshal([]byte(schema), &record); err != nil {
+		return nil, err
+	}
+	if record.Type != "record" {
+		return nil, fmt.Errorf("expected schema of type 'record' but got %q", record.Type)
+	}
+
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := s.Subject
+	if subject == "" {
+		subject = record.Name
+		if record.Namespace != "" {
+			subject = record.Namespace + "." + record.Name
+		}
+	}
+
+	return &schemaInfo{
+		schema:  codec.Schema(),
+		subject: subject,
+		record:  &record,
+		codec:   codec,
+	}, nil
+}
+
+// signature identifies the structure of a metric, i.e. its name, tag keys
+// and field keys and types, and thus the generated schema
+func signature(metric telegraf.Metric) string {
+	var b strings.Builder
+	b.WriteString(metric.Name())
+	for _, tag := range metric.TagList() {
+		b.WriteString("\x00t")
+		b.WriteString(tag.Key)
+	}
+	fields := sortedFields(metric)
+	for _, field := range fields {
+		b.WriteString("\x00f")
+		b.WriteString(field.Key)
+		b.WriteString(":")
+		b.WriteString(avroType(field.Value))
+	}
+	return b.String()
+}
+
+func (s *Serializer) generateSchema(metric telegraf.Metric) (string, error) {
+	record := recordSchema{
+		Type:      "record",
+		Name:      sanitize(metric.Name()),
+		Namespace: s.Namespace,
+		Fields:    []fieldSchema{{Name: s.Timestamp, Type: "long"}},
+	}
+
+	seen := map[string]bool{s.Timestamp: true}
+	for _, tag := range metric.TagList() {
+		name := sanitize(tag.Key)
+		if seen[name] {
+			return "", fmt.Errorf("duplicate field name %q for tag %q", name, tag.Key)
+		}
+		seen[name] = true
+		record.Fields = append(record.Fields, fieldSchema{
+			Name:    name,
+			Type:    []interface{}{"null", "string"},
+			Default: json.RawMessage("null"),
+		})
+	}
+
+	fields := sortedFields(metric)
+	for _, field := range fields {
+		name := sanitize(field.Key)
+		if seen[name] {
+			return "", fmt.Errorf("duplicate field name %q for field %q", name, field.Key)
+		}
+		seen[name] = true
+		typ := avroType(field.Value)
+		if typ == "" {
+			return "", fmt.Errorf("unsupported type %T of field %q", field.Value, field.Key)
+		}
+		record.Fields = append(record.Fields, fieldSchema{
+			Name:    name,
+			Type:    []interface{}{"null", typ},
+			Default: json.RawMessage("null"),
+		})
+	}
+
+	schema, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	return string(schema), nil
+}
+
+// native converts the metric into the native Go representation of the given
+// record schema. Tags and fields are matched by their sanitized name,
+// metric values not contained in the schema are ignored and missing ones are
+// filled with the schema's default values.
+func (s *Serializer) native(record *recordSchema, metric telegraf.Metric) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(metric.TagList())+len(metric.FieldList()))
+	for _, tag := range metric.TagList() {
+		values[sanitize(tag.Key)] = tag.Value
+	}
+	for _, field := range metric.FieldList() {
+		values[sanitize(field.Key)] = field.Value
+	}
+	values[s.Timestamp] = metric.Time()
+
+	native := make(map[string]interface{}, len(record.Fields))
+	for _, field := range record.Fields {
+		value, found := values[field.Name]
+		if !found {
+			// Use the default value if any or null for nullable unions
+			switch {
+			case len(field.Default) > 0:
+			case nullable(field.Type):
+				native[field.Name] = nil
+			default:
+				return nil, fmt.Errorf("no value for field %q without default", field.Name)
+			}
+			continue
+		}
+		v, err := s.convert(field.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", field.Name, err)
+		}
+		native[field.Name] = v
+	}
+	return native, nil
+}
+
+// convert casts the value to the native representation of the given schema
+// type. For unions, the first member type accepting the value is used.
+func (s *Serializer) convert(schemaType, value interface{}) (interface{}, error) {
+	switch t := schemaType.(type) {
+	case string:
+		return s.convertPrimitive(t, value)
+	case []interface{}:
+		for _, member := range t {
+			v, err := s.convert(member, value)
+			if err != nil {
+				continue
+			}
+			name := typeName(member)
+			if name == "null" {
+				return nil, nil
+			}
+			return goavro.Union(name, v), nil
+		}
+		return nil, fmt.Errorf("no union member of %v accepts value %v (%T)", t, value, value)
+	case map[string]interface{}:
+		if ts, ok := value.(time.Time); ok {
+			switch t["logicalType"] {
+			case "timestamp-millis", "timestamp-micros":
+				return ts, nil
+			}
+		}
+		switch t["type"] {
+		case "enum":
+			if v, ok := value.(string); ok {
+				return v, nil
+			}
+			return nil, fmt.Errorf("cannot convert %T to enum", value)
+		case "record", "array", "map", "fixed":
+			return nil, fmt.Errorf("unsupported type %q", t["type"])
+		}
+		return s.convert(t["type"], value)
+	}
+	return nil, fmt.Errorf("unsupported schema type %v", schemaType)
+}
+
+func (s *Serializer) convertPrimitive(typ string, value interface{}) (interface{}, error) {
+	if ts, ok := value.(time.Time); ok {
+		value = s.timestamp(ts)
+	}
+
+	switch typ {
+	case "null":
+		if value == nil {
+			return nil, nil
+		}
+	case "boolean":
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+	case "int":
+		switch v := value.(type) {
+		case int64:
+			if v >= math.MinInt32 && v <= math.MaxInt32 {
+				return int32(v), nil
+			}
+		case uint64:
+			if v <= math.MaxInt32 {
+				return int32(v), nil
+			}
+		}
+	case "long":
+		switch v := value.(type) {
+		case int64:
+			return v, nil
+		case uint64:
+			if v <= math.MaxInt64 {
+				return int64(v), nil
+			}
+		}
+	case "float":
+		switch v := value.(type) {
+		case float64:
+			return float32(v), nil
+		case int64:
+			return float32(v), nil
+		case uint64:
+			return float32(v), nil
+		}
+	case "double":
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case int64:
+			return float64(v), nil
+		case uint64:
+			return float64(v), nil
+		}
+	case "string":
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+	case "bytes":
+		if v, ok := value.(string); ok {
+			return []byte(v), nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported type %q", typ)
+	}
+	return nil, fmt.Errorf("cannot convert %v (%T) to %q", value, value, typ)
+}
+
+func (s *Serializer) timestamp(ts time.Time) int64 {
+	switch s.TimestampFormat {
+	case "unix":
+		return ts.Unix()
+	case "unix_ms":
+		return ts.UnixMilli()
+	case "unix_us":
+		return ts.UnixMicro()
+	}
+	return ts.UnixNano()
+}
+
+// nullable checks if the given type is a union containing null
+func nullable(schemaType interface{}) bool {
+	if members, ok := schemaType.([]interface{}); ok {
+		for _, member := range members {
+			if typeName(member) == "null" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// typeName returns the name of the type used to identify union members
+func typeName(schemaType interface{}) string {
+	switch t := schemaType.(type) {
+	case string:
+		return t
+	case map[string]interface{}:
+		name, _ := t["type"].(string)
+		switch name {
+		case "enum", "fixed", "record":
+			name, _ = t["name"].(string)
+			if ns, ok := t["namespace"].(string); ok && ns != "" {
+				name = ns + "." + name
+			}
+		default:
+			if logical, ok := t["logicalType"].(string); ok {
+				name += "." + logical
+			}
+		}
+		return name
+	}
+	return ""
+}
+
+// sortedFields returns a copy of the metric's fields sorted by key
+func sortedFields(metric telegraf.Metric) []*telegraf.Field {
+	fields := append([]*telegraf.Field(nil), metric.FieldList()...)
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Key < fields[j].Key })
+	return fields
+}
+
+func avroType(value interface{}) string {
+	switch value.(type) {
+	case int64, uint64:
+		return "long"
+	case float64:
+		return "double"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	}
+	return ""
+}
+
+// sanitize converts the given name to a valid Avro name by replacing all
+// invalid characters by underscores
+func sanitize(name string) string {
+	if name == "" {
+		return "_"
+	}
+
+	b := []byte(name)
+	for i, c := range b {
+		valid := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || i > 0 && c >= '0' && c <= '9'
+		if !valid {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+func init() {
+	serializers.Add("avro",
+		func() telegraf.Serializer {
+			return &Serializer{}
+		},
+	)
+}
@@ -0,0 +1,429 @@
+package avro
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers/avro"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+// registry is a minimal stand-in for a Confluent schema registry
+type registry struct {
+	schemas       []string
+	subjects      map[string][]int
+	registrations int
+	sync.Mutex
+}
+
+func newRegistry() *registry {
+	return &registry{subjects: make(map[string][]int)}
+}
+
+func (r *registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	defer r.Unlock()
+
+	switch {
+	case req.Method == http.MethodPost && strings.HasPrefix(req.URL.Path, "/subjects/"):
+		subject := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/subjects/"), "/versions")
+		var body struct {
+			Schema string `json:"schema"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		if _, err := goavro.NewCodec(body.Schema); err != nil {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			fmt.Fprintf(w, `{"error_code":42201,"message":%q}`, err.Error())
+			return
+		}
+		r.registrations++
+		id := -1
+		for i, s := range r.schemas {
+			if s == body.Schema {
+				id = i + 1
+			}
+		}
+		if id < 0 {
+			r.schemas = append(r.schemas, body.Schema)
+			id = len(r.schemas)
+		}
+		r.subjects[subject] = append(r.subjects[subject], id)
+		fmt.Fprintf(w, `{"id":%d}`, id)
+	case req.Method == http.MethodGet && strings.HasPrefix(req.URL.Path, "/schemas/ids/"):
+		var id int
+		if _, err := fmt.Sscanf(req.URL.Path, "/schemas/ids/%d", &id); err != nil || id < 1 || id > len(r.schemas) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		resp, err := json.Marshal(map[string]string{"schema": r.schemas[id-1]})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if _, err := w.Write(resp); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestSerializeGeneratedSchema(t *testing.T) {
+	m := metric.New(
+		"cpu.usage",
+		map[string]string{"host": "localhost", "cpu-id": "0"},
+		map[string]interface{}{
+			"value":   42.5,
+			"count":   int64(3),
+			"big":     uint64(5),
+			"ok":      true,
+			"message": "hello",
+		},
+		time.Unix(1700000000, 123456789),
+	)
+
+	s := &Serializer{Namespace: "telegraf"}
+	require.NoError(t, s.Init())
+
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+
+	require.Len(t, s.schemas, 1)
+	var info *schemaInfo
+	for _, v := range s.schemas {
+		info = v
+	}
+	require.Equal(t, "telegraf.cpu_usage", info.subject)
+	require.JSONEq(t, `{
+		"type": "record",
+		"name": "cpu_usage",
+		"namespace": "telegraf",
+		"fields": [
+			{"name": "timestamp", "type": "long"},
+			{"name": "cpu_id", "type": ["null", "string"], "default": null},
+			{"name": "host", "type": ["null", "string"], "default": null},
+			{"name": "big", "type": ["null", "long"], "default": null},
+			{"name": "count", "type": ["null", "long"], "default": null},
+			{"name": "message", "type": ["null", "string"], "default": null},
+			{"name": "ok", "type": ["null", "boolean"], "default": null},
+			{"name": "value", "type": ["null", "double"], "default": null}
+		]
+	}`, info.schema)
+
+	native, remaining, err := info.codec.NativeFromBinary(buf)
+	require.NoError(t, err)
+	require.Empty(t, remaining)
+	expected := map[string]interface{}{
+		"timestamp": int64(1700000000123456789),
+		"cpu_id":    map[string]interface{}{"string": "0"},
+		"host":      map[string]interface{}{"string": "localhost"},
+		"big":       map[string]interface{}{"long": int64(5)},
+		"count":     map[string]interface{}{"long": int64(3)},
+		"message":   map[string]interface{}{"string": "hello"},
+		"ok":        map[string]interface{}{"boolean": true},
+		"value":     map[string]interface{}{"double": 42.5},
+	}
+	require.Equal(t, expected, native)
+}
+
+func TestSerializeSchemaRegistry(t *testing.T) {
+	reg := newRegistry()
+	server := httptest.NewServer(reg)
+	defer server.Close()
+
+	s := &Serializer{SchemaRegistry: server.URL}
+	require.NoError(t, s.Init())
+
+	m1 := metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0))
+	m2 := metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 2.0}, time.Unix(0, 0))
+	m3 := metric.New("cpu", map[string]string{"host": "c"}, map[string]interface{}{"value": 3.0, "count": int64(1)}, time.Unix(0, 0))
+
+	// Metrics with the same structure must reuse the cached schema ID
+	buf1, err := s.Serialize(m1)
+	require.NoError(t, err)
+	buf2, err := s.Serialize(m2)
+	require.NoError(t, err)
+	require.Equal(t, 1, reg.registrations)
+	require.Equal(t, byte(0), buf1[0])
+	require.Equal(t, uint32(1), binary.BigEndian.Uint32(buf1[1:5]))
+	require.Equal(t, buf1[:5], buf2[:5])
+
+	// Another structure registers a new schema version for the same subject
+	buf3, err := s.Serialize(m3)
+	require.NoError(t, err)
+	require.Equal(t, 2, reg.registrations)
+	require.Equal(t, uint32(2), binary.BigEndian.Uint32(buf3[1:5]))
+	require.Equal(t, map[string][]int{"cpu": {1, 2}}, reg.subjects)
+
+	codec, err := goavro.NewCodec(reg.schemas[1])
+	require.NoError(t, err)
+	native, _, err := codec.NativeFromBinary(buf3[5:])
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"double": 3.0}, native.(map[string]interface{})["value"])
+}
+
+func TestSerializeSchemaRegistryConcurrent(t *testing.T) {
+	reg := newRegistry()
+	server := httptest.NewServer(reg)
+	defer server.Close()
+
+	s := &Serializer{SchemaRegistry: server.URL}
+	require.NoError(t, s.Init())
+
+	// Concurrent metrics of the same structure must register the schema once
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m := metric.New("cpu", map[string]string{}, map[string]interface{}{"value": float64(i)}, time.Unix(0, 0))
+			buf, err := s.Serialize(m)
+			assert.NoError(t, err)
+			assert.Equal(t, uint32(1), binary.BigEndian.Uint32(buf[1:5]))
+		}(i)
+	}
+	wg.Wait()
+	require.Equal(t, 1, reg.registrations)
+}
+
+func TestSerializeRoundTrip(t *testing.T) {
+	server := httptest.NewServer(newRegistry())
+	defer server.Close()
+
+	s := &Serializer{
+		SchemaRegistry:  server.URL,
+		TimestampFormat: "unix_ms",
+	}
+	require.NoError(t, s.Init())
+
+	p := &avro.Parser{
+		SchemaRegistry:  server.URL,
+		Tags:            []string{"host"},
+		Fields:          []string{"value", "count"},
+		Timestamp:       "timestamp",
+		TimestampFormat: "unix_ms",
+		UnionMode:       "any",
+		Log:             testutil.Logger{},
+	}
+	require.NoError(t, p.Init())
+
+	input := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.5, "count": int64(1)}, time.UnixMilli(1700000000123)),
+		metric.New("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": 2.5, "count": int64(2)}, time.UnixMilli(1700000001123)),
+	}
+
+	actual := make([]telegraf.Metric, 0, len(input))
+	for _, m := range input {
+		buf, err := s.Serialize(m)
+		require.NoError(t, err)
+		parsed, err := p.Parse(buf)
+		require.NoError(t, err)
+		actual = append(actual, parsed...)
+	}
+	testutil.RequireMetricsEqual(t, input, actual)
+}
+
+func TestSerializeStaticSchema(t *testing.T) {
+	schema := `{
+		"type": "record",
+		"name": "Measurement",
+		"namespace": "com.example",
+		"fields": [
+			{"name": "time", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+			{"name": "host", "type": "string"},
+			{"name": "value", "type": ["null", "float", "long"]},
+			{"name": "count", "type": "int"},
+			{"name": "unit", "type": "string", "default": "percent"}
+		]
+	}`
+
+	s := &Serializer{
+		Schema:    schema,
+		Timestamp: "time",
+	}
+	require.NoError(t, s.Init())
+	require.Equal(t, "com.example.Measurement", s.static.subject)
+
+	m := metric.New(
+		"cpu",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{"value": int64(5), "count": int64(3), "ignored": "x"},
+		time.UnixMilli(1700000000123),
+	)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+
+	native, _, err := s.static.codec.NativeFromBinary(buf)
+	require.NoError(t, err)
+	expected := map[string]interface{}{
+		"time":  time.UnixMilli(1700000000123).UTC(),
+		"host":  "localhost",
+		"value": map[string]interface{}{"float": float32(5)},
+		"count": int32(3),
+		"unit":  "percent",
+	}
+	require.Equal(t, expected, native)
+
+	// Missing values without default must fail
+	m = metric.New("cpu", map[string]string{}, map[string]interface{}{"count": int64(3)}, time.Unix(0, 0))
+	_, err = s.Serialize(m)
+	require.ErrorContains(t, err, `no value for field "host" without default`)
+
+	// Values not fitting the schema type must fail
+	m = metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"count": int64(1) << 40}, time.Unix(0, 0))
+	_, err = s.Serialize(m)
+	require.ErrorContains(t, err, `cannot convert 1099511627776 (int64) to "int"`)
+
+	// Missing values of nullable unions are set to null
+	m = metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"count": int64(1)}, time.Unix(0, 0))
+	buf, err = s.Serialize(m)
+	require.NoError(t, err)
+	native, _, err = s.static.codec.NativeFromBinary(buf)
+	require.NoError(t, err)
+	require.Nil(t, native.(map[string]interface{})["value"])
+
+	m = metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"count": int64(1), "value": "text"}, time.Unix(0, 0))
+	_, err = s.Serialize(m)
+	require.ErrorContains(t, err, "no union member")
+}
+
+func TestSerializeStaticSchemaSubject(t *testing.T) {
+	reg := newRegistry()
+	server := httptest.NewServer(reg)
+	defer server.Close()
+
+	s := &Serializer{
+		SchemaRegistry: server.URL,
+		Subject:        "metrics-value",
+		Schema:         `{"type": "record", "name": "Measurement", "fields": [{"name": "value", "type": "double"}]}`,
+	}
+	require.NoError(t, s.Init())
+
+	// All measurements share the static schema and thus the configured subject
+	m1 := metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0))
+	m2 := metric.New("mem", map[string]string{}, map[string]interface{}{"value": 2.0}, time.Unix(0, 0))
+	buf, err := s.SerializeBatch([]telegraf.Metric{m1, m2})
+	require.NoError(t, err)
+	require.Len(t, buf, 2*(5+8))
+	require.Equal(t, map[string][]int{"metrics-value": {1}}, reg.subjects)
+}
+
+func TestSerializeBatchJSON(t *testing.T) {
+	s := &Serializer{
+		Format:          "json",
+		TimestampFormat: "unix",
+	}
+	require.NoError(t, s.Init())
+
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.5}, time.Unix(1700000000, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"free": uint64(1024)}, time.Unix(1700000001, 0)),
+	}
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(string(buf), "\n"), "\n")
+	require.Len(t, lines, 2)
+	require.JSONEq(t, `{"timestamp":1700000000,"host":{"string":"a"},"value":{"double":1.5}}`, lines[0])
+	require.JSONEq(t, `{"timestamp":1700000001,"free":{"long":1024}}`, lines[1])
+}
+
+func TestSerializeErrors(t *testing.T) {
+	s := &Serializer{}
+	require.NoError(t, s.Init())
+
+	m := metric.New("cpu", map[string]string{"a-b": "x"}, map[string]interface{}{"a_b": 1.0}, time.Unix(0, 0))
+	_, err := s.Serialize(m)
+	require.ErrorContains(t, err, `duplicate field name "a_b"`)
+
+	m = metric.New("cpu", map[string]string{}, map[string]interface{}{"timestamp": int64(1)}, time.Unix(0, 0))
+	_, err = s.Serialize(m)
+	require.ErrorContains(t, err, `duplicate field name "timestamp"`)
+
+	// Registry failures must be reported and only retried after a delay
+	var fail bool
+	reg := newRegistry()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, `{"error_code":409,"message":"incompatible schema"}`)
+			return
+		}
+		reg.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	s = &Serializer{SchemaRegistry: server.URL}
+	require.NoError(t, s.Init())
+
+	fail = true
+	m = metric.New("cpu", map[string]string{}, map[string]interface{}{"value": 1.0}, time.Unix(0, 0))
+	_, err = s.Serialize(m)
+	require.ErrorContains(t, err, "failed: status 409")
+
+	fail = false
+	_, err = s.Serialize(m)
+	require.ErrorContains(t, err, "failed: status 409")
+	require.Equal(t, 0, reg.registrations)
+
+	s.schemas[signature(m)].retry = time.Time{}
+	_, err = s.Serialize(m)
+	require.NoError(t, err)
+	require.Equal(t, 1, reg.registrations)
+}
+
+func TestInitErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		serializer *Serializer
+		expected   string
+	}{
+		{
+			name:       "invalid format",
+			serializer: &Serializer{Format: "xml"},
+			expected:   `unknown 'avro_format' "xml"`,
+		},
+		{
+			name:       "invalid timestamp format",
+			serializer: &Serializer{TimestampFormat: "rfc3339"},
+			expected:   `invalid timestamp format "rfc3339"`,
+		},
+		{
+			name:       "registry with json",
+			serializer: &Serializer{Format: "json", SchemaRegistry: "http://localhost:8081"},
+			expected:   "schema registry can only be used with 'binary' format",
+		},
+		{
+			name:       "subject with generated schemas",
+			serializer: &Serializer{SchemaRegistry: "http://localhost:8081", Subject: "metrics-value"},
+			expected:   "'avro_subject' requires a static 'avro_schema'",
+		},
+		{
+			name:       "non-record schema",
+			serializer: &Serializer{Schema: `{"type": "string"}`},
+			expected:   "expected schema of type 'record'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, tt.serializer.Init(), tt.expected)
+		})
+	}
+}
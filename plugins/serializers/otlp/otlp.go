@@ -0,0 +1,141 @@
+package otlp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/influxdata/influxdb-observability/common"
+	"github.com/influxdata/influxdb-observability/influx2otel"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+
+	"github.com/influxdata/telegraf"
+	common_otel "github.com/influxdata/telegraf/plugins/common/opentelemetry"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// Serializer encodes metrics as OpenTelemetry ExportMetricsServiceRequest
+// messages in protobuf or JSON encoding.
+type Serializer struct {
+	Encoding     string          `toml:"otlp_encoding"`
+	ResourceTags []string        `toml:"otlp_resource_tags"`
+	Log          telegraf.Logger `toml:"-"`
+
+	converter *influx2otel.LineProtocolToOtelMetrics
+}
+
+func (s *Serializer) Init() error {
+	switch s.Encoding {
+	case "":
+		s.Encoding = "protobuf"
+	case "protobuf", "json":
+		// Do nothing as those are valid settings
+	default:
+		return fmt.Errorf("invalid 'otlp_encoding' %q", s.Encoding)
+	}
+
+	converter, err := influx2otel.NewLineProtocolToOtelMetrics(&common_otel.Logger{Logger: s.Log})
+	if err != nil {
+		return fmt.Errorf("creating converter failed: %w", err)
+	}
+	s.converter = converter
+
+	return nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	// Group the metrics by the values of the resource tags as all metrics of
+	// a batch share the same resource attributes
+	groups := make(map[string]*resourceGroup)
+	order := make([]string, 0)
+	for _, m := range metrics {
+		resource := make(map[string]string, len(s.ResourceTags))
+		var key strings.Builder
+		for _, k := range s.ResourceTags {
+			if v, found := m.GetTag(k); found {
+				resource[k] = v
+				key.WriteString(k + "=" + v + "\x00")
+			}
+		}
+
+		group, found := groups[key.String()]
+		if !found {
+			group = &resourceGroup{
+				attributes: resource,
+				batch:      s.converter.NewBatch(),
+			}
+			groups[key.String()] = group
+			order = append(order, key.String())
+		}
+		s.addPoint(group, m)
+	}
+
+	md := pmetric.NewMetrics()
+	for _, key := range order {
+		group := groups[key]
+		groupMetrics := group.batch.GetMetrics()
+		rms := groupMetrics.ResourceMetrics()
+		for i := 0; i < rms.Len(); i++ {
+			attributes := rms.At(i).Resource().Attributes()
+			for k, v := range group.attributes {
+				attributes.PutStr(k, v)
+			}
+		}
+		rms.MoveAndAppendTo(md.ResourceMetrics())
+	}
+	if md.ResourceMetrics().Len() == 0 {
+		return nil, nil
+	}
+
+	request := pmetricotlp.NewExportRequestFromMetrics(md)
+	if s.Encoding == "json" {
+		return request.MarshalJSON()
+	}
+	return request.MarshalProto()
+}
+
+type resourceGroup struct {
+	attributes map[string]string
+	batch      *influx2otel.MetricsBatch
+}
+
+func (s *Serializer) addPoint(group *resourceGroup, m telegraf.Metric) {
+	var vType common.InfluxMetricValueType
+	switch m.Type() {
+	case telegraf.Gauge:
+		vType = common.InfluxMetricValueTypeGauge
+	case telegraf.Untyped:
+		vType = common.InfluxMetricValueTypeUntyped
+	case telegraf.Counter:
+		vType = common.InfluxMetricValueTypeSum
+	case telegraf.Histogram:
+		vType = common.InfluxMetricValueTypeHistogram
+	case telegraf.Summary:
+		vType = common.InfluxMetricValueTypeSummary
+	default:
+		s.Log.Warnf("Unrecognized metric type %v", m.Type())
+		return
+	}
+
+	// Resource tags are added to the resource instead of the data points
+	tags := m.Tags()
+	for k := range group.attributes {
+		delete(tags, k)
+	}
+
+	if err := group.batch.AddPoint(m.Name(), tags, m.Fields(), m.Time(), vType); err != nil {
+		s.Log.Warnf("Failed to add point: %v", err)
+	}
+}
+
+func init() {
+	serializers.Add("otlp",
+		func() telegraf.Serializer {
+			return &Serializer{}
+		},
+	)
+}
@@ -0,0 +1,200 @@
+package otlp
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestSerializeTypes(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+	metrics := []telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{"host": "a", "cpu": "cpu0"},
+			map[string]interface{}{"usage_idle": 98.5},
+			ts,
+			telegraf.Gauge,
+		),
+		metric.New(
+			"requests",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"counter": int64(42)},
+			ts,
+			telegraf.Counter,
+		),
+		metric.New(
+			"latency",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"count": 10.0, "sum": 4.5, "0.1": 2.0, "0.5": 7.0, "1": 10.0},
+			ts,
+			telegraf.Histogram,
+		),
+		metric.New(
+			"duration",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"count": 5.0, "sum": 2.5, "0.5": 0.4, "0.99": 0.9},
+			ts,
+			telegraf.Summary,
+		),
+	}
+
+	s := &Serializer{Log: testutil.Logger{}}
+	require.NoError(t, s.Init())
+
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	request := pmetricotlp.NewExportRequest()
+	require.NoError(t, request.UnmarshalProto(buf))
+	actual := collect(request.Metrics())
+	require.Len(t, actual, 4)
+
+	gauge := actual["cpu_usage_idle"]
+	require.Equal(t, pmetric.MetricTypeGauge, gauge.Type())
+	dp := gauge.Gauge().DataPoints().At(0)
+	require.InDelta(t, 98.5, dp.DoubleValue(), 1e-9)
+	require.Equal(t, map[string]interface{}{"host": "a", "cpu": "cpu0"}, dp.Attributes().AsRaw())
+	require.Equal(t, ts.UnixNano(), dp.Timestamp().AsTime().UnixNano())
+
+	sum := actual["requests"]
+	require.Equal(t, pmetric.MetricTypeSum, sum.Type())
+	require.True(t, sum.Sum().IsMonotonic())
+	require.Equal(t, pmetric.AggregationTemporalityCumulative, sum.Sum().AggregationTemporality())
+	require.Equal(t, int64(42), sum.Sum().DataPoints().At(0).IntValue())
+
+	histogram := actual["latency"]
+	require.Equal(t, pmetric.MetricTypeHistogram, histogram.Type())
+	hdp := histogram.Histogram().DataPoints().At(0)
+	require.Equal(t, uint64(10), hdp.Count())
+	require.InDelta(t, 4.5, hdp.Sum(), 1e-9)
+	require.Equal(t, []float64{0.1, 0.5, 1}, hdp.ExplicitBounds().AsRaw())
+	require.Equal(t, []uint64{2, 5, 3, 0}, hdp.BucketCounts().AsRaw())
+
+	summary := actual["duration"]
+	require.Equal(t, pmetric.MetricTypeSummary, summary.Type())
+	sdp := summary.Summary().DataPoints().At(0)
+	require.Equal(t, uint64(5), sdp.Count())
+	require.InDelta(t, 2.5, sdp.Sum(), 1e-9)
+	require.Equal(t, 2, sdp.QuantileValues().Len())
+}
+
+func TestSerializeResourceTags(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{"host": "a", "cpu": "cpu0"}, map[string]interface{}{"usage": 1.0}, ts, telegraf.Gauge),
+		metric.New("cpu", map[string]string{"host": "b", "cpu": "cpu0"}, map[string]interface{}{"usage": 2.0}, ts, telegraf.Gauge),
+		metric.New("cpu", map[string]string{"host": "a", "cpu": "cpu1"}, map[string]interface{}{"usage": 3.0}, ts, telegraf.Gauge),
+		metric.New("cpu", map[string]string{"cpu": "cpu2"}, map[string]interface{}{"usage": 4.0}, ts, telegraf.Gauge),
+	}
+
+	s := &Serializer{
+		ResourceTags: []string{"host"},
+		Log:          testutil.Logger{},
+	}
+	require.NoError(t, s.Init())
+
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	request := pmetricotlp.NewExportRequest()
+	require.NoError(t, request.UnmarshalProto(buf))
+
+	rms := request.Metrics().ResourceMetrics()
+	require.Equal(t, 3, rms.Len())
+
+	expected := []struct {
+		resource map[string]interface{}
+		points   []map[string]interface{}
+	}{
+		{
+			resource: map[string]interface{}{"host": "a"},
+			points:   []map[string]interface{}{{"cpu": "cpu0"}, {"cpu": "cpu1"}},
+		},
+		{
+			resource: map[string]interface{}{"host": "b"},
+			points:   []map[string]interface{}{{"cpu": "cpu0"}},
+		},
+		{
+			resource: map[string]interface{}{},
+			points:   []map[string]interface{}{{"cpu": "cpu2"}},
+		},
+	}
+	for i, e := range expected {
+		rm := rms.At(i)
+		require.Equal(t, e.resource, rm.Resource().Attributes().AsRaw())
+		dps := rm.ScopeMetrics().At(0).Metrics().At(0).Gauge().DataPoints()
+		require.Equal(t, len(e.points), dps.Len())
+		for j, attrs := range e.points {
+			require.Equal(t, attrs, dps.At(j).Attributes().AsRaw())
+		}
+	}
+}
+
+func TestSerializeJSON(t *testing.T) {
+	s := &Serializer{
+		Encoding: "json",
+		Log:      testutil.Logger{},
+	}
+	require.NoError(t, s.Init())
+
+	m := metric.New(
+		"requests",
+		map[string]string{"host": "a"},
+		map[string]interface{}{"counter": int64(42)},
+		time.Unix(1700000000, 0),
+		telegraf.Counter,
+	)
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.True(t, json.Valid(buf))
+
+	request := pmetricotlp.NewExportRequest()
+	require.NoError(t, request.UnmarshalJSON(buf))
+	actual := collect(request.Metrics())
+	require.Contains(t, actual, "requests")
+	require.Equal(t, int64(42), actual["requests"].Sum().DataPoints().At(0).IntValue())
+}
+
+func TestSerializeEmpty(t *testing.T) {
+	s := &Serializer{Log: testutil.Logger{}}
+	require.NoError(t, s.Init())
+
+	// String fields cannot be represented and are skipped
+	m := metric.New("status", map[string]string{}, map[string]interface{}{"state": "ok"}, time.Unix(0, 0))
+	buf, err := s.Serialize(m)
+	require.NoError(t, err)
+	require.Empty(t, buf)
+
+	buf, err = s.SerializeBatch(nil)
+	require.NoError(t, err)
+	require.Empty(t, buf)
+}
+
+func TestInvalidEncoding(t *testing.T) {
+	s := &Serializer{Encoding: "xml"}
+	require.ErrorContains(t, s.Init(), `invalid 'otlp_encoding' "xml"`)
+}
+
+func collect(md pmetric.Metrics) map[string]pmetric.Metric {
+	result := make(map[string]pmetric.Metric)
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		sms := rms.At(i).ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			ms := sms.At(j).Metrics()
+			for k := 0; k < ms.Len(); k++ {
+				result[ms.At(k).Name()] = ms.At(k)
+			}
+		}
+	}
+	return result
+}
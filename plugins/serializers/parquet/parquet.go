@@ -0,0 +1,99 @@
+package parquet
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/columnar"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// Serializer encodes a batch of metrics as a self-contained Apache Parquet
+// file.
+type Serializer struct {
+	TimestampColumn   string `toml:"parquet_timestamp_column"`
+	MeasurementColumn string `toml:"parquet_measurement_column"`
+	TypeWidening      string `toml:"parquet_type_widening"`
+	Compression       string `toml:"parquet_compression"`
+
+	builder *columnar.Builder
+	codec   compress.Compression
+}
+
+func (s *Serializer) Init() error {
+	switch s.Compression {
+	case "", "snappy":
+		s.Compression = "snappy"
+		s.codec = compress.Codecs.Snappy
+	case "none":
+		s.codec = compress.Codecs.Uncompressed
+	case "gzip":
+		s.codec = compress.Codecs.Gzip
+	case "zstd":
+		s.codec = compress.Codecs.Zstd
+	default:
+		return fmt.Errorf("invalid 'parquet_compression' %q", s.Compression)
+	}
+
+	// Put all metrics into a single record as a batch must be serialized
+	// with a single schema to be readable as a whole
+	if s.MeasurementColumn == "" {
+		s.MeasurementColumn = "measurement"
+	}
+
+	s.builder = &columnar.Builder{
+		TimestampColumn:   s.TimestampColumn,
+		MeasurementColumn: s.MeasurementColumn,
+		TypeWidening:      s.TypeWidening,
+	}
+	return s.builder.Init()
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	records, err := s.builder.Build(metrics)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, r := range records {
+			r.Release()
+		}
+	}()
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+	record := records[0]
+
+	var buf bytes.Buffer
+	props := parquet.NewWriterProperties(parquet.WithCompression(s.codec))
+	writer, err := pqarrow.NewFileWriter(record.Schema(), &buf, props, pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema()))
+	if err != nil {
+		return nil, fmt.Errorf("creating writer failed: %w", err)
+	}
+	if err := writer.Write(record); err != nil {
+		return nil, fmt.Errorf("writing record failed: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("closing writer failed: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func init() {
+	serializers.Add("parquet",
+		func() telegraf.Serializer {
+			return &Serializer{}
+		},
+	)
+}
@@ -0,0 +1,123 @@
+package parquet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	parsers_parquet "github.com/influxdata/telegraf/plugins/parsers/parquet"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestSerializeBatch(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"usage": 1.5, "count": int64(3), "ok": true},
+			time.Unix(1, 0),
+		),
+		metric.New(
+			"cpu",
+			map[string]string{"host": "b"},
+			map[string]interface{}{"usage": int64(2), "count": uint64(4), "state": "idle"},
+			time.Unix(2, 0),
+		),
+	}
+
+	// The count column is widened to unsigned integers which are read back
+	// from their physical signed representation by the parser
+	expected := []telegraf.Metric{
+		metric.New(
+			"cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"usage": 1.5, "count": int64(3), "ok": true},
+			time.Unix(1, 0),
+		),
+		metric.New(
+			"cpu",
+			map[string]string{"host": "b"},
+			map[string]interface{}{"usage": 2.0, "count": int64(4), "state": "idle"},
+			time.Unix(2, 0),
+		),
+	}
+
+	for _, compression := range []string{"", "none", "gzip", "zstd"} {
+		t.Run(compression, func(t *testing.T) {
+			serializer := &Serializer{Compression: compression}
+			require.NoError(t, serializer.Init())
+
+			buf, err := serializer.SerializeBatch(metrics)
+			require.NoError(t, err)
+
+			parser := parsers.Parsers["parquet"]("cpu").(*parsers_parquet.Parser)
+			parser.MeasurementColumn = "measurement"
+			parser.TagColumns = []string{"host"}
+			parser.TimestampColumn = "timestamp"
+			parser.TimestampFormat = "unix_ns"
+			require.NoError(t, parser.Init())
+
+			actual, err := parser.Parse(buf)
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, expected, actual)
+		})
+	}
+}
+
+func TestSerializeBatchMeasurementColumn(t *testing.T) {
+	metrics := []telegraf.Metric{
+		metric.New("cpu", map[string]string{}, map[string]interface{}{"usage": 1.5}, time.Unix(1, 0)),
+		metric.New("mem", map[string]string{}, map[string]interface{}{"free": int64(10)}, time.Unix(2, 0)),
+	}
+
+	// Multiple measurements must be combined into a single file by default
+	serializer := &Serializer{}
+	require.NoError(t, serializer.Init())
+	buf, err := serializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	parser := &parsers_parquet.Parser{
+		MeasurementColumn: "measurement",
+		TimestampColumn:   "timestamp",
+		TimestampFormat:   "unix_ns",
+	}
+	require.NoError(t, parser.Init())
+
+	actual, err := parser.Parse(buf)
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, metrics, actual)
+
+	serializer = &Serializer{MeasurementColumn: "name", TimestampColumn: "time"}
+	require.NoError(t, serializer.Init())
+	buf, err = serializer.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	parser = &parsers_parquet.Parser{
+		MeasurementColumn: "name",
+		TimestampColumn:   "time",
+		TimestampFormat:   "unix_ns",
+	}
+	require.NoError(t, parser.Init())
+
+	actual, err = parser.Parse(buf)
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t, metrics, actual)
+}
+
+func TestSerializeEmpty(t *testing.T) {
+	serializer := &Serializer{}
+	require.NoError(t, serializer.Init())
+
+	buf, err := serializer.SerializeBatch(nil)
+	require.NoError(t, err)
+	require.Empty(t, buf)
+}
+
+func TestInitErrors(t *testing.T) {
+	require.ErrorContains(t, (&Serializer{Compression: "lzo"}).Init(), "invalid 'parquet_compression'")
+	require.ErrorContains(t, (&Serializer{TypeWidening: "all"}).Init(), "invalid type widening")
+}